@@ -0,0 +1,154 @@
+// Command blog_bench measures the throughput of the ListBlog stream. Missing
+// posts are created first, so the server holds at least -posts blogs.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	addr := flag.String("server", "localhost:50051", "address of the blog server")
+	posts := flag.Int("posts", 100000, "number of blogs streamed, missing ones are created before the benchmark")
+	contentSize := flag.Int("content-size", 1024, "content length in bytes of created blogs")
+	workers := flag.Int("workers", 16, "concurrent CreateBlog calls while seeding")
+	batchSize := flag.Int("batch-size", 0, "requested blogs per message, 0 uses the server limit")
+	rate := flag.Int64("max-bytes-per-second", 0, "requested throughput cap, 0 means no cap")
+	runs := flag.Int("runs", 3, "number of times the stream is read")
+	token := flag.String("token", os.Getenv("BLOG_TOKEN"), "API key of the tenant sent as bearer authorization, defaults to $BLOG_TOKEN")
+	flag.Parse()
+
+	cc, err := grpc.Dial(*addr, grpc.WithInsecure(), grpc.WithPerRPCCredentials(auth.Token(*token)))
+	if err != nil {
+		log.Fatalf("Could not dial: %v", err)
+	}
+	defer cc.Close()
+	c := pb.NewBlogServiceClient(cc)
+
+	existing, err := stream(c, &pb.ListBlogRequest{})
+	if err != nil {
+		log.Fatalf("Could not list blogs: %v", err)
+	}
+	if missing := *posts - existing.blogs; missing > 0 {
+		log.Printf("Creating %d blogs...", missing)
+		if err := seed(c, missing, *contentSize, *workers); err != nil {
+			log.Fatalf("Could not create blogs: %v", err)
+		}
+		// blogs held by moderation are not listed
+		listed, err := stream(c, &pb.ListBlogRequest{})
+		if err != nil {
+			log.Fatalf("Could not list blogs: %v", err)
+		}
+		if listed.blogs < *posts {
+			log.Fatalf("Only %d of %d blogs are listed, the others are held by moderation", listed.blogs, *posts)
+		}
+	}
+
+	req := &pb.ListBlogRequest{BatchSize: int32(*batchSize), MaxBytesPerSecond: *rate}
+	for i := 1; i <= *runs; i++ {
+		r, err := stream(c, req)
+		if err != nil {
+			log.Fatalf("Could not stream blogs: %v", err)
+		}
+		secs := r.elapsed.Seconds()
+		fmt.Printf("run %d: %d blogs in %d messages, %.1f MiB in %v, %.0f blogs/s, %.2f MiB/s\n",
+			i, r.blogs, r.messages, float64(r.bytes)/(1<<20), r.elapsed.Round(time.Millisecond),
+			float64(r.blogs)/secs, float64(r.bytes)/(1<<20)/secs)
+	}
+}
+
+type result struct {
+	blogs    int
+	messages int
+	bytes    int
+	elapsed  time.Duration
+}
+
+func stream(c pb.BlogServiceClient, req *pb.ListBlogRequest) (result, error) {
+	var r result
+	start := time.Now()
+
+	s, err := c.ListBlog(context.Background(), req)
+	if err != nil {
+		return r, err
+	}
+	for {
+		res, err := s.Recv()
+		if err == io.EOF {
+			r.elapsed = time.Since(start)
+			return r, nil
+		}
+		if err != nil {
+			return r, err
+		}
+		r.messages++
+		r.blogs += len(res.GetBlogs())
+		r.bytes += proto.Size(res)
+	}
+}
+
+// words of the seeded content, picked at random so no word repeats often
+// enough for the spam stage of the moderation.
+var words = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod
+	tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation
+	ullamco laboris nisi aliquip ex ea commodo consequat duis aute irure in reprehenderit voluptate
+	velit esse cillum fugiat nulla pariatur excepteur sint occaecat cupidatat non proident sunt culpa`)
+
+func content(rnd *rand.Rand, size int) string {
+	var b strings.Builder
+	for b.Len() < size {
+		b.WriteString(words[rnd.Intn(len(words))])
+		b.WriteByte(' ')
+	}
+	return b.String()[:size]
+}
+
+func seed(c pb.BlogServiceClient, n, contentSize, workers int) error {
+	// every blog has its own author, the flood stage holds back authors
+	// creating many blogs in a short time
+	run := time.Now().UnixNano()
+
+	var next int64
+	var failed error
+	var once sync.Once
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(rnd *rand.Rand) {
+			defer wg.Done()
+			for {
+				i := atomic.AddInt64(&next, 1)
+				if i > int64(n) {
+					return
+				}
+				_, err := c.CreateBlog(context.Background(), &pb.CreateBlogRequest{Blog: &pb.Blog{
+					AuthorId: fmt.Sprintf("blog_bench_%d_%d", run, i),
+					Title:    fmt.Sprintf("Benchmark post %d", i),
+					Content:  content(rnd, contentSize),
+					Tags:     []string{"benchmark"},
+				}})
+				if err != nil {
+					once.Do(func() { failed = err })
+					return
+				}
+			}
+		}(rand.New(rand.NewSource(run + int64(w))))
+	}
+	wg.Wait()
+	return failed
+}
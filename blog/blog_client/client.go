@@ -2,75 +2,122 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/validation"
 	"google.golang.org/grpc"
-	"io"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"log"
+	"os"
+	"time"
 )
 
-func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	cc, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
-	if err != nil {
-		log.Fatalf("Could not dial: %v", err)
-	}
-	defer cc.Close()
+const usage = `Usage: blog_client [flags] <command> [command flags] [args]
 
-	c := pb.NewBlogServiceClient(cc)
+Commands:
+  create            create a blog
+  get <id>          print a blog
+  update <id>       update a blog, flags that are not set keep their value
+  delete <id>...    delete blogs
+  list              print all blogs
+  search <query>    print blogs whose title or content contains a word of the query
+  related <id>      print the blogs most similar to a blog
+  watch [id]        print changes of one or all blogs until interrupted
 
-	blog, err := c.CreateBlog(context.Background(), &pb.CreateBlogRequest{Blog: &pb.Blog{
-		AuthorId: "123",
-		Title:    "Iron Man",
-		Content:  "RDJ",
-	}})
-	if err != nil {
-		log.Fatalf("Could not create a blog: %v", err)
-	}
-	log.Println("Created blog: ", blog.GetBlog())
+Run 'blog_client <command> -h' for the flags of a command.
 
-	res, err := c.ReadBlog(context.Background(), &pb.ReadBlogRequest{BlogId: blog.Blog.Id})
-	if err != nil {
-		log.Fatalf("could not read blog: %v", err)
-	}
+Flags:
+`
+
+// client holds what every command needs to talk to the server.
+type client struct {
+	c   pb.BlogServiceClient
+	out *printer
+	// ctx carries the auth metadata
+	ctx     context.Context
+	timeout time.Duration
+}
 
-	log.Println("Read blog: ", res.GetBlog())
+var commands = map[string]func(cl *client, args []string) error{
+	"create":  (*client).create,
+	"get":     (*client).get,
+	"update":  (*client).update,
+	"delete":  (*client).delete,
+	"list":    (*client).list,
+	"search":  (*client).search,
+	"related": (*client).related,
+	"watch":   (*client).watch,
+}
 
-	updateBlog, err := c.UpdateBlog(context.Background(), &pb.UpdateBlogRequest{Blog: &pb.Blog{
-		Id:       res.Blog.Id,
-		AuthorId: "123",
-		Title:    "123",
-		Content:  "123",
-	}})
-	if err != nil {
-		log.Fatalf("Could not update blog: %v", err)
+func main() {
+	log.SetFlags(0)
+
+	addr := flag.String("server", "localhost:50051", "address of the blog server")
+	tls := flag.Bool("tls", false, "connect using TLS")
+	caFile := flag.String("ca-file", "ssl/ca.crt", "CA certificate used to verify the server when -tls is set")
+	serverName := flag.String("server-name", "", "overrides the server name verified with TLS")
+	token := flag.String("token", os.Getenv("BLOG_TOKEN"), "auth token sent as bearer authorization, defaults to $BLOG_TOKEN")
+	output := flag.String("output", "table", "output format: table, json or yaml")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a single request, watch and list are not limited")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	log.Println("Updated blog: ", updateBlog.Blog)
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		log.Printf("unknown command %q", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	deleteBlog, err := c.DeleteBlog(context.Background(), &pb.DeleteBlogRequest{BlogId: updateBlog.Blog.Id})
+	out, err := newPrinter(os.Stdout, *output)
 	if err != nil {
-		log.Fatalf("Could not delete blog: %v", err)
+		log.Fatal(err)
 	}
 
-	log.Println("Deleted blog id", deleteBlog.BlogId)
+	opts := grpc.WithInsecure()
+	if *tls {
+		creds, sslErr := credentials.NewClientTLSFromFile(*caFile, *serverName)
+		if sslErr != nil {
+			log.Fatalf("could not load certificate: %v", sslErr)
+		}
+		opts = grpc.WithTransportCredentials(creds)
+	}
 
-	stream, err := c.ListBlog(context.Background(), &pb.ListBlogRequest{})
+	cc, err := grpc.Dial(*addr, opts,
+		grpc.WithUnaryInterceptor(validation.UnaryClient()),
+		grpc.WithStreamInterceptor(validation.StreamClient()),
+	)
 	if err != nil {
-		log.Fatalf("Could not create stream of ListBlog: %v", err)
+		log.Fatalf("could not dial: %v", err)
 	}
+	defer cc.Close()
 
-	for {
-		recv, err := stream.Recv()
-		if err != nil {
-			if err == io.EOF {
-				stream.CloseSend()
-				break
-			} else {
-				log.Fatalf("unexpected error reading stream: %v", err)
-			}
-		}
+	ctx := context.Background()
+	if *token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+*token)
+	}
 
-		log.Println("Received new blog: ", recv.Blog)
+	cl := &client{c: pb.NewBlogServiceClient(cc), out: out, ctx: ctx, timeout: *timeout}
+	if err := cmd(cl, flag.Args()[1:]); err != nil {
+		cc.Close()
+		log.Fatalf("%s: %v", flag.Arg(0), err)
 	}
+}
 
+// request returns the context of a single unary call.
+func (cl *client) request(parent context.Context) (context.Context, context.CancelFunc) {
+	if cl.timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, cl.timeout)
 }
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// blogFlags are shared by create and update.
+type blogFlags struct {
+	fs          *flag.FlagSet
+	author      *string
+	title       *string
+	tags        *string
+	language    *string
+	content     *string
+	contentFile *string
+	edit        *bool
+}
+
+func newBlogFlags(name string) *blogFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return &blogFlags{
+		fs:          fs,
+		author:      fs.String("author", "", "author id"),
+		title:       fs.String("title", "", "title"),
+		tags:        fs.String("tags", "", "comma separated tags"),
+		language:    fs.String("language", "", "BCP-47 language tag of title and content"),
+		content:     fs.String("content", "", "content"),
+		contentFile: fs.String("content-file", "", "file to read the content from, - reads stdin"),
+		edit:        fs.Bool("edit", false, "open the blog in $EDITOR before saving"),
+	}
+}
+
+// apply overwrites the fields of b with the flags set on the command line.
+func (f *blogFlags) apply(b *pb.Blog) error {
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "author":
+			b.AuthorId = *f.author
+		case "title":
+			b.Title = *f.title
+		case "tags":
+			b.Tags = splitList(*f.tags)
+		case "language":
+			b.Language = *f.language
+		case "content":
+			b.Content = *f.content
+		case "content-file":
+			b.Content, err = readContent(*f.contentFile)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if *f.content != "" && *f.contentFile != "" {
+		return errors.New("-content and -content-file cannot be used together")
+	}
+
+	return nil
+}
+
+func readContent(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+func (cl *client) create(args []string) error {
+	f := newBlogFlags("create")
+	f.fs.Parse(args)
+
+	b := &pb.Blog{}
+	if err := f.apply(b); err != nil {
+		return err
+	}
+
+	if *f.edit {
+		var err error
+		if b, err = edit(b); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := cl.request(cl.ctx)
+	defer cancel()
+
+	res, err := cl.c.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: b})
+	if err != nil {
+		return err
+	}
+	return cl.out.blog(res.GetBlog())
+}
+
+func (cl *client) get(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	languages := fs.String("languages", "", "comma separated preferred BCP-47 language tags")
+	ifNoneMatch := fs.String("if-none-match", "", "etag of an earlier response, nothing is printed when it is current")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one blog id")
+	}
+
+	ctx, cancel := cl.request(cl.ctx)
+	defer cancel()
+
+	res, err := cl.c.ReadBlog(ctx, &pb.ReadBlogRequest{BlogId: fs.Arg(0), Languages: splitList(*languages), IfNoneMatch: *ifNoneMatch})
+	if err != nil {
+		return err
+	}
+
+	if cl.out.format != "table" {
+		return cl.out.structured(res)
+	}
+	if res.GetNotModified() {
+		fmt.Fprintf(cl.out.w, "Not modified (etag %s)\n", res.GetEtag())
+		return nil
+	}
+
+	if err := cl.out.blog(res.GetBlog()); err != nil {
+		return err
+	}
+
+	if nav := res.GetSeries(); nav != nil {
+		fmt.Fprintf(cl.out.w, "\nPart %d of %d of series %q (%s)\n", nav.GetPosition(), nav.GetTotal(), nav.GetSeriesTitle(), nav.GetSeriesId())
+		if nav.GetPreviousBlogId() != "" {
+			fmt.Fprintf(cl.out.w, "Previous: %s\n", nav.GetPreviousBlogId())
+		}
+		if nav.GetNextBlogId() != "" {
+			fmt.Fprintf(cl.out.w, "Next: %s\n", nav.GetNextBlogId())
+		}
+	}
+	return nil
+}
+
+func (cl *client) update(args []string) error {
+	f := newBlogFlags("update")
+	f.fs.Parse(args)
+
+	if f.fs.NArg() != 1 {
+		return errors.New("expected exactly one blog id")
+	}
+
+	b, err := cl.readOriginal(f.fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := f.apply(b); err != nil {
+		return err
+	}
+
+	if *f.edit {
+		if b, err = edit(b); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := cl.request(cl.ctx)
+	defer cancel()
+
+	res, err := cl.c.UpdateBlog(ctx, &pb.UpdateBlogRequest{Blog: b})
+	if blogerr.Reason(err) == blogerr.ReasonVersionConflict {
+		return fmt.Errorf("blog was changed by someone else while editing, run update again: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+	return cl.out.blog(res.GetBlog())
+}
+
+// readOriginal reads the blog in its original language, so a translation is
+// never written back as the original.
+func (cl *client) readOriginal(id string) (*pb.Blog, error) {
+	ctx, cancel := cl.request(cl.ctx)
+	defer cancel()
+
+	res, err := cl.c.ReadBlog(ctx, &pb.ReadBlogRequest{BlogId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	b := res.GetBlog()
+	if available := b.GetAvailableLanguages(); len(available) > 0 && available[0] != b.GetLanguage() {
+		res, err = cl.c.ReadBlog(ctx, &pb.ReadBlogRequest{BlogId: id, Languages: available[:1]})
+		if err != nil {
+			return nil, err
+		}
+		b = res.GetBlog()
+	}
+
+	// server managed fields are not sent back
+	b.AvailableLanguages = nil
+	b.CreateTime = nil
+	b.UpdateTime = nil
+	return b, nil
+}
+
+func (cl *client) delete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return errors.New("expected at least one blog id")
+	}
+
+	for _, id := range fs.Args() {
+		ctx, cancel := cl.request(cl.ctx)
+		res, err := cl.c.DeleteBlog(ctx, &pb.DeleteBlogRequest{BlogId: id})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s: %v", id, err)
+		}
+		if err := cl.out.line(res, "Deleted %s", res.GetBlogId()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cl *client) list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	languages := fs.String("languages", "", "comma separated preferred BCP-47 language tags")
+	batchSize := fs.Int("batch-size", 0, "maximum number of blogs per streamed message, 0 uses the server limit")
+	rate := fs.Int64("max-bytes-per-second", 0, "caps the stream throughput, 0 means no cap")
+	fs.Parse(args)
+
+	blogs, err := cl.listAll(cl.ctx, &pb.ListBlogRequest{
+		Languages:         splitList(*languages),
+		BatchSize:         int32(*batchSize),
+		MaxBytesPerSecond: *rate,
+	})
+	if err != nil {
+		return err
+	}
+	return cl.out.blogs(blogs)
+}
+
+func (cl *client) search(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	author := fs.String("author", "", "only blogs of this author")
+	tag := fs.String("tag", "", "only blogs with this tag")
+	languages := fs.String("languages", "", "comma separated preferred BCP-47 language tags")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: blog_client search [flags] <query>\n\nLists blogs whose original title or content contains one of the words of the query, ignoring case.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	query := strings.Join(fs.Args(), " ")
+	if query == "" && *author == "" && *tag == "" {
+		return errors.New("expected a query, -author or -tag")
+	}
+
+	blogs, err := cl.listAll(cl.ctx, &pb.ListBlogRequest{
+		Languages: splitList(*languages),
+		AuthorId:  *author,
+		Tag:       *tag,
+		Text:      query,
+	})
+	if err != nil {
+		return err
+	}
+	return cl.out.blogs(blogs)
+}
+
+func (cl *client) related(args []string) error {
+	fs := flag.NewFlagSet("related", flag.ExitOnError)
+	limit := fs.Int("limit", 5, "number of blogs, at most 50")
+	languages := fs.String("languages", "", "comma separated preferred BCP-47 language tags")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one blog id")
+	}
+
+	ctx, cancel := cl.request(cl.ctx)
+	defer cancel()
+
+	res, err := cl.c.RelatedBlogs(ctx, &pb.RelatedBlogsRequest{BlogId: fs.Arg(0), Limit: int32(*limit), Languages: splitList(*languages)})
+	if err != nil {
+		return err
+	}
+
+	if cl.out.format != "table" {
+		return cl.out.structured(res)
+	}
+
+	tw := tabwriter.NewWriter(cl.out.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tID\tTAGS\tTITLE")
+	for _, rel := range res.GetBlogs() {
+		b := rel.GetBlog()
+		fmt.Fprintf(tw, "%.3f\t%s\t%s\t%s\n", rel.GetScore(), b.GetId(), strings.Join(b.GetTags(), ","), b.GetTitle())
+	}
+	return tw.Flush()
+}
+
+func (cl *client) watch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", 5*time.Second, "how often the server is polled")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		return errors.New("expected at most one blog id")
+	}
+
+	ctx, stop := signal.NotifyContext(cl.ctx, os.Interrupt)
+	defer stop()
+
+	poll := cl.poller(fs.Arg(0))
+	seen := map[string]*pb.Blog{}
+	first := true
+	for {
+		blogs, err := poll(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]*pb.Blog, len(blogs))
+		for _, b := range blogs {
+			current[b.GetId()] = b
+			old, ok := seen[b.GetId()]
+			switch {
+			case first:
+			case !ok:
+				err = cl.out.event("created", b)
+			case !old.GetUpdateTime().AsTime().Equal(b.GetUpdateTime().AsTime()):
+				err = cl.out.event("updated", b)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for id, b := range seen {
+			if _, ok := current[id]; !ok {
+				if err := cl.out.event("deleted", b); err != nil {
+					return err
+				}
+			}
+		}
+		seen = current
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// poller returns a poll of the watched blog, or of every blog when id is
+// empty. The blog is read with the etag of the last read, an unchanged blog
+// is answered with not_modified and its polls are not recorded as views.
+func (cl *client) poller(id string) func(ctx context.Context) ([]*pb.Blog, error) {
+	if id == "" {
+		return func(ctx context.Context) ([]*pb.Blog, error) {
+			return cl.listAll(ctx, &pb.ListBlogRequest{})
+		}
+	}
+
+	var last *pb.ReadBlogResponse
+	return func(ctx context.Context) ([]*pb.Blog, error) {
+		rctx, cancel := cl.request(ctx)
+		defer cancel()
+
+		res, err := cl.c.ReadBlog(rctx, &pb.ReadBlogRequest{BlogId: id, IfNoneMatch: last.GetEtag()})
+		if status.Code(err) == codes.NotFound {
+			last = nil
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !res.GetNotModified() {
+			last = res
+		}
+		return []*pb.Blog{last.GetBlog()}, nil
+	}
+}
+
+func (cl *client) listAll(ctx context.Context, req *pb.ListBlogRequest) ([]*pb.Blog, error) {
+	stream, err := cl.c.ListBlog(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var blogs []*pb.Blog
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return blogs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		blogs = append(blogs, res.GetBlogs()...)
+	}
+}
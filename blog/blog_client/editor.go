@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const separator = "---"
+
+var errNoChanges = errors.New("no changes made in the editor")
+
+// edit opens the blog in $EDITOR as a header followed by the content and
+// returns the blog parsed back from the saved file.
+func edit(b *pb.Blog) (*pb.Blog, error) {
+	f, err := os.CreateTemp("", "blog-*.md")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	original := format(b)
+	if _, err := f.WriteString(original); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// EDITOR may carry arguments, e.g. "code --wait"
+	args := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %q failed: %v", editor, err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == original {
+		return nil, errNoChanges
+	}
+
+	return parse(bytes.NewReader(data), b)
+}
+
+func format(b *pb.Blog) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Title: %s\n", b.GetTitle())
+	fmt.Fprintf(&sb, "Author: %s\n", b.GetAuthorId())
+	fmt.Fprintf(&sb, "Tags: %s\n", strings.Join(b.GetTags(), ", "))
+	fmt.Fprintf(&sb, "Language: %s\n", b.GetLanguage())
+	sb.WriteString(separator + "\n")
+	sb.WriteString(b.GetContent())
+	return sb.String()
+}
+
+// parse reads the format written by format, fields missing from the header keep the values of base.
+func parse(r io.Reader, base *pb.Blog) (*pb.Blog, error) {
+	b := &pb.Blog{
+		Id:       base.GetId(),
+		AuthorId: base.GetAuthorId(),
+		Title:    base.GetTitle(),
+		Tags:     base.GetTags(),
+		Language: base.GetLanguage(),
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if strings.TrimRight(line, "\r\n") == separator {
+			break
+		}
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing %q line between the header and the content", separator)
+		}
+
+		key, value, ok := cut(line, ":")
+		if !ok {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("invalid header line %q", strings.TrimSpace(line))
+		}
+
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "title":
+			b.Title = value
+		case "author":
+			b.AuthorId = value
+		case "tags":
+			b.Tags = splitList(value)
+		case "language":
+			b.Language = value
+		default:
+			return nil, fmt.Errorf("unknown header %q", strings.TrimSpace(key))
+		}
+	}
+
+	content, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	b.Content = string(content)
+
+	return b, nil
+}
+
+func cut(s, sep string) (string, string, bool) {
+	i := strings.Index(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
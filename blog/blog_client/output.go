@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printer writes blogs as a table, JSON or YAML.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "table", "json", "yaml":
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, use table, json or yaml", format)
+	}
+}
+
+// blog prints a single blog with its content.
+func (p *printer) blog(b *pb.Blog) error {
+	if p.format != "table" {
+		return p.structured(b)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", b.GetId())
+	fmt.Fprintf(tw, "Author:\t%s\n", b.GetAuthorId())
+	fmt.Fprintf(tw, "Title:\t%s\n", b.GetTitle())
+	fmt.Fprintf(tw, "Language:\t%s (available: %s)\n", b.GetLanguage(), strings.Join(b.GetAvailableLanguages(), ", "))
+	fmt.Fprintf(tw, "Tags:\t%s\n", strings.Join(b.GetTags(), ", "))
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(b.GetCreateTime().AsTime()))
+	fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(b.GetUpdateTime().AsTime()))
+	if m := b.GetModeration(); m.GetState() == pb.Moderation_PENDING || m.GetState() == pb.Moderation_REJECTED {
+		fmt.Fprintf(tw, "Moderation:\t%s %s\n", strings.ToLower(m.GetState().String()), m.GetNote())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(p.w, "\n%s\n", b.GetContent())
+	return err
+}
+
+// blogs prints a list of blogs without their content.
+func (p *printer) blogs(blogs []*pb.Blog) error {
+	if p.format != "table" {
+		list := make([]interface{}, 0, len(blogs))
+		for _, b := range blogs {
+			v, err := generic(b)
+			if err != nil {
+				return err
+			}
+			list = append(list, v)
+		}
+		return p.encode(list)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tAUTHOR\tLANGUAGE\tTAGS\tUPDATED\tTITLE")
+	for _, b := range blogs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			b.GetId(), b.GetAuthorId(), b.GetLanguage(), strings.Join(b.GetTags(), ","),
+			formatTime(b.GetUpdateTime().AsTime()), b.GetTitle())
+	}
+	return tw.Flush()
+}
+
+// event prints a single line describing a change seen by watch.
+func (p *printer) event(kind string, b *pb.Blog) error {
+	if p.format == "table" {
+		_, err := fmt.Fprintf(p.w, "%s\t%s\t%s\t%s\n", formatTime(time.Now()), kind, b.GetId(), b.GetTitle())
+		return err
+	}
+
+	v, err := generic(b)
+	if err != nil {
+		return err
+	}
+	return p.encode(map[string]interface{}{"event": kind, "blog": v})
+}
+
+// line prints a plain message in table format and the message itself otherwise.
+func (p *printer) line(m proto.Message, format string, args ...interface{}) error {
+	if p.format != "table" {
+		return p.structured(m)
+	}
+	_, err := fmt.Fprintf(p.w, format+"\n", args...)
+	return err
+}
+
+func (p *printer) structured(m proto.Message) error {
+	v, err := generic(m)
+	if err != nil {
+		return err
+	}
+	return p.encode(v)
+}
+
+func (p *printer) encode(v interface{}) error {
+	if p.format == "yaml" {
+		enc := yaml.NewEncoder(p.w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// generic converts a message into plain maps following the protobuf JSON mapping.
+func generic(m proto.Message) (interface{}, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.Unix() <= 0 {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"flag"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"google.golang.org/grpc"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+)
+
+//go:embed theme/*.html
+var defaultTheme embed.FS
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	addr := flag.String("server", "localhost:50051", "address of the blog server")
+	out := flag.String("out", "site", "directory the site is written to")
+	theme := flag.String("theme", "", "directory with index.html, blog.html, list.html and base.html templates, the built-in theme is used when empty")
+	siteURL := flag.String("site-url", "http://localhost:8080", "public URL the site is hosted at, used in sitemap.xml")
+	title := flag.String("title", "Blog", "title of the site")
+	full := flag.Bool("full", false, "rewrite every page, even when it did not change")
+	token := flag.String("token", os.Getenv("BLOG_TOKEN"), "API key of the tenant sent as bearer authorization, defaults to $BLOG_TOKEN")
+	flag.Parse()
+
+	var themeFS fs.FS
+	var err error
+	if *theme == "" {
+		themeFS, err = fs.Sub(defaultTheme, "theme")
+	} else {
+		themeFS = os.DirFS(*theme)
+	}
+	if err != nil {
+		log.Fatalf("Could not open theme: %v", err)
+	}
+
+	cc, err := grpc.Dial(*addr, grpc.WithInsecure(), grpc.WithPerRPCCredentials(auth.Token(*token)))
+	if err != nil {
+		log.Fatalf("Could not dial: %v", err)
+	}
+	defer cc.Close()
+
+	blogs, err := listBlogs(pb.NewBlogServiceClient(cc))
+	if err != nil {
+		log.Fatalf("Could not list blogs: %v", err)
+	}
+	log.Printf("Exporting %d blogs to %s", len(blogs), *out)
+
+	s, err := newSite(*out, *siteURL, *title, themeFS)
+	if err != nil {
+		log.Fatalf("Could not load site: %v", err)
+	}
+
+	stats, err := s.build(blogs, *full)
+	if err != nil {
+		log.Fatalf("Could not build site: %v", err)
+	}
+	log.Printf("Written %d pages, %d unchanged, %d removed", stats.written, stats.unchanged, stats.removed)
+}
+
+func listBlogs(c pb.BlogServiceClient) ([]*pb.Blog, error) {
+	stream, err := c.ListBlog(context.Background(), &pb.ListBlogRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	var blogs []*pb.Blog
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return blogs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		blogs = append(blogs, res.GetBlogs()...)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// manifestFile keeps the hash of every written page, so unchanged pages are not rewritten.
+const manifestFile = ".manifest.json"
+
+type site struct {
+	out      string
+	url      string
+	title    string
+	tmpl     *template.Template
+	manifest map[string]string
+}
+
+type siteInfo struct {
+	Title string
+	URL   string
+}
+
+// page is the data every template is executed with.
+type page struct {
+	Site siteInfo
+	// Root is the relative path from the page to the root of the site.
+	Root  string
+	Title string
+	Blog  *pb.Blog
+	Blogs []*pb.Blog
+}
+
+// With returns a copy of the page for rendering a single blog of a list.
+func (p page) With(b *pb.Blog) page {
+	p.Blog = b
+	return p
+}
+
+type buildStats struct {
+	written, unchanged, removed int
+}
+
+func newSite(out, url, title string, theme fs.FS) (*site, error) {
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"slug": slug,
+		"date": func(t *timestamppb.Timestamp) string { return t.AsTime().Format("2006-01-02") },
+	}).ParseFS(theme, "*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"index.html", "blog.html", "list.html"} {
+		if tmpl.Lookup(name) == nil {
+			return nil, fmt.Errorf("theme is missing %s", name)
+		}
+	}
+
+	s := &site{
+		out:      out,
+		url:      strings.TrimSuffix(url, "/"),
+		title:    title,
+		tmpl:     tmpl,
+		manifest: map[string]string{},
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, manifestFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.manifest); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", manifestFile, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *site) build(blogs []*pb.Blog, full bool) (buildStats, error) {
+	sort.SliceStable(blogs, func(i, j int) bool {
+		return blogs[i].GetCreateTime().AsTime().After(blogs[j].GetCreateTime().AsTime())
+	})
+
+	pages := map[string][]byte{}
+	info := siteInfo{Title: s.title, URL: s.url}
+
+	err := s.render(pages, "index.html", "index.html", page{Site: info, Blogs: blogs})
+	if err != nil {
+		return buildStats{}, err
+	}
+
+	byTag := map[string][]*pb.Blog{}
+	byAuthor := map[string][]*pb.Blog{}
+	for _, b := range blogs {
+		err := s.render(pages, "blogs/"+b.GetId()+".html", "blog.html", page{Site: info, Root: "../", Title: b.GetTitle(), Blog: b})
+		if err != nil {
+			return buildStats{}, err
+		}
+		for _, tag := range b.GetTags() {
+			byTag[tag] = append(byTag[tag], b)
+		}
+		byAuthor[b.GetAuthorId()] = append(byAuthor[b.GetAuthorId()], b)
+	}
+
+	for tag, list := range byTag {
+		err := s.render(pages, "tags/"+slug(tag)+".html", "list.html", page{Site: info, Root: "../", Title: "#" + tag, Blogs: list})
+		if err != nil {
+			return buildStats{}, err
+		}
+	}
+
+	for author, list := range byAuthor {
+		err := s.render(pages, "authors/"+slug(author)+".html", "list.html", page{Site: info, Root: "../", Title: "Blogs by " + author, Blogs: list})
+		if err != nil {
+			return buildStats{}, err
+		}
+	}
+
+	sitemap, err := s.sitemap(blogs, byTag, byAuthor)
+	if err != nil {
+		return buildStats{}, err
+	}
+	pages["sitemap.xml"] = sitemap
+
+	return s.write(pages, full)
+}
+
+func (s *site) render(pages map[string][]byte, path, name string, p page) error {
+	var buf bytes.Buffer
+	if err := s.tmpl.ExecuteTemplate(&buf, name, p); err != nil {
+		return fmt.Errorf("could not render %s: %v", path, err)
+	}
+	pages[path] = buf.Bytes()
+	return nil
+}
+
+// write only touches pages whose content changed since the last build and
+// removes pages of blogs, tags and authors that are gone.
+func (s *site) write(pages map[string][]byte, full bool) (buildStats, error) {
+	var stats buildStats
+	manifest := make(map[string]string, len(pages))
+
+	for path, data := range pages {
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		manifest[path] = hash
+
+		target := filepath.Join(s.out, filepath.FromSlash(path))
+		if !full && s.manifest[path] == hash {
+			if _, err := os.Stat(target); err == nil {
+				stats.unchanged++
+				continue
+			}
+		}
+
+		if err := writeFile(target, data); err != nil {
+			return stats, err
+		}
+		stats.written++
+	}
+
+	for path := range s.manifest {
+		if _, ok := manifest[path]; ok {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.out, filepath.FromSlash(path)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stats, err
+		}
+		stats.removed++
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return stats, err
+	}
+	if err := writeFile(filepath.Join(s.out, manifestFile), data); err != nil {
+		return stats, err
+	}
+	s.manifest = manifest
+
+	return stats, nil
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+func (s *site) sitemap(blogs []*pb.Blog, byTag, byAuthor map[string][]*pb.Blog) ([]byte, error) {
+	set := sitemapURLSet{}
+	set.URLs = append(set.URLs, sitemapURL{Loc: s.url + "/index.html", LastMod: lastMod(blogs)})
+	for _, b := range blogs {
+		set.URLs = append(set.URLs, sitemapURL{Loc: s.url + "/blogs/" + b.GetId() + ".html", LastMod: lastMod([]*pb.Blog{b})})
+	}
+	for _, tag := range sortedKeys(byTag) {
+		set.URLs = append(set.URLs, sitemapURL{Loc: s.url + "/tags/" + url.PathEscape(slug(tag)) + ".html", LastMod: lastMod(byTag[tag])})
+	}
+	for _, author := range sortedKeys(byAuthor) {
+		set.URLs = append(set.URLs, sitemapURL{Loc: s.url + "/authors/" + url.PathEscape(slug(author)) + ".html", LastMod: lastMod(byAuthor[author])})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(set); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func lastMod(blogs []*pb.Blog) string {
+	var latest time.Time
+	for _, b := range blogs {
+		if t := b.GetUpdateTime().AsTime(); t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return latest.UTC().Format("2006-01-02")
+}
+
+func sortedKeys(m map[string][]*pb.Blog) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// slug makes a tag or author id safe to use as a file name. Letters and
+// digits of every script are kept, a name that had to be changed gets a
+// hash of itself, so "C++" and "c--" or "Ann" and "ann" do not share a page.
+// The dot before the hash is never kept, so no unchanged name looks hashed.
+func slug(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	if s != "" && b.String() == s {
+		return s
+	}
+	if b.Len() == 0 {
+		b.WriteRune('-')
+	}
+	sum := sha256.Sum256([]byte(s))
+	return b.String() + "." + hex.EncodeToString(sum[:6])
+}
+
+// writeFile replaces the file atomically, so a sync to object storage never uploads a partial page.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
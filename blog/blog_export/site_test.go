@@ -0,0 +1,84 @@
+package main
+
+import (
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSlug(t *testing.T) {
+	for _, name := range []string{"go", "grpc-go", "snake_case", "żółw", "日本"} {
+		if got := slug(name); got != name {
+			t.Errorf("slug(%q) = %q, want the name unchanged", name, got)
+		}
+	}
+
+	seen := map[string]string{}
+	for _, name := range []string{"żółw", "ćółw", "C++", "c--", "c-.", "Ann", "ann", "a b", "a-b", "", "-", "../etc"} {
+		s := slug(name)
+		if other, ok := seen[s]; ok {
+			t.Errorf("%q and %q share slug %q", name, other, s)
+		}
+		seen[s] = name
+		if strings.ContainsAny(s, "/\\ ") {
+			t.Errorf("slug(%q) = %q is not a safe file name", name, s)
+		}
+	}
+	if slug("C++") != slug("C++") {
+		t.Error("slug is not stable")
+	}
+}
+
+func TestBuildKeepsCollidingTagsApart(t *testing.T) {
+	theme, err := fs.Sub(defaultTheme, "theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	s, err := newSite(out, "https://example.com", "test", theme)
+	if err != nil {
+		t.Fatalf("newSite: %v", err)
+	}
+
+	blogs := []*pb.Blog{
+		{Id: "1", AuthorId: "Ann", Title: "one", Tags: []string{"c++"}},
+		{Id: "2", AuthorId: "ann", Title: "two", Tags: []string{"c--"}},
+		{Id: "3", AuthorId: "ann", Title: "three", Tags: []string{"żółw", "ćółw"}},
+	}
+	if _, err := s.build(blogs, true); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	for _, dir := range []string{"tags", "authors"} {
+		entries, err := os.ReadDir(filepath.Join(out, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]int{"tags": 4, "authors": 2}[dir]
+		if len(entries) != want {
+			t.Errorf("%s has %d pages, want %d", dir, len(entries), want)
+		}
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(sitemap), "/tags/"); n != 4 {
+		t.Errorf("sitemap lists %d tag pages, want 4", n)
+	}
+	if !strings.Contains(string(sitemap), "/tags/%C5%BC%C3%B3%C5%82w.html") {
+		t.Errorf("sitemap does not escape the tag żółw:\n%s", sitemap)
+	}
+
+	page, err := os.ReadFile(filepath.Join(out, "blogs", "1.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link := "tags/" + slug("c++") + ".html"; !strings.Contains(string(page), link) {
+		t.Errorf("blog page does not link %s:\n%s", link, page)
+	}
+}
@@ -0,0 +1,166 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// BatchSize is the number of events written at once.
+	BatchSize = 500
+	// FlushInterval bounds how long an event waits in a partially filled batch.
+	FlushInterval = 2 * time.Second
+	// QueueSize is the number of events buffered before new ones are dropped.
+	QueueSize = 10000
+)
+
+// Views records view events asynchronously and answers read statistics.
+// Events are append-only and deduplicated per viewer, blog and hour. Every
+// event belongs to the tenant of its blog, statistics never mix tenants.
+type Views struct {
+	Store storage.ViewStore
+
+	events chan model.ViewEvent
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	paused func() bool
+}
+
+func New(store storage.ViewStore) *Views {
+	v := &Views{
+		Store:  store,
+		events: make(chan model.ViewEvent, QueueSize),
+		done:   make(chan struct{}),
+	}
+	go v.run()
+	return v
+}
+
+// Record queues a view and never blocks, when the queue is full the view is dropped.
+func (v *Views) Record(tenantID string, blogID primitive.ObjectID, viewer string, t time.Time) {
+	t = t.UTC()
+	e := model.ViewEvent{
+		ID:       fmt.Sprintf("%s:%s:%s", blogID.Hex(), viewer, t.Truncate(time.Hour).Format("2006010215")),
+		TenantID: tenantID,
+		BlogID:   blogID,
+		Viewer:   viewer,
+		Time:     t,
+	}
+
+	// requests still running when the server stops may record after Close
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.closed {
+		return
+	}
+	select {
+	case v.events <- e:
+	default:
+		log.Printf("View queue is full, dropping view of %s", blogID.Hex())
+	}
+}
+
+// PauseWhile holds the writes while paused returns true, e.g. in read-only
+// mode. Views keep being queued until the queue is full.
+func (v *Views) PauseWhile(paused func() bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.paused = paused
+}
+
+func (v *Views) isPaused() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.paused != nil && v.paused()
+}
+
+// Close flushes queued views and stops the background writer, views recorded
+// afterwards are dropped.
+func (v *Views) Close() {
+	v.mu.Lock()
+	if !v.closed {
+		v.closed = true
+		close(v.events)
+	}
+	v.mu.Unlock()
+	<-v.done
+}
+
+func (v *Views) run() {
+	defer close(v.done)
+
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.ViewEvent, 0, BatchSize)
+	// seen skips duplicates before they reach the database, the storage skips the rest
+	seen := make(map[string]bool)
+	hour := time.Now().UTC().Truncate(time.Hour)
+
+	flush := func() {
+		if len(batch) == 0 || v.isPaused() {
+			return
+		}
+		v.write(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		// a full batch waits for the writes to resume, new views queue up behind it
+		events := v.events
+		if len(batch) >= BatchSize {
+			events = nil
+		}
+		select {
+		case e, ok := <-events:
+			if !ok {
+				flush()
+				if len(batch) > 0 {
+					log.Printf("Dropping %d view events, writes are paused", len(batch))
+				}
+				return
+			}
+			if h := e.Time.Truncate(time.Hour); h.After(hour) {
+				hour = h
+				seen = make(map[string]bool)
+			}
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			batch = append(batch, e)
+			if len(batch) >= BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (v *Views) write(batch []model.ViewEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := v.Store.InsertViews(ctx, batch); err != nil {
+		log.Printf("Could not write %d view events: %v", len(batch), err)
+	}
+}
+
+// Daily returns total and unique views of a blog of the tenant of ctx per UTC day in [from, to).
+func (v *Views) Daily(ctx context.Context, blogID primitive.ObjectID, from, to time.Time) ([]storage.DailyViews, error) {
+	return v.Store.DailyViews(ctx, blogID, from, to)
+}
+
+// Top returns the most viewed blogs of the tenant of ctx in [from, to).
+func (v *Views) Top(ctx context.Context, from, to time.Time, limit int) ([]storage.BlogViews, error) {
+	return v.Store.TopViews(ctx, from, to, limit)
+}
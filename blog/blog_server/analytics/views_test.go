@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStore counts the view events written.
+type fakeStore struct {
+	storage.ViewStore
+
+	mu      sync.Mutex
+	written int
+}
+
+func (s *fakeStore) InsertViews(ctx context.Context, events []model.ViewEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written += len(events)
+	return nil
+}
+
+func (s *fakeStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.written
+}
+
+// record records n views of distinct viewers.
+func record(v *Views, n int) {
+	blog := primitive.NewObjectID()
+	for i := 0; i < n; i++ {
+		v.Record("t1", blog, primitive.NewObjectID().Hex(), time.Now())
+	}
+}
+
+func TestPausedViewsAreHeld(t *testing.T) {
+	store := &fakeStore{}
+	v := New(store)
+	var paused int32 = 1
+	v.PauseWhile(func() bool { return atomic.LoadInt32(&paused) == 1 })
+
+	// more than a batch, the rest waits in the queue
+	record(v, BatchSize+10)
+	time.Sleep(50 * time.Millisecond)
+	if n := store.count(); n != 0 {
+		t.Fatalf("%d views were written while paused", n)
+	}
+
+	atomic.StoreInt32(&paused, 0)
+	v.Close()
+	if n := store.count(); n != BatchSize+10 {
+		t.Fatalf("%d views were written after resuming, want %d", n, BatchSize+10)
+	}
+}
+
+func TestRecordAfterClose(t *testing.T) {
+	store := &fakeStore{}
+	v := New(store)
+	record(v, 1)
+	v.Close()
+	v.Close()
+
+	record(v, 1)
+	if n := store.count(); n != 1 {
+		t.Fatalf("%d views were written, want 1", n)
+	}
+}
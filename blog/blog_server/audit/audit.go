@@ -0,0 +1,80 @@
+// Package audit appends a record of every successful change to the audit log
+// of the storage. Records are only ever appended, the server has no code path
+// that updates or deletes them.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"log"
+	"time"
+)
+
+// Entry is the change a handler reports, the rest of a record comes from the request.
+type Entry struct {
+	TenantID string
+	BlogID   string
+	SeriesID string
+	// Before and After are snapshots of the changed resource, nil when it did
+	// not exist before or does not exist after the change.
+	Before interface{}
+	After  interface{}
+}
+
+type Log struct {
+	Store storage.AuditStore
+}
+
+func New(store storage.AuditStore) *Log {
+	return &Log{Store: store}
+}
+
+// Record appends the entry. It must be called in the Tx of the change the
+// entry describes, so a change is never stored without its record.
+func (l *Log) Record(ctx context.Context, e Entry) error {
+	rec := model.AuditRecord{
+		TenantID:   e.TenantID,
+		BlogID:     e.BlogID,
+		SeriesID:   e.SeriesID,
+		BeforeHash: Hash(e.Before),
+		AfterHash:  Hash(e.After),
+		Time:       time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		rec.Principal = p.Name
+	}
+	if m, ok := grpc.Method(ctx); ok {
+		rec.Method = m
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		rec.Peer = p.Addr.String()
+	}
+	return l.Store.AppendAudit(ctx, &rec)
+}
+
+// List calls fn for every matching record, oldest first.
+func (l *Log) List(ctx context.Context, f storage.AuditFilter, fn func(rec *model.AuditRecord) error) error {
+	return l.Store.ListAudit(ctx, f, fn)
+}
+
+// Hash returns the hex sha256 of the JSON encoding of v, or "" for nil. JSON
+// sorts map keys, so equal snapshots always have equal hashes.
+func Hash(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Could not encode audit snapshot: %v", err)
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
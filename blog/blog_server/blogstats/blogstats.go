@@ -0,0 +1,165 @@
+// Package blogstats caches the aggregations of the blogs shown on dashboards.
+// A query is aggregated when it is first asked and again in the background
+// every Interval while it is asked, so dashboards read results at most about
+// one Interval old without running the aggregation themselves. Queries not
+// asked for MaxIdle are dropped.
+package blogstats
+
+import (
+	"context"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"golang.org/x/sync/singleflight"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInterval   = 5 * time.Minute
+	DefaultMaxIdle    = time.Hour
+	DefaultMaxEntries = 1000
+)
+
+type Cache struct {
+	Store    storage.StatsStore
+	Interval time.Duration
+	MaxIdle  time.Duration
+	// MaxEntries bounds the cached queries, the one asked least recently is dropped first.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[storage.StatsQuery]*entry
+	loads   singleflight.Group
+}
+
+type entry struct {
+	stats      *storage.BlogStats
+	computedAt time.Time
+	askedAt    time.Time
+}
+
+func New(store storage.StatsStore) *Cache {
+	return &Cache{
+		Store:      store,
+		Interval:   DefaultInterval,
+		MaxIdle:    DefaultMaxIdle,
+		MaxEntries: DefaultMaxEntries,
+		entries:    make(map[storage.StatsQuery]*entry),
+	}
+}
+
+// Get returns the statistics of q and when they were aggregated. The result
+// is shared, callers must not change it.
+func (c *Cache) Get(ctx context.Context, q storage.StatsQuery) (*storage.BlogStats, time.Time, error) {
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.entries[q]; ok {
+		e.askedAt = now
+		c.mu.Unlock()
+		return e.stats, e.computedAt, nil
+	}
+	c.mu.Unlock()
+
+	// concurrent misses of a query aggregate it once
+	v, err, _ := c.loads.Do(fmt.Sprintf("%+v", q), func() (interface{}, error) {
+		return c.compute(ctx, q, now)
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	e := v.(*entry)
+	return e.stats, e.computedAt, nil
+}
+
+// Flush drops every cached query and returns how many there were, the next
+// Get of a query aggregates it again.
+func (c *Cache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.entries)
+	c.entries = make(map[storage.StatsQuery]*entry)
+	return n
+}
+
+// Run refreshes the cached queries every Interval until ctx is cancelled.
+func (c *Cache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		c.refresh(ctx)
+	}
+}
+
+// refresh aggregates the queries one after another, so the database is not
+// hit by all of them at once. A failed query keeps its previous result.
+func (c *Cache) refresh(ctx context.Context) {
+	now := time.Now()
+	var queries []storage.StatsQuery
+	c.mu.Lock()
+	for q, e := range c.entries {
+		if now.Sub(e.askedAt) > c.MaxIdle {
+			delete(c.entries, q)
+			continue
+		}
+		if now.Sub(e.computedAt) >= c.Interval/2 {
+			queries = append(queries, q)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, q := range queries {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := c.compute(ctx, q, time.Time{}); err != nil && ctx.Err() == nil {
+			log.Printf("Could not refresh blog statistics of %+v: %v", q, err)
+		}
+	}
+}
+
+// compute aggregates q and caches the result. askedAt of zero keeps the time
+// the cached query was asked last, a dropped query is not cached again.
+func (c *Cache) compute(ctx context.Context, q storage.StatsQuery, askedAt time.Time) (*entry, error) {
+	stats, err := c.Store.BlogStats(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{stats: stats, computedAt: time.Now().UTC().Truncate(time.Millisecond), askedAt: askedAt}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old, ok := c.entries[q]
+	switch {
+	case askedAt.IsZero() && !ok:
+		return e, nil
+	case askedAt.IsZero():
+		e.askedAt = old.askedAt
+	case ok && old.askedAt.After(askedAt):
+		e.askedAt = old.askedAt
+	}
+	c.entries[q] = e
+	c.evict()
+	return e, nil
+}
+
+// evict drops the queries asked least recently while there are more than MaxEntries.
+func (c *Cache) evict() {
+	for c.MaxEntries > 0 && len(c.entries) > c.MaxEntries {
+		var oldest storage.StatsQuery
+		var oldestAt time.Time
+		for q, e := range c.entries {
+			if oldestAt.IsZero() || e.askedAt.Before(oldestAt) {
+				oldest, oldestAt = q, e.askedAt
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
@@ -0,0 +1,304 @@
+// Package cache puts a read-through cache of blogs in front of the storage.
+// Blogs are cached in an in-process LRU and, when configured, a cache shared
+// by the replicas of the server. Concurrent misses of one blog load it from
+// the storage once.
+//
+// A change of a blog removes it from both caches when the change is made and
+// again once its transaction is over, and a load that raced with the change
+// is not cached. Readers of this process never see a blog older than the last
+// change they could have observed. Other replicas may serve a blog from their
+// LRU for up to its TTL after a change.
+package cache
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// Shared is a cache shared by the replicas of the server, like memcached or
+// Redis. Its errors are logged and treated as misses.
+type Shared interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Metrics count the lookups of blogs outside of transactions.
+type Metrics struct {
+	// Hits were answered by the LRU, SharedHits by the shared cache.
+	Hits       expvar.Int
+	SharedHits expvar.Int
+	// Misses were loaded from the storage.
+	Misses expvar.Int
+	// Coalesced lookups waited for the load of a concurrent miss.
+	Coalesced     expvar.Int
+	Evictions     expvar.Int
+	Invalidations expvar.Int
+}
+
+// Store caches GetBlog of the wrapped store, every other method is passed through.
+type Store struct {
+	storage.Store
+	Local *LRU
+	// Shared is optional.
+	Shared  Shared
+	Metrics Metrics
+
+	loads    singleflight.Group
+	mu       sync.Mutex
+	inflight map[string]*load
+}
+
+// load is a running load of a blog, it is stale once the blog changed.
+type load struct {
+	stale bool
+}
+
+func New(store storage.Store, local *LRU) *Store {
+	return &Store{
+		Store:    store,
+		Local:    local,
+		inflight: make(map[string]*load),
+	}
+}
+
+// Publish exposes the metrics and the number of cached blogs as the expvar name.
+func (s *Store) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return map[string]int64{
+			"hits":          s.Metrics.Hits.Value(),
+			"shared_hits":   s.Metrics.SharedHits.Value(),
+			"misses":        s.Metrics.Misses.Value(),
+			"coalesced":     s.Metrics.Coalesced.Value(),
+			"evictions":     s.Metrics.Evictions.Value(),
+			"invalidations": s.Metrics.Invalidations.Value(),
+			"entries":       int64(s.Local.Len()),
+		}
+	}))
+}
+
+func cacheKey(tenantID string, id primitive.ObjectID) string {
+	return tenantID + "/" + id.Hex()
+}
+
+// GetBlog reads through the caches, except in a transaction, which may see
+// its own uncommitted changes.
+func (s *Store) GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil || inTx(ctx) {
+		return s.Store.GetBlog(ctx, id)
+	}
+
+	key := cacheKey(t, id)
+	if data, ok := s.Local.Get(key); ok {
+		s.Metrics.Hits.Add(1)
+		return decode(data)
+	}
+
+	v, err, shared := s.loads.Do(key, func() (interface{}, error) {
+		return s.load(ctx, key, id)
+	})
+	if shared {
+		s.Metrics.Coalesced.Add(1)
+	}
+	if err != nil {
+		// the request that started the load was cancelled, not this one
+		if ctx.Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			return s.Store.GetBlog(ctx, id)
+		}
+		return nil, err
+	}
+	return decode(v.([]byte))
+}
+
+func (s *Store) load(ctx context.Context, key string, id primitive.ObjectID) ([]byte, error) {
+	l := &load{}
+	s.mu.Lock()
+	s.inflight[key] = l
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if s.inflight[key] == l {
+			delete(s.inflight, key)
+		}
+		s.mu.Unlock()
+	}()
+
+	if s.Shared != nil {
+		data, ok, err := s.Shared.Get(ctx, key)
+		if err != nil {
+			log.Printf("Could not get %s from the shared cache: %v", key, err)
+		}
+		if ok {
+			s.Metrics.SharedHits.Add(1)
+			s.fill(l, key, data)
+			return data, nil
+		}
+	}
+
+	s.Metrics.Misses.Add(1)
+	b, err := s.Store.GetBlog(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := bson.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.fill(l, key, data) && s.Shared != nil {
+		if err := s.Shared.Set(ctx, key, data, s.Local.TTL); err != nil {
+			log.Printf("Could not set %s in the shared cache: %v", key, err)
+		}
+	}
+	return data, nil
+}
+
+// fill caches data unless the blog changed while it was loaded.
+func (s *Store) fill(l *load, key string, data []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l.stale {
+		return false
+	}
+	if s.Local.Add(key, data) {
+		s.Metrics.Evictions.Add(1)
+	}
+	return true
+}
+
+func decode(data []byte) (*model.BlogItem, error) {
+	var b model.BlogItem
+	if err := bson.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *Store) invalidate(ctx context.Context, key string) {
+	s.Metrics.Invalidations.Add(1)
+
+	s.mu.Lock()
+	s.Local.Remove(key)
+	if l, ok := s.inflight[key]; ok {
+		l.stale = true
+	}
+	// later lookups must not wait for a load that started before the change
+	s.loads.Forget(key)
+	s.mu.Unlock()
+
+	if s.Shared != nil {
+		if err := s.Shared.Delete(ctx, key); err != nil {
+			log.Printf("Could not delete %s from the shared cache: %v", key, err)
+		}
+	}
+}
+
+// changed invalidates the blog now and again after the transaction of ctx.
+func (s *Store) changed(ctx context.Context, id primitive.ObjectID) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return
+	}
+	key := cacheKey(t, id)
+	s.invalidate(ctx, key)
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.add(key)
+	}
+}
+
+type pendingKey struct{}
+
+// pending collects the blogs changed in a transaction.
+type pending struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (p *pending) add(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = append(p.keys, key)
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(pendingKey{}).(*pending)
+	return ok
+}
+
+func (s *Store) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return s.Store.Tx(ctx, fn)
+	}
+
+	p := &pending{}
+	err := s.Store.Tx(context.WithValue(ctx, pendingKey{}, p), fn)
+	// a load between the change and the commit may have cached the old blog
+	for _, key := range p.keys {
+		s.invalidate(ctx, key)
+	}
+	return err
+}
+
+func (s *Store) UpdateBlog(ctx context.Context, id primitive.ObjectID, version int64, fn func(b *model.BlogItem) error) (*model.BlogItem, *model.BlogItem, error) {
+	before, after, err := s.Store.UpdateBlog(ctx, id, version, fn)
+	if err == nil {
+		s.changed(ctx, id)
+	}
+	return before, after, err
+}
+
+func (s *Store) DeleteBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	before, err := s.Store.DeleteBlog(ctx, id)
+	if err == nil {
+		s.changed(ctx, id)
+	}
+	return before, err
+}
+
+// DeleteTenant empties the LRU. Blogs of the tenant may stay in the shared
+// cache for their TTL, requests of a deleted tenant are not authenticated.
+func (s *Store) DeleteTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	before, err := s.Store.DeleteTenant(ctx, id)
+	if err == nil {
+		s.Flush()
+	}
+	return before, err
+}
+
+// Flush empties the LRU and returns how many blogs it held, running loads
+// are not cached. The shared cache is left alone, its entries expire after
+// their TTL.
+func (s *Store) Flush() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.Local.Len()
+	s.Local.Purge()
+	for key, l := range s.inflight {
+		l.stale = true
+		s.loads.Forget(key)
+	}
+	return n
+}
+
+// Snapshot passes the call to the wrapped store when it is a storage.Snapshotter.
+func (s *Store) Snapshot(ctx context.Context, w io.Writer) (int64, error) {
+	snapshotter, ok := s.Store.(storage.Snapshotter)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return snapshotter.Snapshot(ctx, w)
+}
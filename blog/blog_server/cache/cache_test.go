@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore keeps blogs in memory. Changes made in a Tx are only seen by
+// calls outside of it once fn returned, like a committed transaction.
+type fakeStore struct {
+	storage.Store
+
+	mu    sync.Mutex
+	blogs map[primitive.ObjectID]model.BlogItem
+	gets  int
+	// blockGet makes the next GetBlog read the blog, signal loaded and wait
+	// for release before it returns
+	blockGet bool
+	loaded   chan struct{}
+	release  chan struct{}
+}
+
+type fakeTxKey struct{}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{blogs: make(map[primitive.ObjectID]model.BlogItem)}
+}
+
+func (f *fakeStore) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	staged := make(map[primitive.ObjectID]*model.BlogItem)
+	if err := fn(context.WithValue(ctx, fakeTxKey{}, staged)); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, b := range staged {
+		if b == nil {
+			delete(f.blogs, id)
+			continue
+		}
+		f.blogs[id] = *b
+	}
+	return nil
+}
+
+func (f *fakeStore) GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	if staged, ok := ctx.Value(fakeTxKey{}).(map[primitive.ObjectID]*model.BlogItem); ok {
+		if b, ok := staged[id]; ok {
+			if b == nil {
+				return nil, storage.ErrNotFound
+			}
+			return b.Clone(), nil
+		}
+	}
+
+	f.mu.Lock()
+	f.gets++
+	b, ok := f.blogs[id]
+	block := f.blockGet
+	f.blockGet = false
+	f.mu.Unlock()
+
+	if block {
+		f.loaded <- struct{}{}
+		<-f.release
+	}
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return b.Clone(), nil
+}
+
+func (f *fakeStore) UpdateBlog(ctx context.Context, id primitive.ObjectID, version int64, fn func(b *model.BlogItem) error) (*model.BlogItem, *model.BlogItem, error) {
+	before, err := f.GetBlog(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	after := before.Clone()
+	if err := fn(after); err != nil {
+		return nil, nil, err
+	}
+	after.Version++
+	f.put(ctx, id, after)
+	return before, after, nil
+}
+
+func (f *fakeStore) DeleteBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	before, err := f.GetBlog(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	f.put(ctx, id, nil)
+	return before, nil
+}
+
+func (f *fakeStore) put(ctx context.Context, id primitive.ObjectID, b *model.BlogItem) {
+	if staged, ok := ctx.Value(fakeTxKey{}).(map[primitive.ObjectID]*model.BlogItem); ok {
+		staged[id] = b
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if b == nil {
+		delete(f.blogs, id)
+		return
+	}
+	f.blogs[id] = *b
+}
+
+// fakeShared is a Shared cache in memory.
+type fakeShared struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (c *fakeShared) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	return v, ok, nil
+}
+
+func (c *fakeShared) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *fakeShared) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+func setup(t *testing.T) (context.Context, *fakeStore, *Store, primitive.ObjectID) {
+	t.Helper()
+	f := newFakeStore()
+	id := primitive.NewObjectID()
+	f.blogs[id] = model.BlogItem{ID: id, TenantID: "t1", Title: "v1", Version: 1}
+	return tenant.NewContext(context.Background(), "t1"), f, New(f, NewLRU(10, time.Minute)), id
+}
+
+func setTitle(title string) func(b *model.BlogItem) error {
+	return func(b *model.BlogItem) error {
+		b.Title = title
+		return nil
+	}
+}
+
+func mustTitle(t *testing.T, ctx context.Context, s *Store, id primitive.ObjectID, want string) {
+	t.Helper()
+	b, err := s.GetBlog(ctx, id)
+	if err != nil {
+		t.Fatalf("GetBlog: %v", err)
+	}
+	if b.Title != want {
+		t.Fatalf("GetBlog returned title %q, want %q", b.Title, want)
+	}
+}
+
+func TestReadAfterWrite(t *testing.T) {
+	ctx, f, s, id := setup(t)
+
+	mustTitle(t, ctx, s, id, "v1")
+	mustTitle(t, ctx, s, id, "v1")
+	if f.gets != 1 {
+		t.Fatalf("storage was read %d times, want 1", f.gets)
+	}
+
+	if _, _, err := s.UpdateBlog(ctx, id, 0, setTitle("v2")); err != nil {
+		t.Fatal(err)
+	}
+	mustTitle(t, ctx, s, id, "v2")
+
+	if _, err := s.DeleteBlog(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetBlog(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetBlog after DeleteBlog returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestLoadRacingUpdateIsNotCached(t *testing.T) {
+	ctx, f, s, id := setup(t)
+	f.blockGet, f.loaded, f.release = true, make(chan struct{}), make(chan struct{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// started before the update, so it may return v1, but must not cache it
+		s.GetBlog(ctx, id)
+	}()
+	<-f.loaded
+	if _, _, err := s.UpdateBlog(ctx, id, 0, setTitle("v2")); err != nil {
+		t.Fatal(err)
+	}
+	close(f.release)
+	<-done
+
+	mustTitle(t, ctx, s, id, "v2")
+}
+
+func TestLoadDuringTxIsDroppedOnCommit(t *testing.T) {
+	ctx, _, s, id := setup(t)
+
+	err := s.Tx(ctx, func(txCtx context.Context) error {
+		if _, _, err := s.UpdateBlog(txCtx, id, 0, setTitle("v2")); err != nil {
+			return err
+		}
+		// another request reads the committed v1 and caches it
+		mustTitle(t, ctx, s, id, "v1")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustTitle(t, ctx, s, id, "v2")
+}
+
+func TestConcurrentMissesLoadOnce(t *testing.T) {
+	ctx, f, s, id := setup(t)
+	f.blockGet, f.loaded, f.release = true, make(chan struct{}), make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mustTitle(t, ctx, s, id, "v1")
+	}()
+	<-f.loaded
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mustTitle(t, ctx, s, id, "v1")
+		}()
+	}
+	// lookups that do not join the load in time hit the LRU once it is filled
+	time.Sleep(20 * time.Millisecond)
+	close(f.release)
+	wg.Wait()
+
+	if f.gets != 1 {
+		t.Fatalf("storage was read %d times, want 1", f.gets)
+	}
+}
+
+func TestSharedCacheIsInvalidated(t *testing.T) {
+	ctx, f, s, id := setup(t)
+	shared := &fakeShared{values: make(map[string][]byte)}
+	s.Shared = shared
+	replica := New(f, NewLRU(10, time.Minute))
+	replica.Shared = shared
+
+	mustTitle(t, ctx, s, id, "v1")
+	if _, _, err := s.UpdateBlog(ctx, id, 0, setTitle("v2")); err != nil {
+		t.Fatal(err)
+	}
+
+	mustTitle(t, ctx, replica, id, "v2")
+}
+
+func TestFlush(t *testing.T) {
+	ctx, f, s, id := setup(t)
+
+	mustTitle(t, ctx, s, id, "v1")
+	if n := s.Flush(); n != 1 {
+		t.Fatalf("Flush dropped %d blogs, want 1", n)
+	}
+	mustTitle(t, ctx, s, id, "v1")
+	if f.gets != 2 {
+		t.Fatalf("storage was read %d times, want 2", f.gets)
+	}
+}
+
+func TestLRUExpires(t *testing.T) {
+	c := NewLRU(2, 10*time.Millisecond)
+	c.Add("a", []byte("1"))
+	c.Add("b", []byte("2"))
+	c.Add("c", []byte("3"))
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("least recently used value was not evicted")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("c"); ok {
+		t.Fatal("expired value was returned")
+	}
+}
@@ -0,0 +1,37 @@
+package db
+
+// Collections names the collections of the blog database.
+type Collections struct {
+	Blog            string `yaml:"blog"`
+	Series          string `yaml:"series"`
+	Views           string `yaml:"views"`
+	IdempotencyKeys string `yaml:"idempotency_keys"`
+	Tenants         string `yaml:"tenants"`
+	AuditLog        string `yaml:"audit_log"`
+	Outbox          string `yaml:"outbox"`
+	Webhooks        string `yaml:"webhooks"`
+	Deliveries      string `yaml:"webhook_deliveries"`
+	ReadingLists    string `yaml:"reading_lists"`
+}
+
+var DefaultCollections = Collections{
+	Blog:            "blog",
+	Series:          "series",
+	Views:           "views",
+	IdempotencyKeys: "idempotency_keys",
+	Tenants:         "tenants",
+	AuditLog:        "audit_log",
+	Outbox:          "outbox",
+	Webhooks:        "webhooks",
+	Deliveries:      "webhook_deliveries",
+	ReadingLists:    "reading_lists",
+}
+
+func (c Collections) hasEmpty() bool {
+	for _, name := range []string{c.Blog, c.Series, c.Views, c.IdempotencyKeys, c.Tenants, c.AuditLog, c.Outbox, c.Webhooks, c.Deliveries, c.ReadingLists} {
+		if name == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config of the Mongo connection. Values are taken from, in increasing
+// priority, the defaults, the YAML file, BLOG_MONGO_* environment variables
+// and flags set on the command line. The server writes in transactions, so
+// URI must point to a replica set or a sharded cluster, not a standalone
+// mongod.
+type Config struct {
+	URI         string      `yaml:"uri"`
+	Database    string      `yaml:"database"`
+	Collections Collections `yaml:"collections"`
+
+	// Settings left at zero or empty keep the value of the URI or the driver default.
+	MinPoolSize uint64 `yaml:"min_pool_size"`
+	MaxPoolSize uint64 `yaml:"max_pool_size"`
+
+	// ReadConcern is a level such as local or majority, empty uses the server default.
+	ReadConcern string `yaml:"read_concern"`
+	// WriteConcern is majority or a number of nodes, empty uses the server default.
+	WriteConcern string `yaml:"write_concern"`
+	// ReadPreference is a mode such as primary or secondaryPreferred.
+	ReadPreference string `yaml:"read_preference"`
+
+	TLS TLSConfig `yaml:"tls"`
+
+	ConnectTimeout         time.Duration `yaml:"connect_timeout"`
+	ServerSelectionTimeout time.Duration `yaml:"server_selection_timeout"`
+	SocketTimeout          time.Duration `yaml:"socket_timeout"`
+
+	// ConnectRetries is the number of retries after the first failed connection attempt.
+	ConnectRetries int `yaml:"connect_retries"`
+	// RetryBackoff is the wait before the first retry, it doubles up to MaxRetryBackoff.
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+}
+
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// MaxRetryBackoff caps the wait between connection attempts.
+const MaxRetryBackoff = 30 * time.Second
+
+var DefaultConfig = Config{
+	URI:                    "mongodb://localhost:27017",
+	Database:               "mydb",
+	Collections:            DefaultCollections,
+	ConnectTimeout:         10 * time.Second,
+	ServerSelectionTimeout: 10 * time.Second,
+	ConnectRetries:         5,
+	RetryBackoff:           500 * time.Millisecond,
+}
+
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field func(c *Config) interface{}
+}
+
+var settings = []setting{
+	{"mongo-uri", "BLOG_MONGO_URI", "Mongo connection string of a replica set or sharded cluster", func(c *Config) interface{} { return &c.URI }},
+	{"mongo-database", "BLOG_MONGO_DATABASE", "database of the blog", func(c *Config) interface{} { return &c.Database }},
+	{"mongo-collection", "BLOG_MONGO_COLLECTION", "collection of the blogs", func(c *Config) interface{} { return &c.Collections.Blog }},
+	{"mongo-min-pool-size", "BLOG_MONGO_MIN_POOL_SIZE", "minimum number of pooled connections", func(c *Config) interface{} { return &c.MinPoolSize }},
+	{"mongo-max-pool-size", "BLOG_MONGO_MAX_POOL_SIZE", "maximum number of pooled connections", func(c *Config) interface{} { return &c.MaxPoolSize }},
+	{"mongo-read-concern", "BLOG_MONGO_READ_CONCERN", "read concern level, e.g. local or majority", func(c *Config) interface{} { return &c.ReadConcern }},
+	{"mongo-write-concern", "BLOG_MONGO_WRITE_CONCERN", "write concern, majority or a number of nodes", func(c *Config) interface{} { return &c.WriteConcern }},
+	{"mongo-read-preference", "BLOG_MONGO_READ_PREFERENCE", "read preference mode, e.g. primary or secondaryPreferred", func(c *Config) interface{} { return &c.ReadPreference }},
+	{"mongo-tls", "BLOG_MONGO_TLS", "connect to Mongo using TLS", func(c *Config) interface{} { return &c.TLS.Enabled }},
+	{"mongo-tls-ca-file", "BLOG_MONGO_TLS_CA_FILE", "CA certificate verifying Mongo", func(c *Config) interface{} { return &c.TLS.CAFile }},
+	{"mongo-tls-cert-file", "BLOG_MONGO_TLS_CERT_FILE", "client certificate presented to Mongo", func(c *Config) interface{} { return &c.TLS.CertFile }},
+	{"mongo-tls-key-file", "BLOG_MONGO_TLS_KEY_FILE", "key of the client certificate", func(c *Config) interface{} { return &c.TLS.KeyFile }},
+	{"mongo-tls-insecure", "BLOG_MONGO_TLS_INSECURE", "skip verification of the Mongo certificate", func(c *Config) interface{} { return &c.TLS.InsecureSkipVerify }},
+	{"mongo-connect-timeout", "BLOG_MONGO_CONNECT_TIMEOUT", "timeout of a single connection attempt", func(c *Config) interface{} { return &c.ConnectTimeout }},
+	{"mongo-server-selection-timeout", "BLOG_MONGO_SERVER_SELECTION_TIMEOUT", "how long an operation waits for a suitable server", func(c *Config) interface{} { return &c.ServerSelectionTimeout }},
+	{"mongo-socket-timeout", "BLOG_MONGO_SOCKET_TIMEOUT", "socket read and write timeout, 0 means none", func(c *Config) interface{} { return &c.SocketTimeout }},
+	{"mongo-connect-retries", "BLOG_MONGO_CONNECT_RETRIES", "connection retries on startup", func(c *Config) interface{} { return &c.ConnectRetries }},
+	{"mongo-retry-backoff", "BLOG_MONGO_RETRY_BACKOFF", "wait before the first connection retry, doubled on every retry", func(c *Config) interface{} { return &c.RetryBackoff }},
+}
+
+// Flags binds the configuration to command line flags.
+type Flags struct {
+	fs     *flag.FlagSet
+	file   *string
+	values Config
+}
+
+// RegisterFlags defines the Mongo flags on fs, Load reads the configuration after fs is parsed.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs, values: DefaultConfig}
+	f.file = fs.String("config", os.Getenv("BLOG_CONFIG"), "optional YAML file with the Mongo configuration, defaults to $BLOG_CONFIG")
+
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		switch v := s.field(&f.values).(type) {
+		case *string:
+			fs.StringVar(v, s.flag, *v, usage)
+		case *bool:
+			fs.BoolVar(v, s.flag, *v, usage)
+		case *int:
+			fs.IntVar(v, s.flag, *v, usage)
+		case *uint64:
+			fs.Uint64Var(v, s.flag, *v, usage)
+		case *time.Duration:
+			fs.DurationVar(v, s.flag, *v, usage)
+		}
+	}
+	return f
+}
+
+func (f *Flags) Load() (Config, error) {
+	cfg := DefaultConfig
+
+	if *f.file != "" {
+		data, err := os.ReadFile(*f.file)
+		if err != nil {
+			return cfg, fmt.Errorf("could not read config file: %v", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("could not parse config file %s: %v", *f.file, err)
+		}
+	}
+
+	for _, s := range settings {
+		v, ok := os.LookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := setFromString(s.field(&cfg), v); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %v", s.env, err)
+		}
+	}
+
+	set := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	for _, s := range settings {
+		if !set[s.flag] {
+			continue
+		}
+		switch v := s.field(&cfg).(type) {
+		case *string:
+			*v = *s.field(&f.values).(*string)
+		case *bool:
+			*v = *s.field(&f.values).(*bool)
+		case *int:
+			*v = *s.field(&f.values).(*int)
+		case *uint64:
+			*v = *s.field(&f.values).(*uint64)
+		case *time.Duration:
+			*v = *s.field(&f.values).(*time.Duration)
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func setFromString(field interface{}, value string) error {
+	switch v := field.(type) {
+	case *string:
+		*v = value
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*v = b
+	case *int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*v = i
+	case *uint64:
+		u, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*v = u
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*v = d
+	}
+	return nil
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.URI == "":
+		return errors.New("mongo uri is required")
+	case c.Database == "":
+		return errors.New("mongo database is required")
+	case c.Collections.hasEmpty():
+		return errors.New("mongo collection names cannot be empty")
+	case c.MaxPoolSize != 0 && c.MinPoolSize > c.MaxPoolSize:
+		return errors.New("mongo min pool size cannot be greater than max pool size")
+	case c.ConnectTimeout <= 0:
+		return errors.New("mongo connect timeout must be positive")
+	case c.ConnectRetries < 0:
+		return errors.New("mongo connect retries cannot be negative")
+	case (c.TLS.CertFile == "") != (c.TLS.KeyFile == ""):
+		return errors.New("mongo tls cert file and key file must be set together")
+	}
+	return nil
+}
@@ -2,30 +2,157 @@ package db
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"log"
+	"os"
+	"strconv"
 	"time"
 )
 
-func New() (*mongo.Client, func()) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+// New connects to Mongo, retrying with a doubling backoff until the server
+// answers a ping or cfg.ConnectRetries is exhausted. The returned func
+// disconnects the client.
+func New(cfg Config) (*mongo.Client, func(), error) {
+	opts, err := cfg.clientOptions()
 	if err != nil {
-		log.Printf("Could not connect to mongo: %v", err)
+		return nil, nil, err
 	}
-	err = client.Ping(ctx, &readpref.ReadPref{})
-	if err != nil {
-		log.Fatalf("Could not ping mongodb: %v", err)
+
+	backoff := cfg.RetryBackoff
+	var client *mongo.Client
+	for attempt := 0; ; attempt++ {
+		client, err = connect(cfg, opts)
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, nil, fmt.Errorf("could not connect to mongo after %d attempts: %v", attempt+1, err)
+		}
+
+		log.Printf("Could not connect to mongo, retrying in %v: %v", backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > MaxRetryBackoff {
+			backoff = MaxRetryBackoff
+		}
 	}
 
 	closeMongo := func() {
 		log.Println("Closing mongodb connection...")
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+		defer cancel()
 		if err := client.Disconnect(ctx); err != nil {
-			panic(err)
+			log.Printf("Could not disconnect from mongo: %v", err)
+		}
+	}
+	return client, closeMongo, nil
+}
+
+func connect(cfg Config, opts *options.ClientOptions) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		dctx, dcancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+		defer dcancel()
+		client.Disconnect(dctx)
+		return nil, err
+	}
+	return client, nil
+}
+
+func (c Config) clientOptions() (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(c.URI).
+		SetConnectTimeout(c.ConnectTimeout)
+
+	if c.MinPoolSize > 0 {
+		opts.SetMinPoolSize(c.MinPoolSize)
+	}
+	if c.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(c.MaxPoolSize)
+	}
+	if c.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(c.ServerSelectionTimeout)
+	}
+	if c.SocketTimeout > 0 {
+		opts.SetSocketTimeout(c.SocketTimeout)
+	}
+
+	if c.ReadConcern != "" {
+		opts.SetReadConcern(readconcern.New(readconcern.Level(c.ReadConcern)))
+	}
+
+	if c.WriteConcern != "" {
+		if c.WriteConcern == "majority" {
+			opts.SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+		} else {
+			w, err := strconv.Atoi(c.WriteConcern)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid mongo write concern %q, expected majority or a number", c.WriteConcern)
+			}
+			opts.SetWriteConcern(writeconcern.New(writeconcern.W(w)))
+		}
+	}
+
+	if c.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(c.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mongo read preference: %v", err)
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mongo read preference: %v", err)
+		}
+		opts.SetReadPreference(rp)
+	}
+
+	if c.TLS.Enabled {
+		tlsConfig, err := c.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid mongo options: %v", err)
+	}
+	return opts, nil
+}
+
+func (t TLSConfig) config() (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read mongo CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("mongo CA file contains no certificates")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load mongo client certificate: %v", err)
 		}
+		cfg.Certificates = []tls.Certificate{cert}
 	}
-	return client, closeMongo
+	return cfg, nil
 }
@@ -0,0 +1,214 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limit is the number of most recent blogs included in a feed.
+const Limit = 50
+
+// Handler serves RSS 2.0 and Atom 1.0 feeds of active tenants:
+//
+//	/{tenant}/feed.rss, /{tenant}/feed.atom         all blogs
+//	/{tenant}/authors/{id}/feed.rss, .../feed.atom  blogs of one author
+//	/{tenant}/tags/{tag}/feed.rss, .../feed.atom    blogs with one tag
+type Handler struct {
+	Store Store
+	// BaseURL is the site the feeds link to, a blog is linked as BaseURL/{tenant}/blogs/{id}.html.
+	BaseURL string
+	// Title is used for tenants without a name.
+	Title string
+}
+
+// Store is the part of the storage a Handler uses.
+type Store interface {
+	storage.BlogStore
+	storage.TenantStore
+}
+
+func New(store Store, baseURL, title string) *Handler {
+	return &Handler{Store: store, BaseURL: strings.TrimSuffix(baseURL, "/"), Title: title}
+}
+
+type query struct {
+	tenant string
+	filter storage.BlogQuery
+	title  string
+	path   string
+	format string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, ok := h.parse(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	t, err := h.Store.GetTenant(r.Context(), q.tenant)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Printf("Could not find TenantItem for feed: %v", err)
+		http.Error(w, "unexpected database error", http.StatusInternalServerError)
+		return
+	}
+	if t.State != model.TenantActive {
+		http.NotFound(w, r)
+		return
+	}
+	if t.Name != "" {
+		q.title = t.Name + q.title
+	} else {
+		q.title = h.Title + q.title
+	}
+
+	// blogs pending or rejected by moderation are not listed
+	q.filter.Newest = true
+	q.filter.Limit = Limit
+	var items []model.BlogItem
+	err = h.Store.ListBlogs(tenant.NewContext(r.Context(), q.tenant), q.filter, func(b *model.BlogItem) error {
+		items = append(items, *b)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Could not list BlogItem for feed: %v", err)
+		http.Error(w, "unexpected database error", http.StatusInternalServerError)
+		return
+	}
+
+	var updated time.Time
+	for i := range items {
+		if t := items[i].UpdateTime(); t.After(updated) {
+			updated = t
+		}
+	}
+
+	var doc interface{}
+	contentType := "application/atom+xml; charset=utf-8"
+	if q.format == "rss" {
+		doc = h.rss(q, items, updated)
+		contentType = "application/rss+xml; charset=utf-8"
+	} else {
+		doc = h.atom(q, items, updated)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		log.Printf("Could not encode feed: %v", err)
+		http.Error(w, "could not encode feed", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// no Last-Modified, the newest update time does not move when a blog
+	// is deleted or unpublished, only the ETag changes with every feed
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=60")
+
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(buf.Bytes())
+	}
+}
+
+func (h *Handler) parse(path string) (query, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	var q query
+	file := parts[len(parts)-1]
+	switch file {
+	case "feed.rss":
+		q.format = "rss"
+	case "feed.atom":
+		q.format = "atom"
+	default:
+		return q, false
+	}
+
+	if len(parts) < 2 || parts[0] == "" {
+		return q, false
+	}
+	q.tenant = parts[0]
+
+	// the title is prefixed with the name of the tenant once it is found
+	switch rest := parts[1:]; {
+	case len(rest) == 1:
+	case len(rest) == 3 && rest[0] == "authors" && rest[1] != "":
+		q.filter.AuthorID = rest[1]
+		q.title = " - author " + rest[1]
+	case len(rest) == 3 && rest[0] == "tags" && rest[1] != "":
+		tag := strings.ToLower(rest[1])
+		q.filter.Tag = tag
+		q.title = " - tag " + tag
+	default:
+		return q, false
+	}
+
+	q.path = "/" + strings.Join(parts, "/")
+	return q, true
+}
+
+func (h *Handler) link(tenant, id string) string {
+	return h.BaseURL + "/" + tenant + "/blogs/" + id + ".html"
+}
+
+// notModified matches If-None-Match, If-Modified-Since is ignored as there
+// is no Last-Modified.
+func notModified(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Serve runs the feed handler on addr until ctx is cancelled.
+func Serve(ctx context.Context, addr string, h http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: h}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestHandler(t *testing.T) (*Handler, storage.Store) {
+	t.Helper()
+	store, err := storage.OpenBolt(filepath.Join(t.TempDir(), "blog.bolt"))
+	if err != nil {
+		t.Fatalf("could not open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close(context.Background()) })
+	if err := store.CreateTenant(context.Background(), &model.TenantItem{ID: "t1", State: model.TenantActive, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateTenant: %v", err)
+	}
+	return New(store, "https://example.com", "Blog"), store
+}
+
+// get requests the feed with the header and returns the response.
+func get(h *Handler, header, value string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/t1/feed.atom", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestFeedIsValidatedByETag(t *testing.T) {
+	h, store := newTestHandler(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	created := time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
+	var ids []primitive.ObjectID
+	for i := 0; i < 3; i++ {
+		// the newest blog is older than the others were updated
+		b := &model.BlogItem{ID: primitive.NewObjectID(), AuthorId: "author", Title: "title", Language: "en", CreatedAt: created, UpdatedAt: created.Add(time.Duration(3-i) * time.Minute), Version: 1}
+		if err := store.CreateBlog(ctx, b); err != nil {
+			t.Fatalf("CreateBlog: %v", err)
+		}
+		ids = append(ids, b.ID)
+	}
+
+	w := get(h, "", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("feed returned %d with ETag %q", w.Code, etag)
+	}
+	if lm := w.Header().Get("Last-Modified"); lm != "" {
+		t.Fatalf("feed has Last-Modified %s", lm)
+	}
+	if w := get(h, "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("feed with its ETag returned %d, want 304", w.Code)
+	}
+	if w := get(h, "If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); w.Code != http.StatusOK {
+		t.Fatalf("feed with If-Modified-Since returned %d, want 200", w.Code)
+	}
+
+	// neither change moves the newest update time
+	if _, err := store.DeleteBlog(ctx, ids[2]); err != nil {
+		t.Fatalf("DeleteBlog: %v", err)
+	}
+	if w := get(h, "If-None-Match", etag); w.Code != http.StatusOK {
+		t.Fatalf("feed without a deleted blog returned %d, want 200", w.Code)
+	}
+	etag = get(h, "", "").Header().Get("ETag")
+	_, _, err := store.UpdateBlog(ctx, ids[1], 0, func(b *model.BlogItem) error {
+		b.ModerationState = model.ModerationPending
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+	if w := get(h, "If-None-Match", etag); w.Code != http.StatusOK {
+		t.Fatalf("feed without an unpublished blog returned %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package feed
+
+import (
+	"encoding/xml"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"time"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func (h *Handler) rss(q query, items []model.BlogItem, updated time.Time) *rssFeed {
+	feed := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       q.title,
+			Link:        h.BaseURL + "/" + q.tenant + "/",
+			Description: q.title,
+			Self:        atomLink{Rel: "self", Type: "application/rss+xml", Href: h.BaseURL + q.path},
+		},
+	}
+	if !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for i := range items {
+		item := &items[i]
+		link := h.link(q.tenant, item.ID.Hex())
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        link,
+			Description: item.Content,
+			Creator:     item.AuthorId,
+			Categories:  item.Tags,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			PubDate:     item.CreateTime().UTC().Format(time.RFC1123Z),
+		})
+	}
+	return feed
+}
+
+func (h *Handler) atom(q query, items []model.BlogItem, updated time.Time) *atomFeed {
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+
+	feed := &atomFeed{
+		ID:      h.BaseURL + q.path,
+		Title:   q.title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: h.BaseURL + q.path},
+			{Rel: "alternate", Type: "text/html", Href: h.BaseURL + "/" + q.tenant + "/"},
+		},
+	}
+
+	for i := range items {
+		item := &items[i]
+		link := h.link(q.tenant, item.ID.Hex())
+		author := item.AuthorId
+		if author == "" {
+			author = "unknown"
+		}
+		entry := atomEntry{
+			ID:        link,
+			Title:     item.Title,
+			Published: item.CreateTime().UTC().Format(time.RFC3339),
+			Updated:   item.UpdateTime().UTC().Format(time.RFC3339),
+			Author:    atomPerson{Name: author},
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: link}},
+			Content:   atomText{Type: "text", Body: item.Content},
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
@@ -1,34 +1,217 @@
 package main
 
 import (
+	"context"
+	"expvar"
+	"flag"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/analytics"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/blogstats"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/cache"
 	"github.com/dbielecki97/grpc-go-course/blog/blog_server/db"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/feed"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/migrate"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/moderation"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/outbox"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/related"
 	"github.com/dbielecki97/grpc-go-course/blog/blog_server/server"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
 	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"github.com/dbielecki97/grpc-go-course/interceptor/deadline"
+	"github.com/dbielecki97/grpc-go-course/interceptor/idempotency"
+	"github.com/dbielecki97/grpc-go-course/interceptor/validation"
+	"golang.org/x/text/language"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"time"
 )
 
 func main() {
+	languages := flag.String("languages", "en,pl", "comma separated fallback chain of BCP-47 language tags, the first one is the default language of new blogs")
+	httpAddr := flag.String("http", "0.0.0.0:8080", "address of the HTTP server serving RSS and Atom feeds, and metrics at /debug/vars")
+	siteURL := flag.String("site-url", "http://localhost:8080", "base URL of the blog site the feeds link to")
+	idempotencyTTL := flag.Duration("idempotency-ttl", 24*time.Hour, "how long responses to requests with an idempotency-key are replayed")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "deadline of requests sent without one, 0 disables it")
+	listBatchSize := flag.Int("list-batch-size", server.DefaultListBatchSize, "maximum number of blogs in one ListBlog response")
+	listBatchBytes := flag.Int("list-batch-bytes", server.DefaultListBatchBytes, "maximum size in bytes of the blogs in one ListBlog response")
+	listTimeout := flag.Duration("list-timeout", server.DefaultListTimeout, "deadline of ListBlog streams sent without one and without max_bytes_per_second, negative disables it")
+	adminToken := flag.String("admin-token", os.Getenv("BLOG_ADMIN_TOKEN"), "bearer token of the admin role, empty disables the AdminService (env BLOG_ADMIN_TOKEN)")
+	adminAddr := flag.String("admin-addr", "127.0.0.1:50052", "loopback address the AdminService is served on")
+	readOnly := flag.Bool("read-only", false, "start in read-only mode, switched off by AdminService.SetReadOnly")
+	autoMigrate := flag.Bool("migrate", true, "apply pending migrations on startup")
+	backend := flag.String("storage", "mongo", "storage backend of the blog data: mongo, bolt, "+storage.DriverSQLite+" or "+storage.DriverPostgres)
+	sqlDSN := flag.String("sql-dsn", "blog.db", "data source name of the "+storage.DriverSQLite+" or "+storage.DriverPostgres+" database")
+	cacheSize := flag.Int("cache-size", 10000, "number of blogs cached in memory for ReadBlog, 0 disables the cache")
+	cacheTTL := flag.Duration("cache-ttl", time.Minute, "how long a blog stays cached, bounds how stale other replicas may serve it")
+	statsInterval := flag.Duration("stats-interval", blogstats.DefaultInterval, "how often the cached BlogStatsService aggregations are computed again")
+	statsMaxIdle := flag.Duration("stats-max-idle", blogstats.DefaultMaxIdle, "how long a BlogStatsService aggregation nobody asks for stays cached")
+	relatedMaxAge := flag.Duration("related-max-age", related.DefaultMaxAge, "how long the RelatedBlogs index of a tenant is used before it is read again, bounds how long changes of other replicas are missed")
+	moderationWords := flag.String("moderation-words", "", "file of words and phrases, one per line, that hold a blog for moderation")
+	moderationLinks := flag.Int("moderation-max-links", moderation.DefaultMaxLinks, "number of links a blog may have before it is held for moderation")
+	floodPosts := flag.Int("moderation-flood-posts", moderation.DefaultFloodPosts, "number of blogs and translations an author may submit in -moderation-flood-window before they are held for moderation")
+	floodWindow := flag.Duration("moderation-flood-window", moderation.DefaultFloodWindow, "window of -moderation-flood-posts")
+	boltPath := flag.String("bolt-path", "blog.bolt", "database file of the bolt storage, created when missing")
+	mongoFlags := db.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [migrate up|down|status [-to version]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	if *backend != "mongo" && flag.NArg() > 0 {
+		log.Fatalf("Migrations only apply to the mongo storage")
+	}
+
+	var store storage.Store
+	var idempotencyStore idempotency.Store
+	if *backend == "mongo" {
+		cfg, err := mongoFlags.Load()
+		if err != nil {
+			log.Fatalf("Invalid mongo configuration: %v", err)
+		}
+
+		c, closeDb, err := db.New(cfg)
+		if err != nil {
+			log.Fatalf("Could not connect to mongo: %v", err)
+		}
+		defer closeDb()
+		database := c.Database(cfg.Database)
+		collections := cfg.Collections
+		runner := migrate.New(database, collections)
+
+		if flag.NArg() > 0 {
+			if flag.Arg(0) != "migrate" {
+				flag.Usage()
+				return
+			}
+			if err := runMigrate(context.Background(), runner, flag.Args()[1:]); err != nil {
+				log.Printf("Could not migrate: %v", err)
+				closeDb()
+				os.Exit(1)
+			}
+			return
+		}
+
+		if *autoMigrate {
+			applied, err := runner.Up(context.Background(), 0)
+			if err != nil {
+				log.Fatalf("Could not apply migrations: %v", err)
+			}
+			if len(applied) > 0 {
+				log.Printf("Applied migrations: %v", applied)
+			}
+		}
+
+		mongoStore := storage.NewMongoStore(database, collections)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = mongoStore.CheckTransactions(ctx)
+		cancel()
+		if err != nil {
+			log.Fatalf("Could not use mongo: %v", err)
+		}
+		store = mongoStore
+		idempotencyStore = idempotency.NewMongoStore(database.Collection(collections.IdempotencyKeys))
+	} else if *backend == "bolt" {
+		boltStore, err := storage.OpenBolt(*boltPath)
+		if err != nil {
+			log.Fatalf("Could not open bolt database: %v", err)
+		}
+		defer boltStore.Close(context.Background())
+		store = boltStore
+		idempotencyStore = idempotency.NewMemoryStore()
+	} else {
+		sqlStore, err := storage.OpenSQL(context.Background(), *backend, *sqlDSN)
+		if err != nil {
+			log.Fatalf("Could not open %s database: %v", *backend, err)
+		}
+		defer sqlStore.Close(context.Background())
+		store = sqlStore
+		// idempotency keys are not shared between replicas without mongo
+		idempotencyStore = idempotency.NewMemoryStore()
+	}
+
+	if *cacheSize > 0 {
+		cached := cache.New(store, cache.NewLRU(*cacheSize, *cacheTTL))
+		cached.Publish("blog_cache")
+		store = cached
+	}
+
 	log.Println("Blog service started")
 
-	c, closeDb := db.New()
-	defer closeDb()
-	collection := c.Database("mydb").Collection("blog")
-	srv := server.New(collection)
+	views := analytics.New(store)
+	defer views.Close()
+	srv := server.New(store, views, audit.New(store))
+	srv.AdminToken = *adminToken
+	srv.ReadOnly.Set(*readOnly)
+	views.PauseWhile(srv.ReadOnly.Enabled)
+	srv.ListBatchSize = *listBatchSize
+	srv.ListBatchBytes = *listBatchBytes
+	srv.ListTimeout = *listTimeout
+	srv.Related.MaxAge = *relatedMaxAge
+	if *statsInterval <= 0 {
+		log.Fatalf("Invalid stats interval %s, it must be positive", *statsInterval)
+	}
+	srv.Stats.Interval = *statsInterval
+	srv.Stats.MaxIdle = *statsMaxIdle
+	srv.Moderation = moderation.Chain{&moderation.Links{Max: *moderationLinks}, &moderation.Spam{}, moderation.NewFlood(*floodPosts, *floodWindow)}
+	if *moderationWords != "" {
+		words, err := moderation.LoadWordList(*moderationWords)
+		if err != nil {
+			log.Fatalf("Could not load moderation words: %v", err)
+		}
+		srv.Moderation = append(moderation.Chain{words}, srv.Moderation...)
+	}
+	for _, lang := range strings.Split(*languages, ",") {
+		tag, err := language.Parse(strings.TrimSpace(lang))
+		if err != nil {
+			log.Fatalf("Invalid language %q: %v", lang, err)
+		}
+		srv.Languages = append(srv.Languages, tag)
+	}
 
 	lis, err := net.Listen("tcp", "0.0.0.0:50051")
 	if err != nil {
 		log.Fatalf("Could not lister: %v", err)
 	}
 
-	s := grpc.NewServer()
+	idempotent := idempotency.New(idempotencyStore, *idempotencyTTL, server.MutatingMethods...)
+	idempotent.Scope = func(ctx context.Context) string {
+		scope := ""
+		if p, ok := auth.FromContext(ctx); ok {
+			scope = p.Name
+		}
+		// readers of a tenant must not be answered with the responses of each other
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("reader-id")) > 0 {
+			scope += "/" + md.Get("reader-id")[0]
+		}
+		return scope
+	}
+
+	deadlines := deadline.New(*requestTimeout, server.Timeouts)
+
+	authn := auth.New(srv, server.AdminPrefixes, server.PublicMethods...)
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(deadlines.Unary(), authn.Unary(), srv.ReadOnly.Unary(), validation.UnaryServer(), idempotent.Unary()),
+		grpc.ChainStreamInterceptor(deadlines.Stream(), authn.Stream(), srv.ReadOnly.Stream(), validation.StreamServer()),
+	)
 	reflection.Register(s)
 	pb.RegisterBlogServiceServer(s, srv)
+	pb.RegisterWebhookServiceServer(s, srv)
+	pb.RegisterModerationServiceServer(s, srv)
+	pb.RegisterReadingListServiceServer(s, srv)
+	pb.RegisterBlogStatsServiceServer(s, srv)
 
 	go func() {
 		log.Println("Starting server...")
@@ -37,14 +220,102 @@ func main() {
 		}
 	}()
 
+	// the AdminService is only reachable from the host, and still needs the admin token
+	var admin *grpc.Server
+	if *adminToken != "" {
+		if err := checkLoopback(*adminAddr); err != nil {
+			log.Fatalf("Invalid admin address: %v", err)
+		}
+		adminLis, err := net.Listen("tcp", *adminAddr)
+		if err != nil {
+			log.Fatalf("Could not listen on admin address: %v", err)
+		}
+		admin = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(deadlines.Unary(), authn.Unary(), srv.ReadOnly.Unary(), validation.UnaryServer()),
+			grpc.ChainStreamInterceptor(deadlines.Stream(), authn.Stream(), srv.ReadOnly.Stream(), validation.StreamServer()),
+		)
+		reflection.Register(admin)
+		pb.RegisterAdminServiceServer(admin, srv)
+		go func() {
+			log.Printf("Serving the AdminService on %s...", adminLis.Addr())
+			if err := admin.Serve(adminLis); err != nil {
+				log.Fatalf("Could not serve the AdminService: %v", err)
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := outbox.NewDispatcher(store)
+	dispatcher.Paused = srv.ReadOnly.Enabled
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		dispatcher.Run(ctx)
+	}()
+	go srv.Stats.Run(ctx)
+
+	go func() {
+		log.Printf("Serving feeds on %s...", *httpAddr)
+		mux := http.NewServeMux()
+		mux.Handle("/", feed.New(store, *siteURL, "Blog"))
+		mux.Handle("/debug/vars", metricsHandler("blog_cache"))
+		if err := feed.Serve(ctx, *httpAddr, mux); err != nil {
+			log.Fatalf("Could not serve feeds: %v", err)
+		}
+	}()
+
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt)
 
 	<-ch
 
+	log.Println("Stopping the feeds and webhook deliveries...")
+	cancel()
+	<-dispatcherDone
 	log.Println("Stopping the server...")
 	s.Stop()
+	if admin != nil {
+		admin.Stop()
+	}
 	log.Println("Stopping listener...")
 	lis.Close()
 	log.Println("Stopping program...")
 }
+
+// checkLoopback fails unless addr is a host:port whose host is localhost or a loopback IP.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%s is not a loopback address", addr)
+	}
+	return nil
+}
+
+// metricsHandler serves the expvar vars of names like expvar.Handler, which
+// would also publish cmdline with the tokens and URIs passed as flags.
+func metricsHandler(names ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, "{")
+		first := true
+		for _, name := range names {
+			v := expvar.Get(name)
+			if v == nil {
+				continue
+			}
+			if !first {
+				fmt.Fprint(w, ",")
+			}
+			first = false
+			fmt.Fprintf(w, "\n%q: %s", name, v)
+		}
+		fmt.Fprint(w, "\n}\n")
+	})
+}
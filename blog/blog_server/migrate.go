@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/migrate"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+// runMigrate handles migrate up, down and status.
+func runMigrate(ctx context.Context, runner *migrate.Runner, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected up, down or status")
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	to := fs.Int("to", -1, "target version, up defaults to the latest and down to the one before the current")
+	fs.Parse(args[1:])
+
+	switch args[0] {
+	case "up":
+		target := *to
+		if target < 0 {
+			target = 0
+		}
+		applied, err := runner.Up(ctx, target)
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied migrations: %v", applied)
+	case "down":
+		target := *to
+		if target < 0 {
+			current, err := runner.Current(ctx)
+			if err != nil {
+				return err
+			}
+			target = current - 1
+		}
+		reverted, err := runner.Down(ctx, target)
+		if err != nil {
+			return err
+		}
+		log.Printf("Reverted migrations: %v", reverted)
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+	return nil
+}
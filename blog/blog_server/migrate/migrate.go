@@ -0,0 +1,284 @@
+// Package migrate applies versioned schema migrations to the blog database.
+// Applied versions are recorded in the schema_migrations collection and a
+// lease in schema_migrations_lock keeps concurrent servers from running
+// migrations at the same time.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	VersionsCollection = "schema_migrations"
+	LockCollection     = "schema_migrations_lock"
+	// LockLease is how long a lock is held without being refreshed.
+	LockLease = time.Minute
+)
+
+// Migration is a single, ordered change of the database. Down may be nil for
+// migrations that cannot be reverted, reverting them only forgets the version.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database, c db.Collections) error
+	Down        func(ctx context.Context, database *mongo.Database, c db.Collections) error
+}
+
+type Status struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+type appliedVersion struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+type Runner struct {
+	DB          *mongo.Database
+	Collections db.Collections
+	Migrations  []Migration
+	// Owner identifies this process in the lock.
+	Owner string
+}
+
+func New(database *mongo.Database, collections db.Collections) *Runner {
+	host, _ := os.Hostname()
+	return &Runner{
+		DB:          database,
+		Collections: collections,
+		Migrations:  Migrations,
+		Owner:       fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano()),
+	}
+}
+
+// Up applies all pending migrations up to target, 0 means the latest one.
+func (r *Runner) Up(ctx context.Context, target int) ([]int, error) {
+	migrations := r.sorted()
+	if target == 0 && len(migrations) > 0 {
+		target = migrations[len(migrations)-1].Version
+	}
+
+	var done []int
+	err := r.locked(ctx, func(ctx context.Context) error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if m.Version > target {
+				break
+			}
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			log.Printf("Applying migration %d: %s", m.Version, m.Description)
+			if err := m.Up(ctx, r.DB, r.Collections); err != nil {
+				return fmt.Errorf("migration %d failed: %v", m.Version, err)
+			}
+
+			_, err := r.DB.Collection(VersionsCollection).InsertOne(ctx, appliedVersion{
+				Version:     m.Version,
+				Description: m.Description,
+				AppliedAt:   time.Now().UTC(),
+			})
+			if err != nil {
+				return fmt.Errorf("could not record migration %d: %v", m.Version, err)
+			}
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts applied migrations newer than target, newest first.
+func (r *Runner) Down(ctx context.Context, target int) ([]int, error) {
+	migrations := r.sorted()
+
+	var done []int
+	err := r.locked(ctx, func(ctx context.Context) error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if m.Version <= target {
+				break
+			}
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			log.Printf("Reverting migration %d: %s", m.Version, m.Description)
+			if m.Down != nil {
+				if err := m.Down(ctx, r.DB, r.Collections); err != nil {
+					return fmt.Errorf("reverting migration %d failed: %v", m.Version, err)
+				}
+			}
+
+			_, err := r.DB.Collection(VersionsCollection).DeleteOne(ctx, bson.M{"_id": m.Version})
+			if err != nil {
+				return fmt.Errorf("could not forget migration %d: %v", m.Version, err)
+			}
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Current returns the highest applied version, 0 when none is applied.
+func (r *Runner) Current(ctx context.Context) (int, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	current := 0
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current, nil
+}
+
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Status
+	for _, m := range r.sorted() {
+		a, ok := applied[m.Version]
+		res = append(res, Status{Version: m.Version, Description: m.Description, Applied: ok, AppliedAt: a.AppliedAt})
+	}
+	return res, nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]appliedVersion, error) {
+	cur, err := r.DB.Collection(VersionsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []appliedVersion
+	if err := cur.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]appliedVersion, len(versions))
+	for _, v := range versions {
+		res[v.Version] = v
+	}
+	return res, nil
+}
+
+func (r *Runner) sorted() []Migration {
+	migrations := make([]Migration, len(r.Migrations))
+	copy(migrations, r.Migrations)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+type lock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// locked runs fn while holding the migration lock, waiting for other
+// processes to release it. The lease is refreshed while fn runs.
+func (r *Runner) locked(ctx context.Context, fn func(ctx context.Context) error) error {
+	locks := r.DB.Collection(LockCollection)
+
+	for {
+		_, err := locks.InsertOne(ctx, lock{ID: "migrations", Owner: r.Owner, ExpiresAt: time.Now().Add(LockLease)})
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("could not acquire migration lock: %v", err)
+		}
+
+		// a crashed process leaves its lock behind until the lease expires
+		_, err = locks.DeleteOne(ctx, bson.M{"_id": "migrations", "expires_at": bson.M{"$lt": time.Now()}})
+		if err != nil {
+			return fmt.Errorf("could not remove expired migration lock: %v", err)
+		}
+
+		log.Println("Waiting for migrations run by another process...")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(LockLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				res, err := locks.UpdateOne(ctx,
+					bson.M{"_id": "migrations", "owner": r.Owner},
+					bson.M{"$set": bson.M{"expires_at": time.Now().Add(LockLease)}},
+				)
+				if err == nil && res.MatchedCount == 0 {
+					log.Println("Migration lock was lost")
+					close(lost)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err := fn(ctx)
+
+	select {
+	case <-lost:
+		if err == nil {
+			err = errors.New("migration lock was lost")
+		}
+	default:
+	}
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer releaseCancel()
+	if _, rErr := locks.DeleteOne(releaseCtx, bson.M{"_id": "migrations", "owner": r.Owner}); rErr != nil {
+		log.Printf("Could not release migration lock: %v", rErr)
+	}
+
+	return err
+}
+
+func index(keys interface{}, name string) mongo.IndexModel {
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetName(name)}
+}
@@ -0,0 +1,343 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/db"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Migrations are applied in order of their versions, a released version must never change.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create indexes of blogs, series, views and idempotency keys",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			_, err := database.Collection(c.Blog).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				index(bson.D{{Key: "author_id", Value: 1}}, "author_id"),
+				index(bson.D{{Key: "tags", Value: 1}}, "tags"),
+				{
+					Keys: bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+					// language holds BCP-47 tags, which text indexes do not understand
+					Options: options.Index().SetName("text").
+						SetWeights(bson.M{"title": 3, "content": 1}).
+						SetDefaultLanguage("none").
+						SetLanguageOverride("text_language"),
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = database.Collection(c.Series).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "blog_ids", Value: 1}},
+				// a blog belongs to at most one series, empty series are left out
+				Options: options.Index().SetName("blog_ids").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"blog_ids.0": bson.M{"$exists": true}}),
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = database.Collection(c.Views).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				index(bson.D{{Key: "blog_id", Value: 1}, {Key: "time", Value: 1}}, "blog_id_time"),
+				index(bson.D{{Key: "time", Value: 1}}, "time"),
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = database.Collection(c.IdempotencyKeys).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at").SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{
+				{database.Collection(c.Blog), "author_id"},
+				{database.Collection(c.Blog), "tags"},
+				{database.Collection(c.Blog), "text"},
+				{database.Collection(c.Series), "blog_ids"},
+				{database.Collection(c.Views), "blog_id_time"},
+				{database.Collection(c.Views), "time"},
+				{database.Collection(c.IdempotencyKeys), "expires_at"},
+			})
+		},
+	},
+	{
+		Version:     2,
+		Description: "backfill created_at and updated_at of blogs and index them",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			blogs := database.Collection(c.Blog)
+
+			// the creation time is taken from the ObjectID
+			_, err := blogs.UpdateMany(ctx,
+				bson.M{"created_at": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}}},
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = blogs.UpdateMany(ctx,
+				bson.M{"updated_at": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"updated_at": "$created_at"}}}},
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = blogs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				index(bson.D{{Key: "created_at", Value: -1}}, "created_at"),
+				index(bson.D{{Key: "updated_at", Value: -1}}, "updated_at"),
+			})
+			return err
+		},
+		// the backfilled timestamps are kept, the server reads them either way
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{
+				{database.Collection(c.Blog), "created_at"},
+				{database.Collection(c.Blog), "updated_at"},
+			})
+		},
+	},
+	{
+		Version:     3,
+		Description: "backfill slugs of blogs and index them",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			blogs := database.Collection(c.Blog)
+
+			cur, err := blogs.Find(ctx,
+				bson.M{"$or": bson.A{bson.M{"slug": bson.M{"$exists": false}}, bson.M{"slug": ""}}},
+				options.Find().SetProjection(bson.M{"title": 1}),
+			)
+			if err != nil {
+				return err
+			}
+			defer cur.Close(ctx)
+
+			var batch []mongo.WriteModel
+			flush := func() error {
+				if len(batch) == 0 {
+					return nil
+				}
+				_, err := blogs.BulkWrite(ctx, batch, options.BulkWrite().SetOrdered(false))
+				batch = batch[:0]
+				return err
+			}
+
+			for cur.Next(ctx) {
+				var item model.BlogItem
+				if err := cur.Decode(&item); err != nil {
+					return err
+				}
+				batch = append(batch, mongo.NewUpdateOneModel().
+					SetFilter(bson.M{"_id": item.ID}).
+					SetUpdate(bson.M{"$set": bson.M{"slug": model.Slugify(item.Title)}}))
+				if len(batch) >= 500 {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			if err := cur.Err(); err != nil {
+				return err
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+
+			_, err = blogs.Indexes().CreateOne(ctx, index(bson.D{{Key: "slug", Value: 1}}, "slug"))
+			return err
+		},
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{{database.Collection(c.Blog), "slug"}})
+		},
+	},
+	{
+		Version:     4,
+		Description: "assign existing data to the default tenant and index tenant ids",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			missing := bson.M{tenant.Field: bson.M{"$exists": false}}
+			for _, name := range []string{c.Blog, c.Series, c.Views} {
+				_, err := database.Collection(name).UpdateMany(ctx, missing, bson.M{"$set": bson.M{tenant.Field: tenant.Default}})
+				if err != nil {
+					return err
+				}
+			}
+
+			// the default tenant gets no api key, an admin issues one
+			_, err := database.Collection(c.Tenants).UpdateOne(ctx,
+				bson.M{"_id": tenant.Default},
+				bson.M{"$setOnInsert": model.TenantItem{
+					ID:        tenant.Default,
+					Name:      "Blog",
+					State:     model.TenantActive,
+					CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+				}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = database.Collection(c.Blog).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				index(bson.D{{Key: tenant.Field, Value: 1}, {Key: "_id", Value: -1}}, "tenant_id"),
+				index(bson.D{{Key: tenant.Field, Value: 1}, {Key: "author_id", Value: 1}}, "tenant_id_author_id"),
+				index(bson.D{{Key: tenant.Field, Value: 1}, {Key: "tags", Value: 1}}, "tenant_id_tags"),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = database.Collection(c.Series).Indexes().CreateOne(ctx, index(bson.D{{Key: tenant.Field, Value: 1}}, "tenant_id"))
+			if err != nil {
+				return err
+			}
+			_, err = database.Collection(c.Views).Indexes().CreateOne(ctx, index(bson.D{{Key: tenant.Field, Value: 1}, {Key: "time", Value: 1}}, "tenant_id_time"))
+			return err
+		},
+		// tenant ids are kept, removing them would merge the data of all tenants
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{
+				{database.Collection(c.Blog), "tenant_id"},
+				{database.Collection(c.Blog), "tenant_id_author_id"},
+				{database.Collection(c.Blog), "tenant_id_tags"},
+				{database.Collection(c.Series), "tenant_id"},
+				{database.Collection(c.Views), "tenant_id_time"},
+			})
+		},
+	},
+	{
+		Version:     5,
+		Description: "index the audit log",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			_, err := database.Collection(c.AuditLog).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				index(bson.D{{Key: "time", Value: 1}}, "time"),
+				index(bson.D{{Key: "principal", Value: 1}, {Key: "time", Value: 1}}, "principal_time"),
+				index(bson.D{{Key: "tenant_id", Value: 1}, {Key: "time", Value: 1}}, "tenant_id_time"),
+				index(bson.D{{Key: "blog_id", Value: 1}, {Key: "time", Value: 1}}, "blog_id_time"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{
+				{database.Collection(c.AuditLog), "time"},
+				{database.Collection(c.AuditLog), "principal_time"},
+				{database.Collection(c.AuditLog), "tenant_id_time"},
+				{database.Collection(c.AuditLog), "blog_id_time"},
+			})
+		},
+	},
+	{
+		Version:     6,
+		Description: "create the outbox and index webhooks and their deliveries",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			// collections cannot be created implicitly inside transactions before mongo 4.4
+			for _, name := range []string{c.Blog, c.Series, c.Outbox} {
+				if err := database.CreateCollection(ctx, name); err != nil && !isNamespaceExists(err) {
+					return err
+				}
+			}
+
+			_, err := database.Collection(c.Outbox).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "dispatched_at", Value: 1}},
+				// deliveries are retried for hours, a week leaves room for redeliveries
+				Options: options.Index().SetName("dispatched_at").SetExpireAfterSeconds(7 * 24 * 60 * 60),
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = database.Collection(c.Webhooks).Indexes().CreateOne(ctx, index(bson.D{{Key: tenant.Field, Value: 1}}, "tenant_id"))
+			if err != nil {
+				return err
+			}
+
+			_, err = database.Collection(c.Deliveries).Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "event_id", Value: 1}, {Key: "webhook_id", Value: 1}},
+					Options: options.Index().SetName("event_id_webhook_id").SetUnique(true),
+				},
+				index(bson.D{{Key: "state", Value: 1}, {Key: "next_attempt_at", Value: 1}}, "state_next_attempt_at"),
+				index(bson.D{{Key: tenant.Field, Value: 1}, {Key: "_id", Value: -1}}, "tenant_id"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{
+				{database.Collection(c.Outbox), "dispatched_at"},
+				{database.Collection(c.Webhooks), "tenant_id"},
+				{database.Collection(c.Deliveries), "event_id_webhook_id"},
+				{database.Collection(c.Deliveries), "state_next_attempt_at"},
+				{database.Collection(c.Deliveries), "tenant_id"},
+			})
+		},
+	},
+	{
+		Version:     7,
+		Description: "index the moderation queue",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			// published blogs have no moderation state and stay out of the index
+			_, err := database.Collection(c.Blog).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: tenant.Field, Value: 1}, {Key: "moderation_state", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("tenant_id_moderation_state").
+					SetPartialFilterExpression(bson.M{"moderation_state": bson.M{"$exists": true}}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{{database.Collection(c.Blog), "tenant_id_moderation_state"}})
+		},
+	},
+	{
+		Version:     8,
+		Description: "create and index reading lists",
+		Up: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			if err := database.CreateCollection(ctx, c.ReadingLists); err != nil && !isNamespaceExists(err) {
+				return err
+			}
+			_, err := database.Collection(c.ReadingLists).Indexes().CreateOne(ctx,
+				index(bson.D{{Key: tenant.Field, Value: 1}, {Key: "reader_id", Value: 1}, {Key: "_id", Value: 1}}, "tenant_id_reader_id"))
+			return err
+		},
+		Down: func(ctx context.Context, database *mongo.Database, c db.Collections) error {
+			return dropIndexes(ctx, []dropIndex{{database.Collection(c.ReadingLists), "tenant_id_reader_id"}})
+		},
+	},
+}
+
+type dropIndex struct {
+	collection *mongo.Collection
+	name       string
+}
+
+// dropIndexes ignores indexes that do not exist, so a half applied migration can be reverted.
+func dropIndexes(ctx context.Context, indexes []dropIndex) error {
+	for _, i := range indexes {
+		_, err := i.collection.Indexes().DropOne(ctx, i.name)
+		if err != nil && !isIndexNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNamespaceExists(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 48
+}
+
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	// IndexNotFound and NamespaceNotFound
+	return cmdErr.Code == 27 || cmdErr.Code == 26
+}
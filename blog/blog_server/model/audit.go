@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type AuditRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Principal  string             `bson:"principal"`
+	TenantID   string             `bson:"tenant_id,omitempty"`
+	Method     string             `bson:"method"`
+	BlogID     string             `bson:"blog_id,omitempty"`
+	SeriesID   string             `bson:"series_id,omitempty"`
+	BeforeHash string             `bson:"before_hash,omitempty"`
+	AfterHash  string             `bson:"after_hash,omitempty"`
+	Peer       string             `bson:"peer,omitempty"`
+	Time       time.Time          `bson:"time"`
+}
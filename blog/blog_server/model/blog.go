@@ -1,10 +1,89 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+	"unicode"
+)
 
 type BlogItem struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	AuthorId string             `bson:"author_id"`
-	Content  string             `bson:"content"`
-	Title    string             `bson:"title"`
+	ID           primitive.ObjectID         `bson:"_id,omitempty"`
+	TenantID     string                     `bson:"tenant_id,omitempty"`
+	AuthorId     string                     `bson:"author_id"`
+	Content      string                     `bson:"content"`
+	Title        string                     `bson:"title"`
+	Slug         string                     `bson:"slug"`
+	Language     string                     `bson:"language,omitempty"`
+	Translations map[string]TranslationItem `bson:"translations,omitempty"`
+	Tags         []string                   `bson:"tags,omitempty"`
+	CreatedAt    time.Time                  `bson:"created_at,omitempty"`
+	UpdatedAt    time.Time                  `bson:"updated_at,omitempty"`
+	// Version is 0 for blogs stored before versioning, the first change sets it to 1.
+	Version int64 `bson:"version,omitempty"`
+	// ModerationState is empty for published blogs.
+	ModerationState string          `bson:"moderation_state,omitempty"`
+	Moderation      *ModerationItem `bson:"moderation,omitempty"`
+}
+
+// CreateTime falls back to the creation time of the ObjectID for blogs stored without created_at.
+func (b *BlogItem) CreateTime() time.Time {
+	if b.CreatedAt.IsZero() {
+		return b.ID.Timestamp().UTC()
+	}
+	return b.CreatedAt
+}
+
+// Published reports whether the blog may be served.
+func (b *BlogItem) Published() bool {
+	return b.ModerationState == ""
+}
+
+// UpdateTime falls back to CreateTime for blogs stored without updated_at.
+func (b *BlogItem) UpdateTime() time.Time {
+	if b.UpdatedAt.IsZero() {
+		return b.CreateTime()
+	}
+	return b.UpdatedAt
+}
+
+// Clone returns a copy that shares no maps or slices with b.
+func (b *BlogItem) Clone() *BlogItem {
+	c := *b
+	if b.Translations != nil {
+		c.Translations = make(map[string]TranslationItem, len(b.Translations))
+		for lang, t := range b.Translations {
+			c.Translations[lang] = t
+		}
+	}
+	c.Tags = append([]string(nil), b.Tags...)
+	if b.Moderation != nil {
+		m := *b.Moderation
+		m.Flags = append([]ModerationFlag(nil), m.Flags...)
+		c.Moderation = &m
+	}
+	return &c
+}
+
+type TranslationItem struct {
+	Title   string `bson:"title"`
+	Content string `bson:"content"`
+}
+
+// Slugify turns a title into a lower-case, dash separated URL fragment.
+func Slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
 }
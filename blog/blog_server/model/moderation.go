@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Moderation states of a blog, blogs without one are published.
+const (
+	// ModerationPending blogs were flagged and wait for a decision.
+	ModerationPending = "pending"
+	// ModerationRejected blogs are kept for appeals but never served.
+	ModerationRejected = "rejected"
+)
+
+type ModerationItem struct {
+	Flags     []ModerationFlag `bson:"flags,omitempty"`
+	FlaggedAt time.Time        `bson:"flagged_at,omitempty"`
+	DecidedAt time.Time        `bson:"decided_at,omitempty"`
+	DecidedBy string           `bson:"decided_by,omitempty"`
+	Note      string           `bson:"note,omitempty"`
+}
+
+// ModerationFlag tells which stage of the moderation chain flagged a blog and why.
+type ModerationFlag struct {
+	Stage  string `bson:"stage"`
+	Reason string `bson:"reason"`
+}
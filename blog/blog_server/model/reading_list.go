@@ -0,0 +1,35 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ReadingListItem is a named list of blogs saved by a reader of a tenant.
+type ReadingListItem struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID string             `bson:"tenant_id,omitempty"`
+	// ReaderID identifies the reader on the site of the tenant.
+	ReaderID string             `bson:"reader_id"`
+	Name     string             `bson:"name"`
+	Entries  []ReadingListEntry `bson:"entries"`
+	// ShareTokenHash is set while the list is shared, only the hash of the token is kept.
+	ShareTokenHash string    `bson:"share_token_hash,omitempty"`
+	CreatedAt      time.Time `bson:"created_at"`
+	UpdatedAt      time.Time `bson:"updated_at"`
+}
+
+// ReadingListEntry keeps the title the blog had when it was saved, so an
+// entry of a deleted blog can still be shown.
+type ReadingListEntry struct {
+	BlogID  primitive.ObjectID `bson:"blog_id"`
+	Title   string             `bson:"title"`
+	AddedAt time.Time          `bson:"added_at"`
+}
+
+// Clone returns a copy that shares no slices with l.
+func (l *ReadingListItem) Clone() *ReadingListItem {
+	c := *l
+	c.Entries = append([]ReadingListEntry(nil), l.Entries...)
+	return &c
+}
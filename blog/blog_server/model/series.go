@@ -0,0 +1,18 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type SeriesItem struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty"`
+	TenantID    string               `bson:"tenant_id,omitempty"`
+	Title       string               `bson:"title"`
+	Description string               `bson:"description"`
+	BlogIds     []primitive.ObjectID `bson:"blog_ids"`
+}
+
+// Clone returns a copy that shares no slices with s.
+func (s *SeriesItem) Clone() *SeriesItem {
+	c := *s
+	c.BlogIds = append([]primitive.ObjectID(nil), s.BlogIds...)
+	return &c
+}
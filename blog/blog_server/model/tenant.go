@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+const (
+	TenantActive    = "active"
+	TenantSuspended = "suspended"
+)
+
+type TenantItem struct {
+	ID    string      `bson:"_id"`
+	Name  string      `bson:"name"`
+	State string      `bson:"state"`
+	Quota TenantQuota `bson:"quota"`
+	// APIKeyHash is the hex sha256 of the API key, the key itself is never stored.
+	APIKeyHash string    `bson:"api_key_hash,omitempty"`
+	CreatedAt  time.Time `bson:"created_at"`
+}
+
+type TenantQuota struct {
+	// MaxPosts of 0 means unlimited.
+	MaxPosts int64 `bson:"max_posts"`
+}
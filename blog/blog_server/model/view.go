@@ -0,0 +1,15 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ViewEvent is deduplicated per viewer and hour by its ID.
+type ViewEvent struct {
+	ID       string             `bson:"_id"`
+	TenantID string             `bson:"tenant_id"`
+	BlogID   primitive.ObjectID `bson:"blog_id"`
+	Viewer   string             `bson:"viewer"`
+	Time     time.Time          `bson:"time"`
+}
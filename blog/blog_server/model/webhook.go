@@ -0,0 +1,59 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// OutboxEvent is written in the transaction of the change it describes.
+type OutboxEvent struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	TenantID string             `bson:"tenant_id"`
+	Type     string             `bson:"type"`
+	BlogID   string             `bson:"blog_id,omitempty"`
+	// Payload is the JSON body POSTed to webhooks.
+	Payload   string    `bson:"payload"`
+	CreatedAt time.Time `bson:"created_at"`
+	// DispatchedAt is set once a delivery was created for every matching webhook.
+	DispatchedAt *time.Time `bson:"dispatched_at,omitempty"`
+}
+
+type WebhookItem struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID    string             `bson:"tenant_id,omitempty"`
+	URL         string             `bson:"url"`
+	Events      []string           `bson:"events"`
+	Description string             `bson:"description,omitempty"`
+	Secret      string             `bson:"secret"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+// Clone returns a copy that shares no slices with w.
+func (w *WebhookItem) Clone() *WebhookItem {
+	c := *w
+	c.Events = append([]string(nil), w.Events...)
+	return &c
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type DeliveryItem struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID  string             `bson:"tenant_id"`
+	EventID   primitive.ObjectID `bson:"event_id"`
+	WebhookID primitive.ObjectID `bson:"webhook_id"`
+	EventType string             `bson:"event_type"`
+	BlogID    string             `bson:"blog_id,omitempty"`
+	State     string             `bson:"state"`
+	Attempts  int32              `bson:"attempts"`
+	LastError string             `bson:"last_error,omitempty"`
+	// NextAttemptAt is pushed forward while an attempt is running, so a crashed
+	// dispatcher's delivery is picked up again.
+	NextAttemptAt time.Time  `bson:"next_attempt_at"`
+	CreatedAt     time.Time  `bson:"created_at"`
+	DeliveredAt   *time.Time `bson:"delivered_at,omitempty"`
+}
@@ -0,0 +1,61 @@
+// Package moderation checks blogs before they are published. A Chain runs
+// every Stage on an Item, a blog flagged by any stage is held in the
+// moderation queue until it is approved or rejected.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"log"
+	"time"
+)
+
+// Item is a submitted blog or translation.
+type Item struct {
+	TenantID string
+	AuthorID string
+	// Texts are the submitted titles and contents.
+	Texts []string
+	Time  time.Time
+	// Created is set for new blogs and translations, edits of existing ones
+	// are not counted by Flood.
+	Created bool
+}
+
+// Stage is one check of the chain.
+type Stage interface {
+	// Name identifies the stage in the flags it raises.
+	Name() string
+	// Check returns why the item is flagged, or an empty string.
+	Check(ctx context.Context, item *Item) (string, error)
+}
+
+// Chain runs its stages in order.
+type Chain []Stage
+
+// Default is the chain without a word list, which has no default words.
+func Default() Chain {
+	return Chain{
+		&Links{Max: DefaultMaxLinks},
+		&Spam{},
+		NewFlood(DefaultFloodPosts, DefaultFloodWindow),
+	}
+}
+
+// Check runs every stage, so stages counting items see all of them. A stage
+// that fails flags the item, it is reviewed rather than published unchecked.
+func (c Chain) Check(ctx context.Context, item *Item) []model.ModerationFlag {
+	var flags []model.ModerationFlag
+	for _, stage := range c {
+		reason, err := stage.Check(ctx, item)
+		if err != nil {
+			log.Printf("Could not run moderation stage %s: %v", stage.Name(), err)
+			reason = fmt.Sprintf("could not be checked: %v", err)
+		}
+		if reason != "" {
+			flags = append(flags, model.ModerationFlag{Stage: stage.Name(), Reason: reason})
+		}
+	}
+	return flags
+}
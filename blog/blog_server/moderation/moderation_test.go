@@ -0,0 +1,43 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stage returns a fixed result and counts its calls.
+type stage struct {
+	name   string
+	reason string
+	err    error
+	calls  int
+}
+
+func (s *stage) Name() string { return s.name }
+
+func (s *stage) Check(context.Context, *Item) (string, error) {
+	s.calls++
+	return s.reason, s.err
+}
+
+func TestChainRunsEveryStage(t *testing.T) {
+	flagged := &stage{name: "flagged", reason: "bad"}
+	failed := &stage{name: "failed", err: errors.New("unavailable")}
+	passed := &stage{name: "passed"}
+
+	flags := Chain{flagged, failed, passed}.Check(context.Background(), &Item{})
+	if len(flags) != 2 || flags[0].Stage != "flagged" || flags[0].Reason != "bad" || flags[1].Stage != "failed" {
+		t.Fatalf("Check returned %+v, want the flagged and the failed stage", flags)
+	}
+	if passed.calls != 1 {
+		t.Fatalf("stage after a flag ran %d times, want 1", passed.calls)
+	}
+}
+
+func TestChainPassesCleanItem(t *testing.T) {
+	item := &Item{TenantID: "t1", AuthorID: "a", Texts: []string{"A title", "Some content about gophers."}, Created: true}
+	if flags := Default().Check(context.Background(), item); len(flags) != 0 {
+		t.Fatalf("Check flagged a clean item: %+v", flags)
+	}
+}
@@ -0,0 +1,224 @@
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	DefaultMaxLinks    = 5
+	DefaultFloodPosts  = 10
+	DefaultFloodWindow = time.Hour
+)
+
+// WordList flags items containing a listed word or phrase, matched case
+// insensitively on whole words.
+type WordList struct {
+	phrases []string
+}
+
+func NewWordList(words []string) *WordList {
+	w := &WordList{}
+	for _, word := range words {
+		if phrase := normalize(word); strings.TrimSpace(phrase) != "" {
+			w.phrases = append(w.phrases, phrase)
+		}
+	}
+	return w
+}
+
+// LoadWordList reads a word or phrase per line, skipping empty lines and
+// lines starting with #.
+func LoadWordList(path string) (*WordList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return NewWordList(words), nil
+}
+
+func (w *WordList) Name() string { return "word_list" }
+
+func (w *WordList) Check(_ context.Context, item *Item) (string, error) {
+	for _, text := range item.Texts {
+		text = normalize(text)
+		for _, phrase := range w.phrases {
+			if strings.Contains(text, phrase) {
+				return fmt.Sprintf("contains %q", strings.TrimSpace(phrase)), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// normalize lower-cases the words of text and joins them with single
+// spaces, with a space before and after every word.
+func normalize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return " " + strings.Join(words, " ") + " "
+}
+
+var link = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s]+`)
+
+// Links flags items with more than Max links in all of their texts.
+type Links struct {
+	Max int
+}
+
+func (l *Links) Name() string { return "links" }
+
+func (l *Links) Check(_ context.Context, item *Item) (string, error) {
+	n := 0
+	for _, text := range item.Texts {
+		n += len(link.FindAllStringIndex(text, -1))
+	}
+	if n > l.Max {
+		return fmt.Sprintf("has %d links, at most %d are allowed", n, l.Max), nil
+	}
+	return "", nil
+}
+
+// Spam flags texts that shout, stretch letters or repeat a word over and over.
+type Spam struct{}
+
+const (
+	// spamMinLetters and spamMinWords keep the heuristics off short texts.
+	spamMinLetters = 20
+	spamMinWords   = 20
+	// spamUpperShare of the letters being upper case is shouting.
+	spamUpperShare = 0.7
+	// spamRun of the same letter in a row is stretching, other characters
+	// repeat in markdown rules and code.
+	spamRun = 10
+	// spamWordShare of the words being the same word is repetition.
+	spamWordShare = 0.3
+)
+
+func (s *Spam) Name() string { return "spam" }
+
+func (s *Spam) Check(_ context.Context, item *Item) (string, error) {
+	for _, text := range item.Texts {
+		if reason := spam(text); reason != "" {
+			return reason, nil
+		}
+	}
+	return "", nil
+}
+
+func spam(text string) string {
+	letters, upper, run := 0, 0, 0
+	var prev rune
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+		if r == prev && unicode.IsLetter(r) {
+			run++
+		} else {
+			run = 1
+		}
+		if run >= spamRun {
+			return fmt.Sprintf("repeats %q %d times in a row", r, run)
+		}
+		prev = r
+	}
+	if letters >= spamMinLetters && float64(upper) > spamUpperShare*float64(letters) {
+		return "is mostly upper case"
+	}
+
+	// links are counted by the links stage
+	words := strings.Fields(normalize(link.ReplaceAllString(text, " ")))
+	if len(words) < spamMinWords {
+		return ""
+	}
+	counts := make(map[string]int)
+	for _, w := range words {
+		counts[w]++
+		if float64(counts[w]) > spamWordShare*float64(len(words)) {
+			return fmt.Sprintf("repeats %q in more than %.0f%% of the words", w, spamWordShare*100)
+		}
+	}
+	return ""
+}
+
+// Flood flags created items of an author once it created more than Max items
+// in the last Window. Submissions are counted per tenant, author and process.
+// Edits are neither counted nor flagged, so editing a published blog often
+// does not send it back to the moderation queue.
+type Flood struct {
+	Max    int
+	Window time.Duration
+
+	mu    sync.Mutex
+	seen  map[string][]time.Time
+	swept time.Time
+}
+
+func NewFlood(max int, window time.Duration) *Flood {
+	return &Flood{Max: max, Window: window, seen: make(map[string][]time.Time)}
+}
+
+func (f *Flood) Name() string { return "flood" }
+
+func (f *Flood) Check(_ context.Context, item *Item) (string, error) {
+	if !item.Created {
+		return "", nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	since := item.Time.Add(-f.Window)
+	// authors that stopped posting are forgotten once per window
+	if item.Time.Sub(f.swept) > f.Window {
+		for k, times := range f.seen {
+			if len(recent(times, since)) == 0 {
+				delete(f.seen, k)
+			}
+		}
+		f.swept = item.Time
+	}
+
+	k := item.TenantID + "/" + item.AuthorID
+	times := append(recent(f.seen[k], since), item.Time)
+	f.seen[k] = times
+	if len(times) > f.Max {
+		return fmt.Sprintf("%d submissions of the author in %s, at most %d are allowed", len(times), f.Window, f.Max), nil
+	}
+	return "", nil
+}
+
+// recent drops the times before since, times are in order.
+func recent(times []time.Time, since time.Time) []time.Time {
+	for i, t := range times {
+		if t.After(since) {
+			return times[i:]
+		}
+	}
+	return times[:0]
+}
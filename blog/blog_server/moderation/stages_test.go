@@ -0,0 +1,112 @@
+package moderation
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func check(t *testing.T, s Stage, item *Item) string {
+	t.Helper()
+	reason, err := s.Check(context.Background(), item)
+	if err != nil {
+		t.Fatalf("%s: %v", s.Name(), err)
+	}
+	return reason
+}
+
+func texts(texts ...string) *Item {
+	return &Item{TenantID: "t1", AuthorID: "a", Texts: texts, Time: time.Now()}
+}
+
+func TestWordList(t *testing.T) {
+	w := NewWordList([]string{"Casino", "free money", " ", ""})
+	for _, c := range []struct {
+		text    string
+		flagged bool
+	}{
+		{"Visit the CASINO tonight", true},
+		{"get free   money now", true},
+		{"free-money!", true},
+		{"casinos are whole other words", false},
+		{"money is not free", false},
+		{"", false},
+	} {
+		if got := check(t, w, texts("title", c.text)) != ""; got != c.flagged {
+			t.Errorf("%q flagged: %t, want %t", c.text, got, c.flagged)
+		}
+	}
+}
+
+func TestLoadWordList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := ioutil.WriteFile(path, []byte("# comment\n\ncasino\n  spam  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := LoadWordList(path)
+	if err != nil {
+		t.Fatalf("LoadWordList: %v", err)
+	}
+	if check(t, w, texts("comment")) != "" || check(t, w, texts("more spam")) == "" {
+		t.Fatalf("loaded phrases are %q", w.phrases)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	l := &Links{Max: 2}
+	if reason := check(t, l, texts("https://a.example", "see www.b.example")); reason != "" {
+		t.Fatalf("two links flagged: %s", reason)
+	}
+	// links are counted over every text of the item
+	if reason := check(t, l, texts("https://a.example", "http://b.example and HTTPS://c.example")); reason == "" {
+		t.Fatal("three links were not flagged")
+	}
+}
+
+func TestSpam(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		text    string
+		flagged bool
+	}{
+		{"prose", "Gophers write servers in Go and test them with care every day.", false},
+		{"short shout", "HELLO GO", false},
+		{"shouting", "THIS IS THE BEST OFFER YOU WILL EVER SEE", true},
+		{"stretched", "sooooooooooo good", true},
+		{"markdown rule", "----------------", false},
+		{"repeated word", strings.Repeat("buy ", 10) + strings.Repeat("now please ", 7), true},
+		{"few words", "buy buy buy buy", false},
+	} {
+		if got := check(t, &Spam{}, texts(c.text)) != ""; got != c.flagged {
+			t.Errorf("%s flagged: %t, want %t", c.name, got, c.flagged)
+		}
+	}
+}
+
+func TestFlood(t *testing.T) {
+	f := NewFlood(2, time.Hour)
+	start := time.Now()
+	submit := func(author string, created bool, at time.Duration) string {
+		return check(t, f, &Item{TenantID: "t1", AuthorID: author, Created: created, Time: start.Add(at)})
+	}
+
+	if submit("a", true, 0) != "" || submit("a", true, time.Minute) != "" {
+		t.Fatal("submissions within the limit were flagged")
+	}
+	if submit("a", false, 2*time.Minute) != "" {
+		t.Fatal("an edit was flagged")
+	}
+	if submit("b", true, 2*time.Minute) != "" {
+		t.Fatal("another author was flagged")
+	}
+	if submit("a", true, 3*time.Minute) == "" {
+		t.Fatal("third submission in the window was not flagged")
+	}
+	// the first two left the window, the flagged one still counts
+	if submit("a", true, time.Hour+2*time.Minute) != "" {
+		t.Fatal("submission after the window was flagged")
+	}
+}
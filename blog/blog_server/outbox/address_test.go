@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublicIP(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::":    true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"10.1.2.3":             false,
+		"172.20.0.1":           false,
+		"192.168.1.1":          false,
+		"100.64.0.1":           false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"224.0.0.1":            false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:169.254.1.1":   false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"255.255.255.255":      false,
+		"::ffff:93.184.216.34": true,
+	} {
+		if got := PublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("PublicIP(%s) = %t, want %t", addr, got, want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for host, want := range map[string]error{
+		"93.184.216.34":   nil,
+		"127.0.0.1":       ErrPrivateAddress,
+		"169.254.169.254": ErrPrivateAddress,
+		"::1":             ErrPrivateAddress,
+		"localhost":       ErrPrivateAddress,
+		"LocalHost.":      ErrPrivateAddress,
+		"api.localhost":   ErrPrivateAddress,
+	} {
+		if got := CheckHost(context.Background(), host); got != want {
+			t.Errorf("CheckHost(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestClientRefusesPrivateAddress(t *testing.T) {
+	reached := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer srv.Close()
+
+	_, err := NewClient().Post(srv.URL, "application/json", nil)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("POST to %s returned %v, want ErrPrivateAddress", srv.URL, err)
+	}
+	if reached {
+		t.Fatal("the request reached the loopback server")
+	}
+}
@@ -0,0 +1,269 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// PollInterval is how often new events and due deliveries are looked for.
+	PollInterval = time.Second
+	// Lease is how long a running attempt holds its delivery.
+	Lease = time.Minute
+	// MaxAttempts is the number of attempts before a delivery is dead.
+	MaxAttempts = 8
+	// MinBackoff doubles after every failed attempt up to MaxBackoff.
+	MinBackoff = 5 * time.Second
+	MaxBackoff = time.Hour
+	// Workers is the number of concurrent deliveries.
+	Workers = 4
+)
+
+// Dispatcher creates a delivery of every event for each matching webhook and
+// POSTs due deliveries, retrying failed ones with exponential backoff. Several
+// dispatchers may share the storage, a delivery is leased by one of them.
+type Dispatcher struct {
+	Store Store
+	// Client of NewDispatcher only connects to public addresses, see NewClient.
+	Client *http.Client
+
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int32
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	Workers      int
+	// Paused stops the dispatching while it returns true, e.g. in read-only
+	// mode. Attempts already running are finished.
+	Paused func() bool
+}
+
+// Store is the part of the storage a Dispatcher uses.
+type Store interface {
+	storage.OutboxStore
+	storage.WebhookStore
+}
+
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		Store:        store,
+		Client:       NewClient(),
+		PollInterval: PollInterval,
+		Lease:        Lease,
+		MaxAttempts:  MaxAttempts,
+		MinBackoff:   MinBackoff,
+		MaxBackoff:   MaxBackoff,
+		Workers:      Workers,
+	}
+}
+
+// Run dispatches until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !d.paused() {
+			if err := d.fanOut(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Could not dispatch outbox events: %v", err)
+			}
+			d.deliverDue(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) paused() bool {
+	return d.Paused != nil && d.Paused()
+}
+
+// fanOut creates the deliveries of undispatched events. A delivery is unique
+// per event and webhook, so an interrupted fan out is simply repeated.
+func (d *Dispatcher) fanOut(ctx context.Context) error {
+	events, err := d.Store.PendingEvents(ctx, 100)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if err := d.fanOutEvent(ctx, &e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) fanOutEvent(ctx context.Context, e *model.OutboxEvent) error {
+	webhooks, err := d.Store.ListWebhooks(tenant.NewContext(ctx, e.TenantID))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var deliveries []model.DeliveryItem
+	for _, w := range webhooks {
+		if !subscribed(&w, e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.DeliveryItem{
+			TenantID:      e.TenantID,
+			EventID:       e.ID,
+			WebhookID:     w.ID,
+			EventType:     e.Type,
+			BlogID:        e.BlogID,
+			State:         model.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return d.Store.DispatchEvent(ctx, e.ID, deliveries)
+}
+
+// subscribed reports whether the webhook receives events of typ, a webhook
+// without events receives all of them.
+func subscribed(w *model.WebhookItem, typ string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil && !d.paused() {
+				delivery, err := d.claim(ctx)
+				if err != nil {
+					if !errors.Is(err, storage.ErrNotFound) && ctx.Err() == nil {
+						log.Printf("Could not claim webhook delivery: %v", err)
+					}
+					return
+				}
+				d.attempt(ctx, delivery)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// claim leases the most overdue pending delivery.
+func (d *Dispatcher) claim(ctx context.Context) (*model.DeliveryItem, error) {
+	now := time.Now().UTC()
+	return d.Store.ClaimDelivery(ctx, now, now.Add(d.Lease))
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery *model.DeliveryItem) {
+	err := d.post(ctx, delivery)
+	if ctx.Err() != nil {
+		// stopped, the lease runs out and the delivery is attempted again
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	update := func(data *model.DeliveryItem) error {
+		data.Attempts = attempts
+		switch {
+		case err == nil:
+			data.State = model.DeliveryDelivered
+			data.DeliveredAt = &now
+			data.LastError = ""
+		case attempts >= d.MaxAttempts || errors.Is(err, errGone) || errors.Is(err, ErrPrivateAddress):
+			data.State = model.DeliveryDead
+			data.LastError = err.Error()
+		default:
+			data.NextAttemptAt = now.Add(d.backoff(attempts))
+			data.LastError = err.Error()
+		}
+		return nil
+	}
+
+	uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := d.Store.UpdateDelivery(tenant.NewContext(uctx, delivery.TenantID), delivery.ID, update); err != nil {
+		log.Printf("Could not update webhook delivery %s: %v", delivery.ID.Hex(), err)
+	}
+}
+
+// errGone fails a delivery without retries.
+var errGone = errors.New("webhook or event no longer exists")
+
+func (d *Dispatcher) post(ctx context.Context, delivery *model.DeliveryItem) error {
+	webhook, err := d.Store.GetWebhook(tenant.NewContext(ctx, delivery.TenantID), delivery.WebhookID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return errGone
+		}
+		return err
+	}
+	event, err := d.Store.GetEvent(ctx, delivery.EventID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return errGone
+		}
+		return err
+	}
+
+	body := []byte(event.Payload)
+	now := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-webhooks/1")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, delivery.ID.Hex())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, now, body))
+
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+	return nil
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int32) time.Duration {
+	delay := d.MinBackoff
+	for i := int32(1); i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
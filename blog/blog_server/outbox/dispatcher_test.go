@@ -0,0 +1,206 @@
+package outbox
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// receiver records the requests POSTed to it and answers with status.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(r.status)
+		r.mu.Unlock()
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// setup returns a dispatcher of a bolt store holding the event of a created
+// blog of tenant t1 and a webhook of url subscribed to it.
+func setup(t *testing.T, url string) (*Dispatcher, storage.Store, *model.WebhookItem) {
+	t.Helper()
+	store, err := storage.OpenBolt(filepath.Join(t.TempDir(), "blog.bolt"))
+	if err != nil {
+		t.Fatalf("OpenBolt: %v", err)
+	}
+	t.Cleanup(func() { store.Close(context.Background()) })
+
+	ctx := tenant.NewContext(context.Background(), "t1")
+	w := &model.WebhookItem{URL: url, Secret: "whsec_test", CreatedAt: time.Now()}
+	if err := store.CreateWebhook(ctx, w); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if err := New(store).Add(ctx, "t1", EventBlogCreated, "b1", nil); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	d := NewDispatcher(store)
+	d.MaxAttempts = 2
+	d.Workers = 1
+	return d, store, w
+}
+
+// dispatch fans the pending events out and runs the due deliveries once.
+func dispatch(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	if err := d.fanOut(context.Background()); err != nil {
+		t.Fatalf("fanOut: %v", err)
+	}
+	d.deliverDue(context.Background())
+}
+
+func delivery(t *testing.T, store storage.Store) model.DeliveryItem {
+	t.Helper()
+	items, err := store.ListDeliveries(tenant.NewContext(context.Background(), "t1"), storage.DeliveryQuery{})
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("%d deliveries were created, want 1", len(items))
+	}
+	return items[0]
+}
+
+func TestDeliverSignsEvent(t *testing.T) {
+	r := newReceiver(t, http.StatusNoContent)
+	d, store, w := setup(t, r.URL+"/hook")
+	// the receiver is on the loopback, which NewClient refuses
+	d.Client = r.Client()
+
+	dispatch(t, d)
+
+	if len(r.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(r.requests))
+	}
+	req, body := r.requests[0], r.bodies[0]
+	if req.URL.Path != "/hook" || req.Header.Get(HeaderEvent) != EventBlogCreated {
+		t.Fatalf("receiver got %s with event %q", req.URL.Path, req.Header.Get(HeaderEvent))
+	}
+	if !strings.Contains(string(body), `"blog_id":"b1"`) {
+		t.Fatalf("body %s does not name the blog", body)
+	}
+	unix, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp: %v", err)
+	}
+	if got, want := req.Header.Get(HeaderSignature), Sign(w.Secret, time.Unix(unix, 0), body); got != want {
+		t.Fatalf("signature is %q, want %q", got, want)
+	}
+
+	got := delivery(t, store)
+	if got.State != model.DeliveryDelivered || got.Attempts != 1 || got.DeliveredAt == nil {
+		t.Fatalf("delivery is %s after %d attempts, want delivered after 1", got.State, got.Attempts)
+	}
+	if req.Header.Get(HeaderDelivery) != got.ID.Hex() {
+		t.Fatalf("delivery header is %q, want %q", req.Header.Get(HeaderDelivery), got.ID.Hex())
+	}
+}
+
+func TestDeliverRetriesFailures(t *testing.T) {
+	r := newReceiver(t, http.StatusInternalServerError)
+	d, store, _ := setup(t, r.URL)
+	d.Client = r.Client()
+
+	dispatch(t, d)
+	got := delivery(t, store)
+	if got.State != model.DeliveryPending || got.Attempts != 1 || !strings.Contains(got.LastError, "500") {
+		t.Fatalf("delivery is %s after %d attempts with error %q, want pending after 1 with the status", got.State, got.Attempts, got.LastError)
+	}
+	if wait := time.Until(got.NextAttemptAt); wait < MinBackoff-time.Second || wait > MinBackoff {
+		t.Fatalf("next attempt is in %v, want %v", wait, MinBackoff)
+	}
+
+	// skip the backoff, MaxAttempts is 2
+	_, err := store.UpdateDelivery(tenant.NewContext(context.Background(), "t1"), got.ID, func(d *model.DeliveryItem) error {
+		d.NextAttemptAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateDelivery: %v", err)
+	}
+	d.deliverDue(context.Background())
+	got = delivery(t, store)
+	if got.State != model.DeliveryDead || got.Attempts != 2 {
+		t.Fatalf("delivery is %s after %d attempts, want dead after 2", got.State, got.Attempts)
+	}
+	if len(r.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(r.requests))
+	}
+}
+
+func TestDeliverRefusesPrivateAddress(t *testing.T) {
+	r := newReceiver(t, http.StatusOK)
+	d, store, _ := setup(t, r.URL)
+
+	dispatch(t, d)
+
+	if len(r.requests) != 0 {
+		t.Fatalf("receiver on the loopback got %d requests", len(r.requests))
+	}
+	got := delivery(t, store)
+	if got.State != model.DeliveryDead || !strings.Contains(got.LastError, ErrPrivateAddress.Error()) {
+		t.Fatalf("delivery is %s with error %q, want dead because the address is not public", got.State, got.LastError)
+	}
+}
+
+func TestPausedDispatcherWritesNothing(t *testing.T) {
+	r := newReceiver(t, http.StatusNoContent)
+	d, store, _ := setup(t, r.URL)
+	d.Client = r.Client()
+	d.PollInterval = 10 * time.Millisecond
+	var paused int32 = 1
+	d.Paused = func() bool { return atomic.LoadInt32(&paused) == 1 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if events, _ := store.PendingEvents(context.Background(), 10); len(events) != 1 {
+		t.Fatalf("%d events are pending while paused, want 1", len(events))
+	}
+
+	atomic.StoreInt32(&paused, 0)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		r.mu.Lock()
+		n := len(r.requests)
+		r.mu.Unlock()
+		if n == 1 {
+			return
+		}
+	}
+	t.Fatal("event was not delivered after the dispatcher resumed")
+}
@@ -0,0 +1,100 @@
+// Package outbox stores events of blog changes in the transaction of the
+// change and delivers them to the webhooks of the tenant. An event is never
+// lost when the server stops after a change was committed, it is delivered
+// at least once.
+package outbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"strconv"
+	"time"
+)
+
+const (
+	EventBlogCreated = "blog.created"
+	EventBlogUpdated = "blog.updated"
+	EventBlogDeleted = "blog.deleted"
+)
+
+// EventTypes are the types webhooks can subscribe to.
+var EventTypes = []string{EventBlogCreated, EventBlogUpdated, EventBlogDeleted}
+
+// Headers of a delivery.
+const (
+	HeaderEvent     = "X-Blog-Event"
+	HeaderDelivery  = "X-Blog-Delivery"
+	HeaderTimestamp = "X-Blog-Timestamp"
+	HeaderSignature = "X-Blog-Signature"
+)
+
+type Outbox struct {
+	Store storage.OutboxStore
+}
+
+func New(store storage.OutboxStore) *Outbox {
+	return &Outbox{Store: store}
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	TenantID string          `json:"tenant_id"`
+	BlogID   string          `json:"blog_id,omitempty"`
+	Time     time.Time       `json:"time"`
+	Blog     json.RawMessage `json:"blog,omitempty"`
+}
+
+// Add writes an event with the blog as it is after the change, or before a
+// delete. ctx must be the context of the transaction of the change.
+func (o *Outbox) Add(ctx context.Context, tenantID, typ, blogID string, blog proto.Message) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	p := Payload{
+		ID:       primitive.NewObjectID().Hex(),
+		Type:     typ,
+		TenantID: tenantID,
+		BlogID:   blogID,
+		Time:     now,
+	}
+	if blog != nil {
+		data, err := protojson.Marshal(blog)
+		if err != nil {
+			return err
+		}
+		p.Blog = data
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	id, _ := primitive.ObjectIDFromHex(p.ID)
+	return o.Store.AddEvent(ctx, &model.OutboxEvent{
+		ID:        id,
+		TenantID:  tenantID,
+		Type:      typ,
+		BlogID:    blogID,
+		Payload:   string(body),
+		CreatedAt: now,
+	})
+}
+
+// Sign returns the X-Blog-Signature of a body sent at timestamp, the hex
+// HMAC-SHA256 of "<unix timestamp>.<body>" prefixed with "sha256=". Receivers
+// should compare it in constant time and reject old timestamps.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,403 @@
+// Package related finds the blogs of a tenant most similar to a blog. Texts
+// are compared by the cosine similarity of TF-IDF vectors of their original
+// title and content, blended with the overlap of their tags. Everything runs
+// in process, translations are not indexed.
+//
+// The index of a tenant is read from the storage on its first query and kept
+// up to date by Put and Remove. Changes made by other replicas are picked up
+// when the index is read again after MaxAge, or when a blog is queried.
+package related
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxResults is the number of related blogs cached per blog.
+	MaxResults = 50
+	// TitleWeight counts a term of the title as that many terms of the content.
+	TitleWeight = 3
+	// DefaultTagWeight is the share of the tag overlap in the score.
+	DefaultTagWeight = 0.3
+	// DefaultMaxAge is how long the index of a tenant is used before it is read again.
+	DefaultMaxAge = 10 * time.Minute
+)
+
+type Index struct {
+	Store storage.BlogStore
+	// TagWeight between 0 and 1 is the share of the Jaccard index of the tags
+	// in the score, the rest is the similarity of the texts.
+	TagWeight float64
+	// MaxAge of 0 never reads the index of a tenant again.
+	MaxAge time.Duration
+
+	mu      sync.Mutex
+	tenants map[string]*corpus
+	loading map[string]*load
+}
+
+type Result struct {
+	BlogID primitive.ObjectID
+	Score  float64
+}
+
+// corpus is the index of one tenant.
+type corpus struct {
+	mu       sync.Mutex
+	loadedAt time.Time
+
+	docs map[primitive.ObjectID]*document
+	// postings and tagged list the blogs having a term or tag
+	postings map[string]map[primitive.ObjectID]bool
+	tagged   map[string]map[primitive.ObjectID]bool
+	// gen changes with every change, which changes the idf of every term
+	gen     int64
+	results map[primitive.ObjectID][]Result
+}
+
+// load is a running load of the index of a tenant. It is built without a
+// lock, the changes made meanwhile are applied before it replaces the index.
+type load struct {
+	done    chan struct{}
+	changes []change
+}
+
+// change puts blog, or removes id when blog is nil.
+type change struct {
+	id   primitive.ObjectID
+	blog *model.BlogItem
+}
+
+type document struct {
+	version int64
+	counts  map[string]int
+	tags    []string
+	norm    float64
+	normGen int64
+}
+
+func New(store storage.BlogStore) *Index {
+	return &Index{
+		Store:     store,
+		TagWeight: DefaultTagWeight,
+		MaxAge:    DefaultMaxAge,
+		tenants:   make(map[string]*corpus),
+		loading:   make(map[string]*load),
+	}
+}
+
+// Related returns up to limit blogs of the tenant of ctx most similar to b,
+// most similar first. b is indexed first when the index has an older version.
+func (idx *Index) Related(ctx context.Context, b *model.BlogItem, limit int) ([]Result, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, err := idx.corpus(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	c.apply(change{id: b.ID, blog: b})
+	if _, ok := c.docs[b.ID]; !ok {
+		// not published
+		return nil, nil
+	}
+
+	res, ok := c.results[b.ID]
+	if !ok {
+		res = c.related(b.ID, idx.TagWeight)
+		c.results[b.ID] = res
+	}
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return append([]Result(nil), res...), nil
+}
+
+// Put indexes a created or changed blog of a tenant whose index is loaded.
+func (idx *Index) Put(tenantID string, b *model.BlogItem) {
+	idx.change(tenantID, change{id: b.ID, blog: b})
+}
+
+func (idx *Index) Remove(tenantID string, id primitive.ObjectID) {
+	idx.change(tenantID, change{id: id})
+}
+
+// change applies ch to the index of the tenant and to the one being loaded.
+// A tenant that is not loaded yet sees the change when it is.
+func (idx *Index) change(tenantID string, ch change) {
+	idx.mu.Lock()
+	if l, ok := idx.loading[tenantID]; ok {
+		l.changes = append(l.changes, ch)
+	}
+	c, ok := idx.tenants[tenantID]
+	idx.mu.Unlock()
+
+	if ok {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.apply(ch)
+	}
+}
+
+// Forget drops the index of a tenant, a running load of it is not used.
+func (idx *Index) Forget(tenantID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.tenants, tenantID)
+	delete(idx.loading, tenantID)
+}
+
+// Tenants returns the tenants whose index is loaded.
+func (idx *Index) Tenants() []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var ids []string
+	for id := range idx.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Reindex reads the index of a tenant from the storage again right away and
+// returns how many blogs it has.
+func (idx *Index) Reindex(ctx context.Context, tenantID string) (int, error) {
+	idx.Forget(tenantID)
+	c, err := idx.corpus(tenant.NewContext(ctx, tenantID), tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer c.mu.Unlock()
+	return len(c.docs), nil
+}
+
+// corpus returns the locked index of the tenant, loading it when it is
+// missing or older than MaxAge. An index older than MaxAge answers until the
+// load replaced it, without one the callers wait for the load.
+func (idx *Index) corpus(ctx context.Context, tenantID string) (*corpus, error) {
+	for {
+		idx.mu.Lock()
+		c, ok := idx.tenants[tenantID]
+		if ok && (idx.MaxAge <= 0 || time.Since(c.loadedAt) < idx.MaxAge) {
+			idx.mu.Unlock()
+			c.mu.Lock()
+			return c, nil
+		}
+		l, loading := idx.loading[tenantID]
+		if !loading {
+			l = &load{done: make(chan struct{})}
+			idx.loading[tenantID] = l
+			idx.mu.Unlock()
+			return idx.load(ctx, tenantID, l)
+		}
+		idx.mu.Unlock()
+
+		if ok {
+			c.mu.Lock()
+			return c, nil
+		}
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// load reads the index of a tenant from the storage and returns it locked.
+func (idx *Index) load(ctx context.Context, tenantID string, l *load) (*corpus, error) {
+	c := &corpus{
+		loadedAt: time.Now(),
+		docs:     make(map[primitive.ObjectID]*document),
+		postings: make(map[string]map[primitive.ObjectID]bool),
+		tagged:   make(map[string]map[primitive.ObjectID]bool),
+		results:  make(map[primitive.ObjectID][]Result),
+	}
+	err := idx.Store.ListBlogs(ctx, storage.BlogQuery{}, func(b *model.BlogItem) error {
+		c.put(b)
+		return nil
+	})
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	defer close(l.done)
+	current := idx.loading[tenantID] == l
+	if current {
+		delete(idx.loading, tenantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ch := range l.changes {
+		c.apply(ch)
+	}
+	if current {
+		idx.tenants[tenantID] = c
+	}
+	c.mu.Lock()
+	return c, nil
+}
+
+// apply puts or removes a blog, an older version than the indexed one is ignored.
+func (c *corpus) apply(ch change) {
+	if ch.blog == nil {
+		c.remove(ch.id)
+		return
+	}
+	if d, ok := c.docs[ch.id]; !ok || d.version < ch.blog.Version {
+		c.put(ch.blog)
+	}
+}
+
+// put indexes a published blog and removes any other one.
+func (c *corpus) put(b *model.BlogItem) {
+	c.remove(b.ID)
+	if !b.Published() {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, term := range terms(b.Title) {
+		counts[term] += TitleWeight
+	}
+	for _, term := range terms(b.Content) {
+		counts[term]++
+	}
+	d := &document{version: b.Version, counts: counts, tags: append([]string(nil), b.Tags...)}
+	c.docs[b.ID] = d
+	for term := range counts {
+		add(c.postings, term, b.ID)
+	}
+	for _, tag := range d.tags {
+		add(c.tagged, tag, b.ID)
+	}
+	c.changed()
+}
+
+func (c *corpus) remove(id primitive.ObjectID) {
+	d, ok := c.docs[id]
+	if !ok {
+		return
+	}
+	delete(c.docs, id)
+	for term := range d.counts {
+		del(c.postings, term, id)
+	}
+	for _, tag := range d.tags {
+		del(c.tagged, tag, id)
+	}
+	c.changed()
+}
+
+// changed drops the cached results, the idf of every term changed.
+func (c *corpus) changed() {
+	c.gen++
+	if len(c.results) > 0 {
+		c.results = make(map[primitive.ObjectID][]Result)
+	}
+}
+
+func add(index map[string]map[primitive.ObjectID]bool, k string, id primitive.ObjectID) {
+	ids, ok := index[k]
+	if !ok {
+		ids = make(map[primitive.ObjectID]bool)
+		index[k] = ids
+	}
+	ids[id] = true
+}
+
+func del(index map[string]map[primitive.ObjectID]bool, k string, id primitive.ObjectID) {
+	delete(index[k], id)
+	if len(index[k]) == 0 {
+		delete(index, k)
+	}
+}
+
+// idf is smoothed, so a term of every blog still counts a little.
+func (c *corpus) idf(term string) float64 {
+	return math.Log(float64(1+len(c.docs))/float64(1+len(c.postings[term]))) + 1
+}
+
+// weight dampens repeated terms logarithmically.
+func (c *corpus) weight(d *document, term string) float64 {
+	return (1 + math.Log(float64(d.counts[term]))) * c.idf(term)
+}
+
+func (c *corpus) norm(d *document) float64 {
+	if d.normGen != c.gen {
+		sum := 0.0
+		for term := range d.counts {
+			w := c.weight(d, term)
+			sum += w * w
+		}
+		d.norm, d.normGen = math.Sqrt(sum), c.gen
+	}
+	return d.norm
+}
+
+// related scores the blogs sharing a term or tag with id.
+func (c *corpus) related(id primitive.ObjectID, tagWeight float64) []Result {
+	q := c.docs[id]
+
+	dots := make(map[primitive.ObjectID]float64)
+	for term := range q.counts {
+		wq := c.weight(q, term)
+		for other := range c.postings[term] {
+			if other != id {
+				dots[other] += wq * c.weight(c.docs[other], term)
+			}
+		}
+	}
+	shared := make(map[primitive.ObjectID]int)
+	for _, tag := range q.tags {
+		for other := range c.tagged[tag] {
+			if other != id {
+				shared[other]++
+			}
+		}
+	}
+
+	scores := make(map[primitive.ObjectID]float64, len(dots)+len(shared))
+	if nq := c.norm(q); nq > 0 {
+		for other, dot := range dots {
+			if nd := c.norm(c.docs[other]); nd > 0 {
+				scores[other] = (1 - tagWeight) * dot / (nq * nd)
+			}
+		}
+	}
+	for other, n := range shared {
+		jaccard := float64(n) / float64(len(q.tags)+len(c.docs[other].tags)-n)
+		scores[other] += tagWeight * jaccard
+	}
+
+	res := make([]Result, 0, len(scores))
+	for other, score := range scores {
+		if score > 0 {
+			res = append(res, Result{BlogID: other, Score: score})
+		}
+	}
+	// ties go to the newer blog
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].BlogID.Hex() > res[j].BlogID.Hex()
+	})
+	if len(res) > MaxResults {
+		res = res[:MaxResults]
+	}
+	return res
+}
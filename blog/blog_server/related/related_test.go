@@ -0,0 +1,132 @@
+package related
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore lists blogs, a list started while block is set waits for release.
+type fakeStore struct {
+	storage.BlogStore
+
+	mu      sync.Mutex
+	blogs   []*model.BlogItem
+	block   bool
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *fakeStore) ListBlogs(ctx context.Context, q storage.BlogQuery, fn func(b *model.BlogItem) error) error {
+	s.mu.Lock()
+	blogs := append([]*model.BlogItem(nil), s.blogs...)
+	block := s.block
+	s.block = false
+	s.mu.Unlock()
+
+	for i, b := range blogs {
+		if err := fn(b); err != nil {
+			return err
+		}
+		// stopped halfway, the rest is read after the changes made meanwhile
+		if block && i == 0 {
+			close(s.started)
+			<-s.release
+		}
+	}
+	return nil
+}
+
+func newBlog(title string) *model.BlogItem {
+	return &model.BlogItem{ID: primitive.NewObjectID(), Title: title, Content: "gophers write grpc servers", Version: 1}
+}
+
+func mustRelate(t *testing.T, idx *Index, ctx context.Context, b *model.BlogItem, want int) {
+	t.Helper()
+	res, err := idx.Related(ctx, b, 10)
+	if err != nil {
+		t.Fatalf("Related: %v", err)
+	}
+	if len(res) != want {
+		t.Fatalf("Related returned %d blogs, want %d", len(res), want)
+	}
+}
+
+// within fails unless fn returns within a second.
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s waited for the load", what)
+	}
+}
+
+func TestLoadKeepsChangesMadeMeanwhile(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "t1")
+	a, b, deleted := newBlog("a"), newBlog("b"), newBlog("deleted")
+	store := &fakeStore{blogs: []*model.BlogItem{a, b, deleted}}
+	idx := New(store)
+	mustRelate(t, idx, ctx, a, 2)
+
+	idx.mu.Lock()
+	idx.tenants["t1"].loadedAt = time.Time{}
+	idx.mu.Unlock()
+	store.block, store.started, store.release = true, make(chan struct{}), make(chan struct{})
+	loaded := make(chan struct{})
+	go func() {
+		defer close(loaded)
+		// b, and added without the deleted blog the load read
+		mustRelate(t, idx, ctx, b, 2)
+	}()
+	<-store.started
+
+	// the load holds no lock, the old index answers and takes changes
+	within(t, "Related", func() { mustRelate(t, idx, ctx, a, 2) })
+	added := newBlog("added")
+	within(t, "Put and Remove", func() {
+		idx.Put("t1", added)
+		idx.Remove("t1", deleted.ID)
+	})
+
+	close(store.release)
+	<-loaded
+	idx.mu.Lock()
+	docs := idx.tenants["t1"].docs
+	idx.mu.Unlock()
+	if _, ok := docs[added.ID]; !ok || len(docs) != 3 {
+		t.Fatalf("loaded index has %d blogs, want a, b and the added one", len(docs))
+	}
+}
+
+func TestQueriesWithoutIndexWaitForLoad(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "t1")
+	a, b := newBlog("a"), newBlog("b")
+	store := &fakeStore{blogs: []*model.BlogItem{a, b}, block: true, started: make(chan struct{}), release: make(chan struct{})}
+	idx := New(store)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mustRelate(t, idx, ctx, a, 1)
+	}()
+	<-store.started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mustRelate(t, idx, ctx, b, 1)
+	}()
+	close(store.release)
+	wg.Wait()
+}
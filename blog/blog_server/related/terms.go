@@ -0,0 +1,41 @@
+package related
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stopwords of the default languages carry no meaning of their own.
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, list := range []string{
+		// English
+		"a an and are as at be been but by can do does for from had has have he her his how i if in into is it its " +
+			"me my no not of on or our she so than that the their them then there these they this to too up us was we " +
+			"were what when where which who why will with would you your",
+		// Polish
+		"a aby ale bez bo by był była było być czy dla do gdy i ich in jak jako je jej jest jestem jeszcze już " +
+			"ku lub ma mi na nad nie o od oraz po pod przez przy się są ta tak te tego tej ten to tu tym w we z za że",
+	} {
+		for _, word := range strings.Fields(list) {
+			stopwords[word] = true
+		}
+	}
+}
+
+// terms splits text into lower-cased words of letters and digits, leaving
+// out single characters and stopwords.
+func terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	res := words[:0]
+	for _, w := range words {
+		if utf8.RuneCountInString(w) > 1 && !stopwords[w] {
+			res = append(res, w)
+		}
+	}
+	return res
+}
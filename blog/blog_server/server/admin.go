@@ -0,0 +1,318 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"time"
+)
+
+func (s *Server) CreateTenant(ctx context.Context, r *pb.CreateTenantRequest) (*pb.CreateTenantResponse, error) {
+	t := r.GetTenant()
+
+	key, hash, err := newAPIKey(t.GetId())
+	if err != nil {
+		log.Printf("Could not generate API key: %v", err)
+		return nil, blogerr.New(codes.Internal, blogerr.ReasonInternal, "cannot generate api key")
+	}
+
+	data := model.TenantItem{
+		ID:         t.GetId(),
+		Name:       t.GetName(),
+		State:      model.TenantActive,
+		Quota:      model.TenantQuota{MaxPosts: t.GetQuota().GetMaxPosts()},
+		APIKeyHash: hash,
+		CreatedAt:  time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		if err := s.Store.CreateTenant(ctx, &data); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: data.ID, After: &data})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrExists) {
+			return nil, blogerr.New(codes.AlreadyExists, blogerr.ReasonTenantExists, "tenant with specified id already exists")
+		}
+		log.Printf("Could not insert TenantItem: %v", err)
+		return nil, storageError(ctx)
+	}
+
+	return &pb.CreateTenantResponse{Tenant: tenantToPb(&data), ApiKey: key}, nil
+}
+
+func (s *Server) ListTenants(ctx context.Context, _ *pb.ListTenantsRequest) (*pb.ListTenantsResponse, error) {
+	items, err := s.Store.ListTenants(ctx)
+	if err != nil {
+		log.Printf("Could not list TenantItem: %v", err)
+		return nil, storageError(ctx)
+	}
+
+	res := &pb.ListTenantsResponse{}
+	for i := range items {
+		res.Tenants = append(res.Tenants, tenantToPb(&items[i]))
+	}
+	return res, nil
+}
+
+func (s *Server) SuspendTenant(ctx context.Context, r *pb.SuspendTenantRequest) (*pb.SuspendTenantResponse, error) {
+	data, err := s.updateTenant(ctx, r.GetTenantId(), func(t *model.TenantItem) {
+		t.State = model.TenantSuspended
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SuspendTenantResponse{Tenant: tenantToPb(data)}, nil
+}
+
+func (s *Server) ResumeTenant(ctx context.Context, r *pb.ResumeTenantRequest) (*pb.ResumeTenantResponse, error) {
+	data, err := s.updateTenant(ctx, r.GetTenantId(), func(t *model.TenantItem) {
+		t.State = model.TenantActive
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ResumeTenantResponse{Tenant: tenantToPb(data)}, nil
+}
+
+func (s *Server) IssueTenantKey(ctx context.Context, r *pb.IssueTenantKeyRequest) (*pb.IssueTenantKeyResponse, error) {
+	key, hash, err := newAPIKey(r.GetTenantId())
+	if err != nil {
+		log.Printf("Could not generate API key: %v", err)
+		return nil, blogerr.New(codes.Internal, blogerr.ReasonInternal, "cannot generate api key")
+	}
+
+	_, err = s.updateTenant(ctx, r.GetTenantId(), func(t *model.TenantItem) {
+		t.APIKeyHash = hash
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.IssueTenantKeyResponse{TenantId: r.GetTenantId(), ApiKey: key}, nil
+}
+
+// DeleteTenant removes the tenant and its data. Audit records of the tenant are kept.
+func (s *Server) DeleteTenant(ctx context.Context, r *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error) {
+	id := r.GetTenantId()
+
+	var before *model.TenantItem
+	err := s.Store.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.Store.DeleteTenant(ctx, id); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: id, Before: before})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, tenantNotFound(id)
+		}
+		log.Printf("Could not delete TenantItem: %v", err)
+		return nil, storageError(ctx)
+	}
+	s.Related.Forget(id)
+
+	return &pb.DeleteTenantResponse{TenantId: id}, nil
+}
+
+// updateTenant applies a change to the tenant, the previous version is kept for the audit log.
+func (s *Server) updateTenant(ctx context.Context, id string, apply func(*model.TenantItem)) (*model.TenantItem, error) {
+	var data *model.TenantItem
+	err := s.Store.Tx(ctx, func(ctx context.Context) error {
+		before, after, err := s.Store.UpdateTenant(ctx, id, func(t *model.TenantItem) error {
+			apply(t)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		data = after
+		return s.Audit.Record(ctx, audit.Entry{TenantID: id, Before: before, After: after})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, tenantNotFound(id)
+		}
+		log.Printf("Could not update TenantItem: %v", err)
+		return nil, storageError(ctx)
+	}
+	return data, nil
+}
+
+// checkPostQuota rejects a new blog when the tenant has max_posts blogs
+// already. It runs in the Tx creating the blog and locks a tenant with a
+// quota, so concurrent creates are counted one after another. Storage errors
+// are returned unchanged, so the Tx can retry conflicts.
+func (s *Server) checkPostQuota(ctx context.Context, id string) error {
+	data, err := s.Store.GetTenant(ctx, id)
+	if err == nil && data.Quota.MaxPosts != 0 {
+		data, err = s.Store.LockTenant(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// e.g. deleted while the request was running
+			return tenantNotFound(id)
+		}
+		return err
+	}
+	if data.Quota.MaxPosts == 0 {
+		return nil
+	}
+
+	count, err := s.Store.CountBlogs(ctx)
+	if err != nil {
+		return err
+	}
+	if count >= data.Quota.MaxPosts {
+		return blogerr.QuotaExceeded(id, "tenant reached its quota of blogs")
+	}
+	return nil
+}
+
+// newAPIKey returns a key prefixed with the tenant id, so the tenant can be
+// looked up without an index on the hash, and the hash that is stored.
+func newAPIKey(tenantID string) (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key := tenantID + "." + hex.EncodeToString(b)
+	return key, hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func tenantToPb(data *model.TenantItem) *pb.Tenant {
+	state := pb.Tenant_ACTIVE
+	if data.State == model.TenantSuspended {
+		state = pb.Tenant_SUSPENDED
+	}
+	return &pb.Tenant{
+		Id:         data.ID,
+		Name:       data.Name,
+		State:      state,
+		Quota:      &pb.TenantQuota{MaxPosts: data.Quota.MaxPosts},
+		CreateTime: timestamppb.New(data.CreatedAt),
+	}
+}
+
+func tenantNotFound(id string) error {
+	return blogerr.NotFound(blogerr.ReasonTenantNotFound, blogerr.ResourceTenant, id, "tenant with specified id could not be found")
+}
+
+func (s *Server) StreamAuditLog(r *pb.StreamAuditLogRequest, stream pb.AdminService_StreamAuditLogServer) error {
+	ctx := stream.Context()
+
+	f := storage.AuditFilter{Principal: r.GetPrincipal(), TenantID: r.GetTenantId(), BlogID: r.GetBlogId()}
+	if r.GetFrom() != nil {
+		if err := r.GetFrom().CheckValid(); err != nil {
+			return blogerr.InvalidArgument("from", "%v", err)
+		}
+		f.From = r.GetFrom().AsTime()
+	}
+	if r.GetTo() != nil {
+		if err := r.GetTo().CheckValid(); err != nil {
+			return blogerr.InvalidArgument("to", "%v", err)
+		}
+		f.To = r.GetTo().AsTime()
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return blogerr.InvalidArgument("from", "must be before to")
+	}
+
+	// errors of Send are returned unchanged, everything else failed in the storage
+	var sendErr error
+	err := s.Audit.List(ctx, f, func(rec *model.AuditRecord) error {
+		if sendErr = stream.Send(auditRecordToPb(rec)); sendErr != nil {
+			log.Printf("Could not send AuditRecord to stream: %v", sendErr)
+		}
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		log.Printf("Could not list AuditRecord: %v", err)
+		return storageError(ctx)
+	}
+	return nil
+}
+
+// snapshotChunkSize is the most bytes sent in one SnapshotChunk.
+const snapshotChunkSize = 64 << 10
+
+func (s *Server) Snapshot(r *pb.SnapshotRequest, stream pb.AdminService_SnapshotServer) error {
+	ctx := stream.Context()
+
+	unsupported := blogerr.New(codes.Unimplemented, blogerr.ReasonSnapshotUnsupported, "storage backend does not support snapshots")
+	snapshotter, ok := s.Store.(storage.Snapshotter)
+	if !ok {
+		return unsupported
+	}
+
+	w := &chunkWriter{stream: stream}
+	n, err := snapshotter.Snapshot(ctx, w)
+	if w.err != nil {
+		return w.err
+	}
+	if errors.Is(err, storage.ErrNotSupported) {
+		return unsupported
+	}
+	if err != nil {
+		log.Printf("Could not write snapshot: %v", err)
+		return storageError(ctx)
+	}
+	log.Printf("Sent snapshot of %d bytes", n)
+	return nil
+}
+
+// chunkWriter sends writes as SnapshotChunks and keeps the error of Send.
+type chunkWriter struct {
+	stream pb.AdminService_SnapshotServer
+	err    error
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		end := n + snapshotChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		if w.err = w.stream.Send(&pb.SnapshotChunk{Data: p[n:end]}); w.err != nil {
+			log.Printf("Could not send SnapshotChunk to stream: %v", w.err)
+			return n, w.err
+		}
+		n = end
+	}
+	return n, nil
+}
+
+func auditRecordToPb(rec *model.AuditRecord) *pb.AuditRecord {
+	return &pb.AuditRecord{
+		Id:         rec.ID.Hex(),
+		Principal:  rec.Principal,
+		TenantId:   rec.TenantID,
+		Method:     rec.Method,
+		BlogId:     rec.BlogID,
+		SeriesId:   rec.SeriesID,
+		BeforeHash: rec.BeforeHash,
+		AfterHash:  rec.AfterHash,
+		Peer:       rec.Peer,
+		Time:       timestamppb.New(rec.Time),
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"testing"
+)
+
+// failingAudit cannot append audit records.
+type failingAudit struct {
+	storage.Store
+}
+
+func (failingAudit) AppendAudit(context.Context, *model.AuditRecord) error {
+	return errors.New("audit log is unavailable")
+}
+
+func TestAuditIsRecordedWithChange(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+
+	blog := createBlog(t, s, ctx, "first")
+
+	var records []model.AuditRecord
+	err := store.ListAudit(context.Background(), storage.AuditFilter{BlogID: blog.GetId()}, func(rec *model.AuditRecord) error {
+		records = append(records, *rec)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListAudit: %v", err)
+	}
+	if len(records) != 1 || records[0].TenantID != "t1" || records[0].AfterHash == "" {
+		t.Fatalf("audit records of the created blog are %+v, want one record of t1", records)
+	}
+}
+
+func TestChangeFailsWithoutAuditRecord(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	blog := createBlog(t, s, ctx, "first")
+
+	s.Audit = audit.New(failingAudit{store})
+
+	if _, err := s.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Title: "second", Content: "content"}}); err == nil {
+		t.Fatal("CreateBlog succeeded without an audit record")
+	}
+	if n := countBlogs(t, store, "t1"); n != 1 {
+		t.Fatalf("tenant has %d blogs, want 1", n)
+	}
+
+	blog.Title = "changed"
+	if _, err := s.UpdateBlog(ctx, &pb.UpdateBlogRequest{Blog: blog}); err == nil {
+		t.Fatal("UpdateBlog succeeded without an audit record")
+	}
+	if _, err := s.DeleteBlog(ctx, &pb.DeleteBlogRequest{BlogId: blog.GetId()}); err == nil {
+		t.Fatal("DeleteBlog succeeded without an audit record")
+	}
+
+	got, err := s.ReadBlog(ctx, &pb.ReadBlogRequest{BlogId: blog.GetId()})
+	if err != nil {
+		t.Fatalf("ReadBlog: %v", err)
+	}
+	if got.GetBlog().GetTitle() != "first" {
+		t.Fatalf("blog title is %q after failed changes, want %q", got.GetBlog().GetTitle(), "first")
+	}
+	events, err := store.PendingEvents(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("PendingEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("outbox holds %d events, want only the one of the first blog", len(events))
+	}
+}
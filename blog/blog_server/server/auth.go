@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"strings"
+)
+
+// Authenticate accepts the AdminToken and the API keys of active tenants.
+func (s *Server) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if s.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1 {
+		return &auth.Principal{Name: "admin", Admin: true}, nil
+	}
+
+	id := strings.SplitN(token, ".", 2)[0]
+	data, err := s.Store.GetTenant(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid api key")
+		}
+		log.Printf("Could not find TenantItem: %v", err)
+		return nil, storageError(ctx)
+	}
+
+	if data.APIKeyHash == "" || subtle.ConstantTimeCompare([]byte(hashAPIKey(token)), []byte(data.APIKeyHash)) != 1 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid api key")
+	}
+	if data.State == model.TenantSuspended {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s is suspended", data.ID)
+	}
+
+	return &auth.Principal{Name: data.ID, Tenant: data.ID}, nil
+}
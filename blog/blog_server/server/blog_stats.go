@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+func (s *Server) PostsPerAuthor(ctx context.Context, r *pb.PostsPerAuthorRequest) (*pb.PostsPerAuthorResponse, error) {
+	stats, computed, err := s.blogStats(ctx, r.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.PostsPerAuthorResponse{ComputeTime: timestamppb.New(computed)}
+	for _, a := range stats.Authors[:statsLimit(r.GetLimit(), len(stats.Authors))] {
+		res.Authors = append(res.Authors, &pb.AuthorPosts{TenantId: a.TenantID, AuthorId: a.AuthorID, Posts: a.Posts})
+	}
+	return res, nil
+}
+
+func (s *Server) PostsPerMonth(ctx context.Context, r *pb.PostsPerMonthRequest) (*pb.PostsPerMonthResponse, error) {
+	stats, computed, err := s.blogStats(ctx, r.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.PostsPerMonthResponse{ComputeTime: timestamppb.New(computed)}
+	for _, m := range stats.Months {
+		res.Months = append(res.Months, &pb.MonthPosts{Month: m.Month, Posts: m.Posts})
+	}
+	return res, nil
+}
+
+func (s *Server) ContentLength(ctx context.Context, r *pb.ContentLengthRequest) (*pb.ContentLengthResponse, error) {
+	stats, computed, err := s.blogStats(ctx, r.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.ContentLengthResponse{
+		Posts:       stats.Posts,
+		TotalLength: stats.ContentLength,
+		ComputeTime: timestamppb.New(computed),
+	}
+	if stats.Posts > 0 {
+		res.AverageLength = float64(stats.ContentLength) / float64(stats.Posts)
+	}
+	return res, nil
+}
+
+func (s *Server) TopTags(ctx context.Context, r *pb.TopTagsRequest) (*pb.TopTagsResponse, error) {
+	stats, computed, err := s.blogStats(ctx, r.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.TopTagsResponse{ComputeTime: timestamppb.New(computed)}
+	for _, t := range stats.Tags[:statsLimit(r.GetLimit(), len(stats.Tags))] {
+		res.Tags = append(res.Tags, &pb.TagPosts{Tag: t.Tag, Posts: t.Posts})
+	}
+	return res, nil
+}
+
+func (s *Server) blogStats(ctx context.Context, f *pb.StatsFilter) (*storage.BlogStats, time.Time, error) {
+	q, err := statsQuery(ctx, f)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	stats, computed, err := s.Stats.Get(ctx, q)
+	if err != nil {
+		log.Printf("Could not aggregate BlogStats: %v", err)
+		return nil, time.Time{}, storageError(ctx)
+	}
+	return stats, computed, nil
+}
+
+// statsQuery limits tenants to their own blogs. The range is widened to whole
+// UTC days, so dashboards asking for the last days share a cached query.
+func statsQuery(ctx context.Context, f *pb.StatsFilter) (storage.StatsQuery, error) {
+	q := storage.StatsQuery{TenantID: f.GetTenantId()}
+	if p, ok := auth.FromContext(ctx); !ok || !p.Admin {
+		t, err := tenant.FromContext(ctx)
+		if err != nil {
+			return q, databaseError(ctx)
+		}
+		if q.TenantID != "" && q.TenantID != t {
+			return q, blogerr.New(codes.PermissionDenied, blogerr.ReasonTenantForbidden, "only admins may ask for the statistics of another tenant")
+		}
+		q.TenantID = t
+	}
+
+	if f.GetFrom() != nil {
+		if err := f.GetFrom().CheckValid(); err != nil {
+			return q, blogerr.InvalidArgument("filter.from", "%v", err)
+		}
+		q.From = f.GetFrom().AsTime().Truncate(day)
+	}
+	if f.GetTo() != nil {
+		if err := f.GetTo().CheckValid(); err != nil {
+			return q, blogerr.InvalidArgument("filter.to", "%v", err)
+		}
+		to := f.GetTo().AsTime()
+		if q.To = to.Truncate(day); q.To.Before(to) {
+			q.To = q.To.Add(day)
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return q, blogerr.InvalidArgument("filter.from", "must be before filter.to")
+	}
+	return q, nil
+}
+
+// statsLimit returns how many of n rows are sent, a limit of 0 sends 20.
+func statsLimit(limit int32, n int) int {
+	if limit == 0 {
+		limit = 20
+	}
+	if int(limit) < n {
+		return int(limit)
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"strings"
+)
+
+// etag is a strong, HTTP style etag of the blog and series of res. It covers
+// the chosen translation and the series navigation, which change without a
+// new version of the blog.
+func etag(res *pb.ReadBlogResponse) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb.ReadBlogResponse{Blog: res.GetBlog(), Series: res.GetSeries()})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// ifNoneMatch returns the requested value, falling back to the if-none-match metadata.
+func ifNoneMatch(ctx context.Context, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		return strings.Join(md.Get("if-none-match"), ",")
+	}
+	return ""
+}
+
+// etagMatches reports whether a comma separated if-none-match list holds tag,
+// comparing weakly like HTTP does for if-none-match.
+func etagMatches(list, tag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/metadata"
+	"testing"
+	"time"
+)
+
+func TestNotModifiedReadIsNoView(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	b := createBlog(t, s, ctx, "watched")
+
+	read := func(viewer, etag string) *pb.ReadBlogResponse {
+		t.Helper()
+		rctx := metadata.NewIncomingContext(ctx, metadata.Pairs("viewer-id", viewer))
+		res, err := s.ReadBlog(rctx, &pb.ReadBlogRequest{BlogId: b.GetId(), IfNoneMatch: etag})
+		if err != nil {
+			t.Fatalf("ReadBlog: %v", err)
+		}
+		return res
+	}
+	first := read("v1", "")
+	if res := read("v2", first.GetEtag()); !res.GetNotModified() || res.GetBlog() != nil {
+		t.Fatalf("ReadBlog with the current etag returned %+v, want not modified", res)
+	}
+	s.Views.Close()
+
+	oid, _ := primitive.ObjectIDFromHex(b.GetId())
+	days, err := store.DailyViews(ctx, oid, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("DailyViews: %v", err)
+	}
+	if len(days) != 1 || days[0].Total != 1 {
+		t.Fatalf("views are %+v, want only the first read", days)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"time"
+)
+
+const (
+	DefaultListBatchSize = 100
+	// DefaultListBatchBytes stays well below the 4MiB default message limit of gRPC.
+	DefaultListBatchBytes = 1 << 20
+	// DefaultListTimeout bounds how long a ListBlog stream holds its cursor.
+	DefaultListTimeout = 5 * time.Minute
+)
+
+func (s *Server) listBatchSize() int {
+	if s.ListBatchSize > 0 {
+		return s.ListBatchSize
+	}
+	return DefaultListBatchSize
+}
+
+func (s *Server) listBatchBytes() int {
+	if s.ListBatchBytes > 0 {
+		return s.ListBatchBytes
+	}
+	return DefaultListBatchBytes
+}
+
+// listContext applies the ListTimeout to a stream sent without a deadline.
+// Streams with a throughput cap are exempt, they take as long as the client
+// asked for and end when it cancels them.
+func (s *Server) listContext(ctx context.Context, r *pb.ListBlogRequest) (context.Context, context.CancelFunc) {
+	timeout := s.ListTimeout
+	if timeout == 0 {
+		timeout = DefaultListTimeout
+	}
+	if _, ok := ctx.Deadline(); ok || timeout < 0 || r.GetMaxBytesPerSecond() > 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// byteLimiter paces a stream to a number of bytes per second.
+type byteLimiter struct {
+	rate  int64
+	start time.Time
+	sent  int64
+}
+
+func newByteLimiter(rate int64) *byteLimiter {
+	return &byteLimiter{rate: rate, start: time.Now()}
+}
+
+// wait blocks until n more bytes can be sent without exceeding the rate.
+func (l *byteLimiter) wait(ctx context.Context, n int) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	// the first batch is sent right away, the following ones wait for the bytes sent before them
+	due := l.start.Add(time.Duration(float64(l.sent) / float64(l.rate) * float64(time.Second)))
+	l.sent += int64(n)
+
+	d := time.Until(due)
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/analytics"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/deadline"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listingStore reports the result of ListBlogs on done once it returned.
+type listingStore struct {
+	storage.Store
+	done chan error
+}
+
+func (s *listingStore) ListBlogs(ctx context.Context, q storage.BlogQuery, fn func(data *model.BlogItem) error) error {
+	err := s.Store.ListBlogs(ctx, q, fn)
+	s.done <- err
+	return err
+}
+
+// tenantStream serves a stream as tenant t1, in place of the auth interceptor.
+type tenantStream struct {
+	grpc.ServerStream
+}
+
+func (s *tenantStream) Context() context.Context {
+	return tenant.NewContext(s.ServerStream.Context(), "t1")
+}
+
+// serveList serves s over an in-memory connection behind the deadline
+// interceptor and returns a client of it.
+func serveList(t testing.TB, s *Server, deadlines *deadline.Interceptor) pb.BlogServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainStreamInterceptor(
+		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, &tenantStream{ServerStream: ss})
+		},
+		deadlines.Stream(),
+	))
+	pb.RegisterBlogServiceServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBlogServiceClient(conn)
+}
+
+// newListServer serves a store of testStores holding blogs, whose ListBlogs
+// reports on the returned channel.
+func newListServer(t *testing.T, backend string, deadlines *deadline.Interceptor) (pb.BlogServiceClient, storage.Store, chan error) {
+	t.Helper()
+	s, store := newTestServerOn(t, backend)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	for i := 0; i < 10; i++ {
+		createBlog(t, s, ctx, fmt.Sprintf("blog %d", i))
+	}
+	done := make(chan error, 1)
+	s.Store = &listingStore{Store: s.Store, done: done}
+	return serveList(t, s, deadlines), store, done
+}
+
+// throttled lists one blog per response at a rate that makes every response
+// after the first wait for seconds.
+var throttled = &pb.ListBlogRequest{BatchSize: 1, MaxBytesPerSecond: 1}
+
+// mustStop waits for ListBlogs to return and checks the backend released
+// the cursor it read with.
+func mustStop(t *testing.T, store storage.Store, done chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("ListBlogs listed every blog of a stopped stream")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListBlogs did not return after the stream stopped")
+	}
+
+	switch store := store.(type) {
+	case *storage.BoltStore:
+		if n := store.DB.Stats().OpenTxN; n != 0 {
+			t.Fatalf("%d read transactions are still open", n)
+		}
+	case *storage.SQLStore:
+		if n := store.DB.Stats().InUse; n != 0 {
+			t.Fatalf("%d connections are still in use", n)
+		}
+	}
+}
+
+func TestListBlogStopsWhenCancelled(t *testing.T) {
+	for backend := range testStores {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			client, store, done := newListServer(t, backend, deadline.New(0, nil))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stream, err := client.ListBlog(ctx, throttled)
+			if err != nil {
+				t.Fatalf("ListBlog: %v", err)
+			}
+			if _, err := stream.Recv(); err != nil {
+				t.Fatalf("Recv: %v", err)
+			}
+			cancel()
+
+			mustStop(t, store, done)
+		})
+	}
+}
+
+func TestListBlogStopsAtDeadline(t *testing.T) {
+	for backend := range testStores {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			client, store, done := newListServer(t, backend, deadline.New(0, nil))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := client.ListBlog(ctx, throttled)
+			if err != nil {
+				t.Fatalf("ListBlog: %v", err)
+			}
+			for err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != codes.DeadlineExceeded {
+				t.Fatalf("stream ended with %v, want DeadlineExceeded", err)
+			}
+
+			mustStop(t, store, done)
+		})
+	}
+}
+
+// deadlineStore reports whether ListBlogs was called with a deadline.
+type deadlineStore struct {
+	storage.Store
+	deadline bool
+}
+
+func (s *deadlineStore) ListBlogs(ctx context.Context, q storage.BlogQuery, fn func(data *model.BlogItem) error) error {
+	_, s.deadline = ctx.Deadline()
+	return s.Store.ListBlogs(ctx, q, fn)
+}
+
+func TestListTimeoutSparesThrottledStreams(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	createBlog(t, s, ctx, "blog")
+	store := &deadlineStore{Store: s.Store}
+	s.Store = store
+
+	for _, c := range []struct {
+		name    string
+		timeout time.Duration
+		r       *pb.ListBlogRequest
+		want    bool
+	}{
+		{"default", 0, &pb.ListBlogRequest{}, true},
+		{"throttled", 0, &pb.ListBlogRequest{MaxBytesPerSecond: 1 << 20}, false},
+		{"disabled", -1, &pb.ListBlogRequest{}, false},
+	} {
+		s.ListTimeout = c.timeout
+		if err := s.ListBlog(c.r, &listStream{ctx: ctx}); err != nil {
+			t.Fatalf("ListBlog: %v", err)
+		}
+		if store.deadline != c.want {
+			t.Errorf("%s stream has a deadline: %t, want %t", c.name, store.deadline, c.want)
+		}
+	}
+}
+
+// BenchmarkListBlog streams every blog of a tenant over gRPC and reports the
+// throughput in blogs and bytes of blogs per second.
+func BenchmarkListBlog(b *testing.B) {
+	const blogs = 10000
+	store, err := storage.OpenBolt(filepath.Join(b.TempDir(), "blog.bolt"))
+	if err != nil {
+		b.Fatalf("OpenBolt: %v", err)
+	}
+	defer store.Close(context.Background())
+	if err := store.CreateTenant(context.Background(), &model.TenantItem{ID: "t1", State: model.TenantActive, CreatedAt: time.Now()}); err != nil {
+		b.Fatalf("CreateTenant: %v", err)
+	}
+	ctx := tenant.NewContext(context.Background(), "t1")
+	content := strings.Repeat("lorem ipsum dolor sit amet ", 40)
+	err = store.Tx(ctx, func(ctx context.Context) error {
+		for i := 0; i < blogs; i++ {
+			now := time.Now()
+			err := store.CreateBlog(ctx, &model.BlogItem{
+				ID:        primitive.NewObjectID(),
+				AuthorId:  fmt.Sprintf("author %d", i%100),
+				Title:     fmt.Sprintf("blog %d", i),
+				Content:   content,
+				CreatedAt: now,
+				UpdatedAt: now,
+				Version:   1,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatalf("could not seed blogs: %v", err)
+	}
+
+	views := analytics.New(store)
+	defer views.Close()
+	client := serveList(b, New(store, views, audit.New(store)), deadline.New(0, nil))
+
+	b.ResetTimer()
+	start := time.Now()
+	var bytes int64
+	for i := 0; i < b.N; i++ {
+		stream, err := client.ListBlog(context.Background(), &pb.ListBlogRequest{})
+		if err != nil {
+			b.Fatalf("ListBlog: %v", err)
+		}
+		n := 0
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatalf("Recv: %v", err)
+			}
+			n += len(res.GetBlogs())
+			bytes += int64(proto.Size(res))
+		}
+		if n != blogs {
+			b.Fatalf("ListBlog listed %d blogs, want %d", n, blogs)
+		}
+	}
+	secs := time.Since(start).Seconds()
+	b.ReportMetric(float64(blogs*b.N)/secs, "blogs/s")
+	b.ReportMetric(float64(bytes)/secs/(1<<20), "MiB/s")
+}
+
+func TestListBlogFilters(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	for _, b := range []*pb.Blog{
+		{AuthorId: "ann", Title: "Gophers", Content: "grpc streams", Tags: []string{"go"}},
+		{AuthorId: "bob", Title: "Pasta", Content: "with basil", Tags: []string{"Food"}},
+		{AuthorId: "ann", Title: "Basil", Content: "grows in pots", Tags: []string{"garden"}},
+	} {
+		if _, err := s.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: b}); err != nil {
+			t.Fatalf("CreateBlog: %v", err)
+		}
+	}
+
+	for _, c := range []struct {
+		name string
+		r    *pb.ListBlogRequest
+		want string
+	}{
+		{"text", &pb.ListBlogRequest{Text: "BASIL"}, "Pasta Basil"},
+		{"author", &pb.ListBlogRequest{AuthorId: "ann"}, "Gophers Basil"},
+		{"tag", &pb.ListBlogRequest{Tag: " food "}, "Pasta"},
+		{"every filter", &pb.ListBlogRequest{Text: "basil", AuthorId: "ann", Tag: "garden"}, "Basil"},
+	} {
+		stream := &listStream{ctx: ctx}
+		if err := s.ListBlog(c.r, stream); err != nil {
+			t.Fatalf("ListBlog %s: %v", c.name, err)
+		}
+		var titles []string
+		for _, b := range stream.blogs {
+			titles = append(titles, b.GetTitle())
+		}
+		if got := strings.Join(titles, " "); got != c.want {
+			t.Errorf("ListBlog %s listed %q, want %q", c.name, got, c.want)
+		}
+	}
+}
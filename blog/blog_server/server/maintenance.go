@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"log"
+)
+
+func (s *Server) SetReadOnly(ctx context.Context, r *pb.SetReadOnlyRequest) (*pb.SetReadOnlyResponse, error) {
+	was := s.ReadOnly.Set(r.GetReadOnly())
+	if was != r.GetReadOnly() {
+		log.Printf("Read-only mode switched to %t", r.GetReadOnly())
+	}
+	return &pb.SetReadOnlyResponse{ReadOnly: r.GetReadOnly(), WasReadOnly: was}, nil
+}
+
+func (s *Server) GetReadOnly(ctx context.Context, _ *pb.GetReadOnlyRequest) (*pb.GetReadOnlyResponse, error) {
+	return &pb.GetReadOnlyResponse{ReadOnly: s.ReadOnly.Enabled()}, nil
+}
+
+func (s *Server) Reindex(ctx context.Context, r *pb.ReindexRequest) (*pb.ReindexResponse, error) {
+	tenants := s.Related.Tenants()
+	if id := r.GetTenantId(); id != "" {
+		if _, err := s.Store.GetTenant(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, tenantNotFound(id)
+			}
+			log.Printf("Could not find TenantItem: %v", err)
+			return nil, storageError(ctx)
+		}
+		tenants = []string{id}
+	}
+
+	res := &pb.ReindexResponse{}
+	for _, id := range tenants {
+		n, err := s.Related.Reindex(ctx, id)
+		if err != nil {
+			log.Printf("Could not reindex related blogs of tenant %s: %v", id, err)
+			return nil, storageError(ctx)
+		}
+		res.Tenants = append(res.Tenants, &pb.ReindexResponse_Tenant{TenantId: id, Blogs: int64(n)})
+	}
+	return res, nil
+}
+
+// flusher is implemented by the cache.Store in front of the storage.
+type flusher interface {
+	Flush() int
+}
+
+func (s *Server) FlushCaches(ctx context.Context, _ *pb.FlushCachesRequest) (*pb.FlushCachesResponse, error) {
+	res := &pb.FlushCachesResponse{BlogStats: int64(s.Stats.Flush())}
+	if f, ok := s.Store.(flusher); ok {
+		res.Blogs = int64(f.Flush())
+	}
+	log.Printf("Flushed %d blogs and %d blog statistics from the caches", res.Blogs, res.BlogStats)
+	return res, nil
+}
+
+func (s *Server) CheckConsistency(r *pb.CheckConsistencyRequest, stream pb.AdminService_CheckConsistencyServer) error {
+	ctx := stream.Context()
+
+	// errors of Send are returned unchanged, everything else failed in the storage
+	var sendErr error
+	err := s.Store.Orphans(ctx, func(o *storage.Orphan) error {
+		if sendErr = stream.Send(orphanToPb(o)); sendErr != nil {
+			log.Printf("Could not send Orphan to stream: %v", sendErr)
+		}
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		log.Printf("Could not check consistency: %v", err)
+		return storageError(ctx)
+	}
+	return nil
+}
+
+func (s *Server) GetStorageSizes(ctx context.Context, _ *pb.GetStorageSizesRequest) (*pb.GetStorageSizesResponse, error) {
+	sizes, err := s.Store.Sizes(ctx)
+	if err != nil {
+		log.Printf("Could not read storage sizes: %v", err)
+		return nil, storageError(ctx)
+	}
+
+	res := &pb.GetStorageSizesResponse{TotalBytes: sizes.TotalBytes}
+	for _, c := range sizes.Collections {
+		res.Collections = append(res.Collections, &pb.GetStorageSizesResponse_Collection{Name: c.Name, Items: c.Items, Bytes: c.Bytes})
+	}
+	return res, nil
+}
+
+func orphanToPb(o *storage.Orphan) *pb.Orphan {
+	kind := pb.Orphan_KIND_UNSPECIFIED
+	switch o.Kind {
+	case storage.OrphanTenant:
+		kind = pb.Orphan_TENANT_MISSING
+	case storage.OrphanSeriesBlog:
+		kind = pb.Orphan_SERIES_BLOG_MISSING
+	}
+	return &pb.Orphan{Kind: kind, Collection: o.Collection, TenantId: o.TenantID, Id: o.ID, MissingId: o.MissingID}
+}
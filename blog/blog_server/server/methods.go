@@ -0,0 +1,73 @@
+package server
+
+import "time"
+
+// MutatingMethods are the full names of the methods of tenants that change data.
+var MutatingMethods = []string{
+	"/blog.BlogService/CreateBlog",
+	"/blog.BlogService/UpdateBlog",
+	"/blog.BlogService/DeleteBlog",
+	"/blog.BlogService/CreateSeries",
+	"/blog.BlogService/DeleteSeries",
+	"/blog.BlogService/AddBlogToSeries",
+	"/blog.BlogService/RemoveBlogFromSeries",
+	"/blog.BlogService/ReorderSeries",
+	"/blog.BlogService/PutTranslation",
+	"/blog.BlogService/DeleteTranslation",
+	"/blog.WebhookService/CreateWebhook",
+	"/blog.WebhookService/UpdateWebhook",
+	"/blog.WebhookService/DeleteWebhook",
+	"/blog.WebhookService/Redeliver",
+	"/blog.ReadingListService/CreateReadingList",
+	"/blog.ReadingListService/RenameReadingList",
+	"/blog.ReadingListService/DeleteReadingList",
+	"/blog.ReadingListService/AddToReadingList",
+	"/blog.ReadingListService/RemoveFromReadingList",
+	"/blog.ReadingListService/ReorderReadingList",
+	"/blog.ReadingListService/ShareReadingList",
+	"/blog.ReadingListService/UnshareReadingList",
+}
+
+// AdminMutatingMethods are the methods of admins that change data.
+var AdminMutatingMethods = []string{
+	"/blog.AdminService/CreateTenant",
+	"/blog.AdminService/SuspendTenant",
+	"/blog.AdminService/ResumeTenant",
+	"/blog.AdminService/DeleteTenant",
+	"/blog.AdminService/IssueTenantKey",
+	"/blog.ModerationService/ApproveItem",
+	"/blog.ModerationService/RejectItem",
+}
+
+// Timeouts override the default deadline of requests sent without one.
+var Timeouts = map[string]time.Duration{
+	"/blog.BlogService/TopBlogs":            30 * time.Second,
+	"/blog.BlogService/GetBlogStats":        30 * time.Second,
+	"/blog.BlogService/RelatedBlogs":        30 * time.Second,
+	"/blog.BlogStatsService/PostsPerAuthor": time.Minute,
+	"/blog.BlogStatsService/PostsPerMonth":  time.Minute,
+	"/blog.BlogStatsService/ContentLength":  time.Minute,
+	"/blog.BlogStatsService/TopTags":        time.Minute,
+	"/blog.AdminService/StreamAuditLog":     5 * time.Minute,
+	"/blog.AdminService/Reindex":            5 * time.Minute,
+	"/blog.AdminService/CheckConsistency":   5 * time.Minute,
+	"/blog.AdminService/GetStorageSizes":    time.Minute,
+	// copying a large database takes as long as it takes
+	"/blog.AdminService/Snapshot": 0,
+	// ListBlog applies Server.ListTimeout, which spares throttled streams
+	"/blog.BlogService/ListBlog": 0,
+}
+
+// AdminPrefixes are the method prefixes restricted to admins.
+var AdminPrefixes = []string{
+	"/blog.AdminService/",
+	// authors must not approve their own blogs
+	"/blog.ModerationService/",
+}
+
+// PublicMethods need no bearer token.
+var PublicMethods = []string{
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+	// the share token is checked by the handler
+	"/blog.ReadingListService/StreamSharedReadingList",
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/moderation"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"sort"
+	"time"
+)
+
+// moderate runs the moderation chain on texts submitted for a blog of author,
+// created is set for new blogs and translations.
+func (s *Server) moderate(ctx context.Context, author string, now time.Time, created bool, texts ...string) []model.ModerationFlag {
+	t, _ := tenant.FromContext(ctx)
+	return s.Moderation.Check(ctx, &moderation.Item{TenantID: t, AuthorID: author, Texts: texts, Time: now, Created: created})
+}
+
+// applyModeration holds a changed blog for review when it was flagged or
+// rejected before. A pending blog is published once a change passes the
+// chain and so do the other texts of the blog, a change of one language must
+// not publish flagged texts of another.
+func (s *Server) applyModeration(ctx context.Context, b *model.BlogItem, flags []model.ModerationFlag, now time.Time) {
+	switch {
+	case len(flags) > 0:
+		b.ModerationState = model.ModerationPending
+		b.Moderation = &model.ModerationItem{Flags: flags, FlaggedAt: now}
+	case b.ModerationState == model.ModerationRejected:
+		// changing a rejected blog appeals the decision, the note stays for the moderator
+		b.ModerationState = model.ModerationPending
+		if b.Moderation == nil {
+			b.Moderation = &model.ModerationItem{}
+		}
+		b.Moderation.FlaggedAt = now
+	case b.ModerationState == model.ModerationPending:
+		if flags := s.recheck(ctx, b, now); len(flags) > 0 {
+			b.Moderation = &model.ModerationItem{Flags: flags, FlaggedAt: now}
+			return
+		}
+		b.ModerationState = ""
+		b.Moderation = nil
+	}
+}
+
+// recheck runs the chain on the original and every translation of b, one
+// language at a time like they were submitted. Nothing is created, so the
+// flood stage neither counts nor flags them.
+func (s *Server) recheck(ctx context.Context, b *model.BlogItem, now time.Time) []model.ModerationFlag {
+	flags := s.moderate(ctx, b.AuthorId, now, false, b.Title, b.Content)
+	langs := make([]string, 0, len(b.Translations))
+	for lang := range b.Translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		t := b.Translations[lang]
+		flags = append(flags, s.moderate(ctx, b.AuthorId, now, false, t.Title, t.Content)...)
+	}
+	return flags
+}
+
+func moderationToPb(b *model.BlogItem) *pb.Moderation {
+	m := &pb.Moderation{State: pb.Moderation_PUBLISHED}
+	switch b.ModerationState {
+	case model.ModerationPending:
+		m.State = pb.Moderation_PENDING
+	case model.ModerationRejected:
+		m.State = pb.Moderation_REJECTED
+	}
+	if b.Moderation == nil {
+		return m
+	}
+
+	for _, f := range b.Moderation.Flags {
+		m.Flags = append(m.Flags, &pb.ModerationFlag{Stage: f.Stage, Reason: f.Reason})
+	}
+	if !b.Moderation.FlaggedAt.IsZero() {
+		m.FlagTime = timestamppb.New(b.Moderation.FlaggedAt)
+	}
+	if !b.Moderation.DecidedAt.IsZero() {
+		m.DecideTime = timestamppb.New(b.Moderation.DecidedAt)
+	}
+	m.DecidedBy = b.Moderation.DecidedBy
+	m.Note = b.Moderation.Note
+	return m
+}
+
+// moderatorContext returns ctx acting as the tenant whose blogs an admin
+// moderates, the admin stays the principal of the audit log.
+func (s *Server) moderatorContext(ctx context.Context, tenantID string) (context.Context, error) {
+	if _, err := s.Store.GetTenant(ctx, tenantID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, tenantNotFound(tenantID)
+		}
+		log.Printf("Could not find TenantItem: %v", err)
+		return nil, storageError(ctx)
+	}
+
+	p := &auth.Principal{Tenant: tenantID}
+	if admin, ok := auth.FromContext(ctx); ok {
+		p.Name, p.Admin = admin.Name, admin.Admin
+	}
+	return auth.NewContext(ctx, p), nil
+}
+
+func (s *Server) ListModerationQueue(ctx context.Context, r *pb.ListModerationQueueRequest) (*pb.ListModerationQueueResponse, error) {
+	ctx, err := s.moderatorContext(ctx, r.GetTenantId())
+	if err != nil {
+		return nil, err
+	}
+
+	state := model.ModerationPending
+	switch r.GetState() {
+	case pb.Moderation_STATE_UNSPECIFIED, pb.Moderation_PENDING:
+	case pb.Moderation_REJECTED:
+		state = model.ModerationRejected
+	default:
+		return nil, blogerr.InvalidArgument("state", "must be PENDING or REJECTED")
+	}
+
+	limit := int(r.GetLimit())
+	if limit < 0 || limit > 500 {
+		return nil, blogerr.InvalidArgument("limit", "must be between 0 and 500")
+	}
+	if limit == 0 {
+		limit = 50
+	}
+
+	res := &pb.ListModerationQueueResponse{}
+	err = s.Store.ListBlogs(ctx, storage.BlogQuery{Moderation: state, Limit: limit}, func(data *model.BlogItem) error {
+		res.Blogs = append(res.Blogs, s.localize(data, nil))
+		return nil
+	})
+	if err != nil {
+		log.Printf("Could not list moderation queue: %v", err)
+		return nil, databaseError(ctx)
+	}
+	return res, nil
+}
+
+func (s *Server) ApproveItem(ctx context.Context, r *pb.ApproveItemRequest) (*pb.ApproveItemResponse, error) {
+	ctx, err := s.moderatorContext(ctx, r.GetTenantId())
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.decide(ctx, r.GetBlogId(), r.GetVersion(), "", r.GetNote())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ApproveItemResponse{Blog: s.localize(data, nil)}, nil
+}
+
+func (s *Server) RejectItem(ctx context.Context, r *pb.RejectItemRequest) (*pb.RejectItemResponse, error) {
+	ctx, err := s.moderatorContext(ctx, r.GetTenantId())
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.decide(ctx, r.GetBlogId(), r.GetVersion(), model.ModerationRejected, r.GetNote())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RejectItemResponse{Blog: s.localize(data, nil)}, nil
+}
+
+// decide moves a blog to a moderation state, which it must not have already.
+func (s *Server) decide(ctx context.Context, id string, version int64, state, note string) (*model.BlogItem, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", id)
+	}
+
+	principal := ""
+	if p, ok := auth.FromContext(ctx); ok {
+		principal = p.Name
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	update := func(data *model.BlogItem) error {
+		if data.ModerationState == state {
+			msg := "blog is published already"
+			if state != "" {
+				msg = fmt.Sprintf("blog is %s already", state)
+			}
+			return blogerr.FailedPrecondition(blogerr.ReasonModerationState, msg,
+				blogerr.PreconditionViolation(blogerr.ReasonModerationState, id, msg))
+		}
+		data.ModerationState = state
+		if data.Moderation == nil {
+			data.Moderation = &model.ModerationItem{}
+		}
+		data.Moderation.DecidedAt = now
+		data.Moderation.DecidedBy = principal
+		data.Moderation.Note = note
+		return nil
+	}
+
+	var before, data *model.BlogItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		before, data, err = s.Store.UpdateBlog(ctx, oid, version, update)
+		if err != nil {
+			return err
+		}
+		if err := s.addBlogEvent(ctx, before, data); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: data.TenantID, BlogID: id, Before: before, After: data})
+	})
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, blogNotFound(id)
+	case errors.Is(err, storage.ErrVersionConflict):
+		return nil, s.updateConflict(ctx, oid, version)
+	case err != nil && isStatus(err):
+		return nil, err
+	case err != nil:
+		log.Printf("Could not moderate BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	s.Related.Put(data.TenantID, data)
+	return data, nil
+}
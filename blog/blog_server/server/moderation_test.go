@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/moderation"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+// newModeratedServer flags blogs containing "casino".
+func newModeratedServer(t *testing.T) (*Server, storage.Store, context.Context) {
+	s, store := newTestServer(t)
+	s.Moderation = moderation.Chain{moderation.NewWordList([]string{"casino"})}
+	return s, store, tenant.NewContext(context.Background(), "t1")
+}
+
+func mustState(t *testing.T, store storage.Store, ctx context.Context, id, want string) {
+	t.Helper()
+	oid, _ := primitive.ObjectIDFromHex(id)
+	b, err := store.GetBlog(ctx, oid)
+	if err != nil {
+		t.Fatalf("GetBlog: %v", err)
+	}
+	if b.ModerationState != want {
+		t.Fatalf("blog is %q, want %q", b.ModerationState, want)
+	}
+}
+
+func update(t *testing.T, s *Server, ctx context.Context, id, content string) {
+	t.Helper()
+	_, err := s.UpdateBlog(ctx, &pb.UpdateBlogRequest{Blog: &pb.Blog{Id: id, AuthorId: "author", Title: "title", Content: content}})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+}
+
+func translate(t *testing.T, s *Server, ctx context.Context, id, lang, content string) {
+	t.Helper()
+	_, err := s.PutTranslation(ctx, &pb.PutTranslationRequest{BlogId: id, Translation: &pb.Translation{Language: lang, Title: "title", Content: content}})
+	if err != nil {
+		t.Fatalf("PutTranslation: %v", err)
+	}
+}
+
+func TestFlaggedBlogIsHeld(t *testing.T) {
+	s, store, ctx := newModeratedServer(t)
+	res, err := s.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Title: "title", Content: "play at the casino"}})
+	if err != nil {
+		t.Fatalf("CreateBlog: %v", err)
+	}
+	id := res.GetBlog().GetId()
+	mustState(t, store, ctx, id, model.ModerationPending)
+	if m := res.GetBlog().GetModeration(); m.GetState() != pb.Moderation_PENDING || len(m.GetFlags()) != 1 || m.GetFlags()[0].GetStage() != "word_list" {
+		t.Fatalf("created blog has moderation %+v", m)
+	}
+	if _, err := s.ReadBlog(ctx, &pb.ReadBlogRequest{BlogId: id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("ReadBlog of a held blog returned %v, want NotFound", err)
+	}
+
+	// a clean change publishes it
+	update(t, s, ctx, id, "play chess")
+	mustState(t, store, ctx, id, "")
+}
+
+func TestHeldBlogIsNotPublishedByAnotherLanguage(t *testing.T) {
+	s, store, ctx := newModeratedServer(t)
+	id := createBlog(t, s, ctx, "clean").GetId()
+
+	// a flagged translation holds the blog, a clean original does not publish it
+	translate(t, s, ctx, id, "pl", "kasyno casino")
+	mustState(t, store, ctx, id, model.ModerationPending)
+	update(t, s, ctx, id, "still clean")
+	mustState(t, store, ctx, id, model.ModerationPending)
+
+	// a flagged original is not published by a clean translation
+	translate(t, s, ctx, id, "pl", "szachy")
+	mustState(t, store, ctx, id, "")
+	update(t, s, ctx, id, "casino again")
+	mustState(t, store, ctx, id, model.ModerationPending)
+	translate(t, s, ctx, id, "de", "Schach")
+	mustState(t, store, ctx, id, model.ModerationPending)
+
+	oid, _ := primitive.ObjectIDFromHex(id)
+	b, _ := store.GetBlog(ctx, oid)
+	if b.Moderation == nil || len(b.Moderation.Flags) != 1 {
+		t.Fatalf("held blog has moderation %+v, want the flag of the original", b.Moderation)
+	}
+
+	update(t, s, ctx, id, "clean again")
+	mustState(t, store, ctx, id, "")
+}
+
+func TestModerationQueue(t *testing.T) {
+	s, store, ctx := newModeratedServer(t)
+	create := func(content string) string {
+		res, err := s.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Title: "title", Content: content}})
+		if err != nil {
+			t.Fatalf("CreateBlog: %v", err)
+		}
+		return res.GetBlog().GetId()
+	}
+	approved, rejected := create("casino one"), create("casino two")
+	create("clean")
+
+	queue := func(state pb.Moderation_State) []string {
+		t.Helper()
+		res, err := s.ListModerationQueue(context.Background(), &pb.ListModerationQueueRequest{TenantId: "t1", State: state})
+		if err != nil {
+			t.Fatalf("ListModerationQueue: %v", err)
+		}
+		var ids []string
+		for _, b := range res.GetBlogs() {
+			ids = append(ids, b.GetId())
+		}
+		return ids
+	}
+	if ids := queue(pb.Moderation_PENDING); len(ids) != 2 {
+		t.Fatalf("queue holds %d blogs, want 2", len(ids))
+	}
+
+	if _, err := s.ApproveItem(context.Background(), &pb.ApproveItemRequest{TenantId: "t1", BlogId: approved, Note: "fine"}); err != nil {
+		t.Fatalf("ApproveItem: %v", err)
+	}
+	mustState(t, store, ctx, approved, "")
+	if _, err := s.RejectItem(context.Background(), &pb.RejectItemRequest{TenantId: "t1", BlogId: rejected, Note: "spam"}); err != nil {
+		t.Fatalf("RejectItem: %v", err)
+	}
+	mustState(t, store, ctx, rejected, model.ModerationRejected)
+
+	if ids := queue(pb.Moderation_PENDING); len(ids) != 0 {
+		t.Fatalf("queue holds %d blogs after the decisions, want 0", len(ids))
+	}
+	if ids := queue(pb.Moderation_REJECTED); len(ids) != 1 || ids[0] != rejected {
+		t.Fatalf("rejected queue holds %v, want %s", ids, rejected)
+	}
+
+	_, err := s.ApproveItem(context.Background(), &pb.ApproveItemRequest{TenantId: "t1", BlogId: approved})
+	if status.Code(err) != codes.FailedPrecondition || blogerr.Reason(err) != blogerr.ReasonModerationState {
+		t.Fatalf("approving a published blog returned %v, want FailedPrecondition", err)
+	}
+	if _, err := s.ListModerationQueue(context.Background(), &pb.ListModerationQueueRequest{TenantId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("queue of a missing tenant returned %v, want NotFound", err)
+	}
+
+	// changing a rejected blog appeals the decision
+	update(t, s, ctx, rejected, "clean now")
+	mustState(t, store, ctx, rejected, model.ModerationPending)
+}
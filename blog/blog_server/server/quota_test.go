@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sync"
+	"testing"
+)
+
+func TestPostQuotaHoldsUnderConcurrentCreates(t *testing.T) {
+	for backend := range testStores {
+		t.Run(backend, func(t *testing.T) {
+			s, store := newTestServerOn(t, backend)
+			_, _, err := store.UpdateTenant(context.Background(), "t1", func(t *model.TenantItem) error {
+				t.Quota.MaxPosts = 5
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("UpdateTenant: %v", err)
+			}
+			ctx := tenant.NewContext(context.Background(), "t1")
+
+			var mu sync.Mutex
+			codeCounts := make(map[codes.Code]int)
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				// every blog has its own author, so the flood stage holds none back
+				author := fmt.Sprintf("author%d", i)
+				go func() {
+					defer wg.Done()
+					_, err := s.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: author, Title: "title", Content: "content"}})
+					mu.Lock()
+					codeCounts[status.Code(err)]++
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			if codeCounts[codes.OK] != 5 || codeCounts[codes.ResourceExhausted] != 15 {
+				t.Fatalf("creates returned %v, want 5 OK and 15 ResourceExhausted", codeCounts)
+			}
+			if n := countBlogs(t, store, "t1"); n != 5 {
+				t.Fatalf("tenant has %d blogs, want 5", n)
+			}
+			// the quota of t1 does not limit t2
+			createBlog(t, s, tenant.NewContext(context.Background(), "t2"), "other tenant")
+		})
+	}
+}
+
+func TestReadingListQuotaHoldsUnderConcurrentCreates(t *testing.T) {
+	for backend := range testStores {
+		t.Run(backend, func(t *testing.T) {
+			s, store := newTestServerOn(t, backend)
+			ctx := tenant.NewContext(context.Background(), "t1")
+			for i := 0; i < MaxReadingLists-5; i++ {
+				if err := store.CreateReadingList(ctx, &model.ReadingListItem{ID: primitive.NewObjectID(), ReaderID: "reader", Name: "list"}); err != nil {
+					t.Fatalf("CreateReadingList: %v", err)
+				}
+			}
+
+			readerCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("reader-id", "reader"))
+			var mu sync.Mutex
+			codeCounts := make(map[codes.Code]int)
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := s.CreateReadingList(readerCtx, &pb.CreateReadingListRequest{List: &pb.ReadingList{Name: "list"}})
+					mu.Lock()
+					codeCounts[status.Code(err)]++
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			if codeCounts[codes.OK] != 5 || codeCounts[codes.ResourceExhausted] != 15 {
+				t.Fatalf("creates returned %v, want 5 OK and 15 ResourceExhausted", codeCounts)
+			}
+			lists, err := store.ReadingListsOf(ctx, "reader")
+			if err != nil || len(lists) != MaxReadingLists {
+				t.Fatalf("reader has %d lists, %v, want %d", len(lists), err, MaxReadingLists)
+			}
+			// the quota of a reader does not limit another one
+			otherCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("reader-id", "other"))
+			if _, err := s.CreateReadingList(otherCtx, &pb.CreateReadingListRequest{List: &pb.ReadingList{Name: "list"}}); err != nil {
+				t.Fatalf("CreateReadingList of another reader: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,524 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxReadingLists is the most lists a reader can have.
+	MaxReadingLists = 100
+	// MaxReadingListEntries is the most blogs a list can have.
+	MaxReadingListEntries = 1000
+	// readingListBatch is the most entries joined with their blogs and sent at once.
+	readingListBatch = 50
+)
+
+func (s *Server) CreateReadingList(ctx context.Context, r *pb.CreateReadingListRequest) (*pb.CreateReadingListResponse, error) {
+	readerID, err := reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	data := model.ReadingListItem{
+		ID:        primitive.NewObjectID(),
+		ReaderID:  readerID,
+		Name:      r.GetList().GetName(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	// the list is counted and created in one Tx, so concurrent requests
+	// cannot both take the last free list
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		data.TenantID, _ = tenant.FromContext(ctx)
+		if err := s.checkReadingListQuota(ctx, data.TenantID, readerID); err != nil {
+			return err
+		}
+		if err := s.Store.CreateReadingList(ctx, &data); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: data.TenantID, After: &data})
+	})
+	if err != nil {
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not insert ReadingListItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.CreateReadingListResponse{List: readingListToPb(&data)}, nil
+}
+
+// checkReadingListQuota locks the tenant like checkPostQuota, so creations
+// of lists wait for each other, and fails when the reader has no list left.
+// It must be called in a Tx.
+func (s *Server) checkReadingListQuota(ctx context.Context, tenantID, readerID string) error {
+	if _, err := s.Store.LockTenant(ctx, tenantID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return tenantNotFound(tenantID)
+		}
+		return err
+	}
+	lists, err := s.Store.ReadingListsOf(ctx, readerID)
+	if err != nil {
+		return err
+	}
+	if len(lists) >= MaxReadingLists {
+		return blogerr.QuotaExceeded(readerID, fmt.Sprintf("reader has %d reading lists already", MaxReadingLists))
+	}
+	return nil
+}
+
+func (s *Server) ListReadingLists(ctx context.Context, _ *pb.ListReadingListsRequest) (*pb.ListReadingListsResponse, error) {
+	readerID, err := reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := s.Store.ReadingListsOf(ctx, readerID)
+	if err != nil {
+		log.Printf("Could not list ReadingListItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	res := &pb.ListReadingListsResponse{}
+	for i := range lists {
+		res.Lists = append(res.Lists, readingListToPb(&lists[i]))
+	}
+	return res, nil
+}
+
+func (s *Server) RenameReadingList(ctx context.Context, r *pb.RenameReadingListRequest) (*pb.RenameReadingListResponse, error) {
+	data, err := s.updateReadingList(ctx, r.GetListId(), "", func(l *model.ReadingListItem) error {
+		l.Name = r.GetName()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RenameReadingListResponse{List: readingListToPb(data)}, nil
+}
+
+func (s *Server) DeleteReadingList(ctx context.Context, r *pb.DeleteReadingListRequest) (*pb.DeleteReadingListResponse, error) {
+	readerID, oid, err := readerAndList(ctx, r.GetListId())
+	if err != nil {
+		return nil, err
+	}
+
+	var before *model.ReadingListItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		data, err := s.Store.GetReadingList(ctx, oid)
+		if err != nil {
+			return err
+		}
+		if data.ReaderID != readerID {
+			return storage.ErrNotFound
+		}
+		if before, err = s.Store.DeleteReadingList(ctx, oid); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, Before: before})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, readingListNotFound(r.GetListId())
+		}
+		log.Printf("Could not delete ReadingListItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	return &pb.DeleteReadingListResponse{ListId: r.GetListId()}, nil
+}
+
+func (s *Server) AddToReadingList(ctx context.Context, r *pb.AddToReadingListRequest) (*pb.AddToReadingListResponse, error) {
+	bid, err := primitive.ObjectIDFromHex(r.GetBlogId())
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+	if r.GetPosition() < 0 {
+		return nil, blogerr.InvalidArgument("position", "cannot be negative")
+	}
+
+	blog, err := s.Store.GetBlog(ctx, bid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogNotFound(r.GetBlogId())
+		}
+		log.Printf("Could not find BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	if !blog.Published() {
+		return nil, blogNotFound(r.GetBlogId())
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	data, err := s.updateReadingList(ctx, r.GetListId(), r.GetBlogId(), func(l *model.ReadingListItem) error {
+		for _, e := range l.Entries {
+			if e.BlogID == bid {
+				return alreadyInReadingList(bid, l.ID)
+			}
+		}
+		if len(l.Entries) >= MaxReadingListEntries {
+			return blogerr.QuotaExceeded(l.ID.Hex(), fmt.Sprintf("reading list has %d blogs already", MaxReadingListEntries))
+		}
+
+		// a position past the end appends
+		at := len(l.Entries)
+		if p := int(r.GetPosition()) - 1; p >= 0 && p < at {
+			at = p
+		}
+		entries := make([]model.ReadingListEntry, 0, len(l.Entries)+1)
+		entries = append(entries, l.Entries[:at]...)
+		entries = append(entries, model.ReadingListEntry{BlogID: bid, Title: blog.Title, AddedAt: now})
+		l.Entries = append(entries, l.Entries[at:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AddToReadingListResponse{List: readingListToPb(data)}, nil
+}
+
+func (s *Server) RemoveFromReadingList(ctx context.Context, r *pb.RemoveFromReadingListRequest) (*pb.RemoveFromReadingListResponse, error) {
+	bid, err := primitive.ObjectIDFromHex(r.GetBlogId())
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+
+	// entries of deleted blogs can be removed too
+	data, err := s.updateReadingList(ctx, r.GetListId(), r.GetBlogId(), func(l *model.ReadingListItem) error {
+		entries := make([]model.ReadingListEntry, 0, len(l.Entries))
+		for _, e := range l.Entries {
+			if e.BlogID != bid {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == len(l.Entries) {
+			return blogerr.NotFound(blogerr.ReasonReadingListNotFound, blogerr.ResourceReadingList, r.GetListId(),
+				"reading list with specified id could not be found or blog is not part of it")
+		}
+		l.Entries = entries
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RemoveFromReadingListResponse{List: readingListToPb(data)}, nil
+}
+
+func (s *Server) ReorderReadingList(ctx context.Context, r *pb.ReorderReadingListRequest) (*pb.ReorderReadingListResponse, error) {
+	oids, err := parseBlogIds("blog_ids", r.GetBlogIds())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.updateReadingList(ctx, r.GetListId(), "", func(l *model.ReadingListItem) error {
+		// the new order must be a permutation of the stored one
+		stored := make(map[primitive.ObjectID]model.ReadingListEntry, len(l.Entries))
+		for _, e := range l.Entries {
+			stored[e.BlogID] = e
+		}
+		if len(oids) != len(l.Entries) {
+			return readingListMismatch(r.GetListId())
+		}
+		entries := make([]model.ReadingListEntry, 0, len(oids))
+		for _, id := range oids {
+			e, ok := stored[id]
+			if !ok {
+				return readingListMismatch(r.GetListId())
+			}
+			entries = append(entries, e)
+		}
+		l.Entries = entries
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReorderReadingListResponse{List: readingListToPb(data)}, nil
+}
+
+func (s *Server) ShareReadingList(ctx context.Context, r *pb.ShareReadingListRequest) (*pb.ShareReadingListResponse, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, databaseError(ctx)
+	}
+	token, hash, err := newShareToken(t, r.GetListId())
+	if err != nil {
+		log.Printf("Could not generate share token: %v", err)
+		return nil, blogerr.New(codes.Internal, blogerr.ReasonInternal, "cannot generate share token")
+	}
+
+	data, err := s.updateReadingList(ctx, r.GetListId(), "", func(l *model.ReadingListItem) error {
+		l.ShareTokenHash = hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ShareReadingListResponse{List: readingListToPb(data), ShareToken: token}, nil
+}
+
+func (s *Server) UnshareReadingList(ctx context.Context, r *pb.UnshareReadingListRequest) (*pb.UnshareReadingListResponse, error) {
+	data, err := s.updateReadingList(ctx, r.GetListId(), "", func(l *model.ReadingListItem) error {
+		l.ShareTokenHash = ""
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UnshareReadingListResponse{List: readingListToPb(data)}, nil
+}
+
+func (s *Server) StreamReadingList(r *pb.StreamReadingListRequest, stream pb.ReadingListService_StreamReadingListServer) error {
+	ctx := stream.Context()
+
+	readerID, oid, err := readerAndList(ctx, r.GetListId())
+	if err != nil {
+		return err
+	}
+	prefs, err := preferredLanguages(ctx, r.GetLanguages())
+	if err != nil {
+		return err
+	}
+
+	data, err := s.Store.GetReadingList(ctx, oid)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Could not find ReadingListItem: %v", err)
+		return databaseError(ctx)
+	}
+	if err != nil || data.ReaderID != readerID {
+		return readingListNotFound(r.GetListId())
+	}
+	return s.streamReadingList(ctx, data, prefs, stream.Send)
+}
+
+// StreamSharedReadingList is public, the share token names the tenant and the
+// list and is checked against the stored hash. Unknown, revoked and invalid
+// tokens are all not found.
+func (s *Server) StreamSharedReadingList(r *pb.StreamSharedReadingListRequest, stream pb.ReadingListService_StreamSharedReadingListServer) error {
+	ctx := stream.Context()
+	notFound := blogerr.NotFound(blogerr.ReasonReadingListNotFound, blogerr.ResourceReadingList, "", "shared reading list could not be found")
+
+	prefs, err := preferredLanguages(ctx, r.GetLanguages())
+	if err != nil {
+		return err
+	}
+
+	token := r.GetShareToken()
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 {
+		return notFound
+	}
+	oid, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return notFound
+	}
+
+	t, err := s.Store.GetTenant(ctx, parts[0])
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFound
+		}
+		log.Printf("Could not find TenantItem: %v", err)
+		return storageError(ctx)
+	}
+	if t.State == model.TenantSuspended {
+		return notFound
+	}
+
+	ctx = tenant.NewContext(ctx, t.ID)
+	data, err := s.Store.GetReadingList(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFound
+		}
+		log.Printf("Could not find ReadingListItem: %v", err)
+		return storageError(ctx)
+	}
+	if data.ShareTokenHash == "" || subtle.ConstantTimeCompare([]byte(hashAPIKey(token)), []byte(data.ShareTokenHash)) != 1 {
+		return notFound
+	}
+	return s.streamReadingList(ctx, data, prefs, stream.Send)
+}
+
+// streamReadingList sends the entries of a list in batches joined with their
+// blogs, the first batch carries the list. Entries of blogs that were deleted
+// or are held by moderation keep their saved title.
+func (s *Server) streamReadingList(ctx context.Context, data *model.ReadingListItem, prefs []language.Tag, send func(*pb.StreamReadingListResponse) error) error {
+	for start := 0; start == 0 || start < len(data.Entries); start += readingListBatch {
+		end := start + readingListBatch
+		if end > len(data.Entries) {
+			end = len(data.Entries)
+		}
+		batch := data.Entries[start:end]
+
+		ids := make([]primitive.ObjectID, 0, len(batch))
+		for _, e := range batch {
+			ids = append(ids, e.BlogID)
+		}
+		items, err := s.Store.GetBlogs(ctx, ids)
+		if err != nil {
+			log.Printf("Could not find BlogItem: %v", err)
+			return storageError(ctx)
+		}
+		blogs := make(map[primitive.ObjectID]*model.BlogItem, len(items))
+		for i := range items {
+			blogs[items[i].ID] = &items[i]
+		}
+
+		res := &pb.StreamReadingListResponse{}
+		if start == 0 {
+			res.List = readingListToPb(data)
+		}
+		for _, e := range batch {
+			entry := &pb.ReadingListEntry{
+				BlogId:  e.BlogID.Hex(),
+				State:   pb.ReadingListEntry_DELETED,
+				Title:   e.Title,
+				AddTime: timestamppb.New(e.AddedAt),
+			}
+			if b, ok := blogs[e.BlogID]; ok && b.Published() {
+				entry.State = pb.ReadingListEntry_AVAILABLE
+				entry.Blog = s.localize(b, prefs)
+				entry.Blog.Content = ""
+			} else if ok {
+				entry.State = pb.ReadingListEntry_UNAVAILABLE
+			}
+			res.Entries = append(res.Entries, entry)
+		}
+
+		if err := send(res); err != nil {
+			log.Printf("Could not send ReadingListEntry to stream: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// updateReadingList applies a change to a list of the reader, blogID is
+// recorded in the audit log when the change is about a blog.
+func (s *Server) updateReadingList(ctx context.Context, id, blogID string, apply func(l *model.ReadingListItem) error) (*model.ReadingListItem, error) {
+	readerID, oid, err := readerAndList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var data *model.ReadingListItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		before, after, err := s.Store.UpdateReadingList(ctx, oid, func(l *model.ReadingListItem) error {
+			if l.ReaderID != readerID {
+				return storage.ErrNotFound
+			}
+			if err := apply(l); err != nil {
+				return err
+			}
+			l.UpdatedAt = now
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		data = after
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, BlogID: blogID, Before: before, After: after})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, readingListNotFound(id)
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not update ReadingListItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	return data, nil
+}
+
+// reader identifies the signed-in reader by the reader-id metadata the site
+// of the tenant sends.
+func reader(ctx context.Context) (string, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("reader-id"); len(v) > 0 && v[0] != "" {
+			if len(v[0]) > 128 {
+				return "", blogerr.InvalidArgument("reader-id", "must be at most 128 characters long")
+			}
+			return v[0], nil
+		}
+	}
+	return "", blogerr.New(codes.Unauthenticated, blogerr.ReasonNoReader, "request names no reader in the reader-id metadata")
+}
+
+func readerAndList(ctx context.Context, listID string) (string, primitive.ObjectID, error) {
+	readerID, err := reader(ctx)
+	if err != nil {
+		return "", primitive.NilObjectID, err
+	}
+	oid, err := primitive.ObjectIDFromHex(listID)
+	if err != nil {
+		return "", oid, blogerr.InvalidID("list_id", listID)
+	}
+	return readerID, oid, nil
+}
+
+// newShareToken returns a token naming the tenant and the list, so the list
+// can be found without an index on the hash, and the hash that is stored.
+func newShareToken(tenantID, listID string) (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := tenantID + "." + listID + "." + hex.EncodeToString(b)
+	return token, hashAPIKey(token), nil
+}
+
+func readingListNotFound(id string) error {
+	return blogerr.NotFound(blogerr.ReasonReadingListNotFound, blogerr.ResourceReadingList, id, "reading list with specified id could not be found")
+}
+
+func readingListMismatch(id string) error {
+	return blogerr.FailedPrecondition(blogerr.ReasonReadingListMismatch, "blog_ids must contain exactly the blogs of the reading list",
+		blogerr.PreconditionViolation(blogerr.ReasonReadingListMismatch, id, "blog_ids must contain exactly the blogs of the reading list"))
+}
+
+func alreadyInReadingList(blogID, listID primitive.ObjectID) error {
+	msg := fmt.Sprintf("blog %s is already in reading list %s", blogID.Hex(), listID.Hex())
+	return blogerr.FailedPrecondition(blogerr.ReasonAlreadyInList, msg,
+		blogerr.PreconditionViolation(blogerr.ReasonAlreadyInList, blogID.Hex(), msg))
+}
+
+func readingListToPb(data *model.ReadingListItem) *pb.ReadingList {
+	ids := make([]string, 0, len(data.Entries))
+	for _, e := range data.Entries {
+		ids = append(ids, e.BlogID.Hex())
+	}
+
+	return &pb.ReadingList{
+		Id:         data.ID.Hex(),
+		Name:       data.Name,
+		BlogIds:    ids,
+		Shared:     data.ShareTokenHash != "",
+		CreateTime: timestamppb.New(data.CreatedAt),
+		UpdateTime: timestamppb.New(data.UpdatedAt),
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+)
+
+func (s *Server) RelatedBlogs(ctx context.Context, r *pb.RelatedBlogsRequest) (*pb.RelatedBlogsResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetBlogId())
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+
+	limit := int(r.GetLimit())
+	if limit < 0 || limit > 50 {
+		return nil, blogerr.InvalidArgument("limit", "must be between 0 and 50")
+	}
+	if limit == 0 {
+		limit = 5
+	}
+
+	prefs, err := preferredLanguages(ctx, r.GetLanguages())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.Store.GetBlog(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogNotFound(r.GetBlogId())
+		}
+		log.Printf("Could not find BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	if !data.Published() {
+		return nil, blogNotFound(r.GetBlogId())
+	}
+
+	related, err := s.Related.Related(ctx, data, limit)
+	if err != nil {
+		log.Printf("Could not find related BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(related))
+	for _, rel := range related {
+		ids = append(ids, rel.BlogID)
+	}
+	items, err := s.Store.GetBlogs(ctx, ids)
+	if err != nil {
+		log.Printf("Could not find BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	blogs := make(map[primitive.ObjectID]*pb.Blog, len(items))
+	for i := range items {
+		if !items[i].Published() {
+			continue
+		}
+		blog := s.localize(&items[i], prefs)
+		blog.Content = ""
+		blogs[items[i].ID] = blog
+	}
+
+	res := &pb.RelatedBlogsResponse{}
+	for _, rel := range related {
+		blog, ok := blogs[rel.BlogID]
+		if !ok {
+			// deleted or flagged on another replica
+			continue
+		}
+		res.Blogs = append(res.Blogs, &pb.RelatedBlog{Blog: blog, Score: rel.Score})
+	}
+	return res, nil
+}
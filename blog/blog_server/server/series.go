@@ -0,0 +1,391 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+)
+
+func (s *Server) CreateSeries(ctx context.Context, r *pb.CreateSeriesRequest) (*pb.CreateSeriesResponse, error) {
+	series := r.GetSeries()
+
+	oids, err := parseBlogIds("series.blog_ids", series.GetBlogIds())
+	if err != nil {
+		return nil, err
+	}
+
+	data := model.SeriesItem{
+		ID:          primitive.NewObjectID(),
+		Title:       series.GetTitle(),
+		Description: series.GetDescription(),
+		BlogIds:     oids,
+	}
+
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		for _, oid := range oids {
+			if err := s.checkBlogAvailable(ctx, oid); err != nil {
+				return err
+			}
+		}
+		if err := s.Store.CreateSeries(ctx, &data); err != nil {
+			return err
+		}
+		data.TenantID, _ = tenant.FromContext(ctx)
+		return s.Audit.Record(ctx, audit.Entry{TenantID: data.TenantID, SeriesID: data.ID.Hex(), After: &data})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrExists) {
+			return nil, s.takenBlogError(ctx, oids)
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not insert SeriesItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.CreateSeriesResponse{Series: seriesToPb(&data)}, nil
+}
+
+func (s *Server) ReadSeries(ctx context.Context, r *pb.ReadSeriesRequest) (*pb.ReadSeriesResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetSeriesId())
+	if err != nil {
+		return nil, blogerr.InvalidID("series_id", r.GetSeriesId())
+	}
+
+	data, err := s.Store.GetSeries(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, seriesNotFound(r.GetSeriesId())
+		}
+		log.Printf("Could not find SeriesItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.ReadSeriesResponse{Series: seriesToPb(data)}, nil
+}
+
+func (s *Server) DeleteSeries(ctx context.Context, r *pb.DeleteSeriesRequest) (*pb.DeleteSeriesResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetSeriesId())
+	if err != nil {
+		return nil, blogerr.InvalidID("series_id", r.GetSeriesId())
+	}
+
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		before, err := s.Store.DeleteSeries(ctx, oid)
+		if err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, SeriesID: r.GetSeriesId(), Before: before})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, seriesNotFound(r.GetSeriesId())
+		}
+		log.Printf("Could not delete SeriesItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.DeleteSeriesResponse{SeriesId: r.GetSeriesId()}, nil
+}
+
+func (s *Server) AddBlogToSeries(ctx context.Context, r *pb.AddBlogToSeriesRequest) (*pb.AddBlogToSeriesResponse, error) {
+	sid, bid, err := parseSeriesAndBlogId(r.GetSeriesId(), r.GetBlogId())
+	if err != nil {
+		return nil, err
+	}
+
+	if r.GetPosition() < 0 {
+		return nil, blogerr.InvalidArgument("position", "cannot be negative")
+	}
+
+	var data *model.SeriesItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		if err := s.checkBlogAvailable(ctx, bid); err != nil {
+			return err
+		}
+		var err error
+		data, err = s.updateSeries(ctx, sid, bid.Hex(), func(series *model.SeriesItem) error {
+			for _, id := range series.BlogIds {
+				if id == bid {
+					return alreadyInSeries(bid, sid)
+				}
+			}
+
+			// a position past the end appends
+			at := len(series.BlogIds)
+			if p := int(r.GetPosition()) - 1; p >= 0 && p < at {
+				at = p
+			}
+			ids := make([]primitive.ObjectID, 0, len(series.BlogIds)+1)
+			ids = append(ids, series.BlogIds[:at]...)
+			ids = append(ids, bid)
+			series.BlogIds = append(ids, series.BlogIds[at:]...)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, seriesNotFound(r.GetSeriesId())
+		}
+		if errors.Is(err, storage.ErrExists) {
+			return nil, s.takenBlogError(ctx, []primitive.ObjectID{bid})
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not add BlogItem to SeriesItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.AddBlogToSeriesResponse{Series: seriesToPb(data)}, nil
+}
+
+func (s *Server) RemoveBlogFromSeries(ctx context.Context, r *pb.RemoveBlogFromSeriesRequest) (*pb.RemoveBlogFromSeriesResponse, error) {
+	sid, bid, err := parseSeriesAndBlogId(r.GetSeriesId(), r.GetBlogId())
+	if err != nil {
+		return nil, err
+	}
+
+	notInSeries := blogerr.NotFound(blogerr.ReasonSeriesNotFound, blogerr.ResourceSeries, r.GetSeriesId(), "series with specified id could not be found or blog is not part of it")
+	data, err := s.updateSeries(ctx, sid, bid.Hex(), func(series *model.SeriesItem) error {
+		ids := make([]primitive.ObjectID, 0, len(series.BlogIds))
+		for _, id := range series.BlogIds {
+			if id != bid {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == len(series.BlogIds) {
+			return notInSeries
+		}
+		series.BlogIds = ids
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, notInSeries
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not remove BlogItem from SeriesItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.RemoveBlogFromSeriesResponse{Series: seriesToPb(data)}, nil
+}
+
+func (s *Server) ReorderSeries(ctx context.Context, r *pb.ReorderSeriesRequest) (*pb.ReorderSeriesResponse, error) {
+	sid, err := primitive.ObjectIDFromHex(r.GetSeriesId())
+	if err != nil {
+		return nil, blogerr.InvalidID("series_id", r.GetSeriesId())
+	}
+
+	oids, err := parseBlogIds("blog_ids", r.GetBlogIds())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.updateSeries(ctx, sid, "", func(series *model.SeriesItem) error {
+		// the new order must be a permutation of the stored one
+		stored := make(map[primitive.ObjectID]bool, len(series.BlogIds))
+		for _, id := range series.BlogIds {
+			stored[id] = true
+		}
+		if len(oids) != len(series.BlogIds) {
+			return seriesMismatch(r.GetSeriesId())
+		}
+		for _, id := range oids {
+			if !stored[id] {
+				return seriesMismatch(r.GetSeriesId())
+			}
+		}
+		series.BlogIds = oids
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, seriesNotFound(r.GetSeriesId())
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not reorder SeriesItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.ReorderSeriesResponse{Series: seriesToPb(data)}, nil
+}
+
+// updateSeries applies fn in a Tx with the audit record of the change, blogID
+// is recorded when the change is about a blog.
+func (s *Server) updateSeries(ctx context.Context, id primitive.ObjectID, blogID string, fn func(series *model.SeriesItem) error) (*model.SeriesItem, error) {
+	var data *model.SeriesItem
+	err := s.Store.Tx(ctx, func(ctx context.Context) error {
+		before, after, err := s.Store.UpdateSeries(ctx, id, fn)
+		if err != nil {
+			return err
+		}
+		data = after
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, BlogID: blogID, SeriesID: id.Hex(), Before: before, After: after})
+	})
+	return data, err
+}
+
+func seriesMismatch(id string) error {
+	return blogerr.FailedPrecondition(blogerr.ReasonSeriesMismatch, "blog_ids must contain exactly the blogs of the series",
+		blogerr.PreconditionViolation(blogerr.ReasonSeriesMismatch, id, "blog_ids must contain exactly the blogs of the series"))
+}
+
+func alreadyInSeries(blogID, seriesID primitive.ObjectID) error {
+	msg := fmt.Sprintf("blog %s already belongs to series %s", blogID.Hex(), seriesID.Hex())
+	return blogerr.FailedPrecondition(blogerr.ReasonAlreadyInSeries, msg,
+		blogerr.PreconditionViolation(blogerr.ReasonAlreadyInSeries, blogID.Hex(), msg))
+}
+
+// checkBlogAvailable makes sure the blog exists, is published and is not part
+// of any series yet. It is called in the Tx adding the blog, errors of the
+// store are returned unchanged.
+func (s *Server) checkBlogAvailable(ctx context.Context, oid primitive.ObjectID) error {
+	b, err := s.Store.GetBlog(ctx, oid)
+	if errors.Is(err, storage.ErrNotFound) {
+		return blogNotFound(oid.Hex())
+	}
+	if err != nil {
+		return err
+	}
+	// a held blog is not found like by ReadBlog
+	if !b.Published() {
+		return blogNotFound(oid.Hex())
+	}
+
+	data, err := s.Store.SeriesOfBlog(ctx, oid)
+	if err == nil {
+		return alreadyInSeries(oid, data.ID)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// takenBlogError reports the blog of oids another series took between
+// checkBlogAvailable and the write, which failed with storage.ErrExists.
+func (s *Server) takenBlogError(ctx context.Context, oids []primitive.ObjectID) error {
+	for _, oid := range oids {
+		data, err := s.Store.SeriesOfBlog(ctx, oid)
+		if err == nil {
+			return alreadyInSeries(oid, data.ID)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Could not find SeriesItem: %v", err)
+			return databaseError(ctx)
+		}
+	}
+	log.Printf("Could not find the SeriesItem holding one of the blogs %v", oids)
+	return databaseError(ctx)
+}
+
+// seriesNavigation returns nil when the blog is not part of any series.
+// Blogs of the series that are not published are skipped.
+func (s *Server) seriesNavigation(ctx context.Context, oid primitive.ObjectID) (*pb.SeriesNavigation, error) {
+	data, err := s.Store.SeriesOfBlog(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items, err := s.Store.GetBlogs(ctx, data.BlogIds)
+	if err != nil {
+		return nil, err
+	}
+	published := make(map[primitive.ObjectID]bool, len(items))
+	for i := range items {
+		published[items[i].ID] = items[i].Published()
+	}
+	ids := make([]primitive.ObjectID, 0, len(data.BlogIds))
+	for _, id := range data.BlogIds {
+		if published[id] || id == oid {
+			ids = append(ids, id)
+		}
+	}
+
+	nav := &pb.SeriesNavigation{
+		SeriesId:    data.ID.Hex(),
+		SeriesTitle: data.Title,
+		Total:       int32(len(ids)),
+	}
+	for i, id := range ids {
+		if id != oid {
+			continue
+		}
+		nav.Position = int32(i + 1)
+		if i > 0 {
+			nav.PreviousBlogId = ids[i-1].Hex()
+		}
+		if i < len(ids)-1 {
+			nav.NextBlogId = ids[i+1].Hex()
+		}
+	}
+
+	return nav, nil
+}
+
+func parseSeriesAndBlogId(seriesId, blogId string) (primitive.ObjectID, primitive.ObjectID, error) {
+	sid, err := primitive.ObjectIDFromHex(seriesId)
+	if err != nil {
+		return sid, primitive.NilObjectID, blogerr.InvalidID("series_id", seriesId)
+	}
+
+	bid, err := primitive.ObjectIDFromHex(blogId)
+	if err != nil {
+		return sid, bid, blogerr.InvalidID("blog_id", blogId)
+	}
+
+	return sid, bid, nil
+}
+
+// parseBlogIds reports violations at field[i].
+func parseBlogIds(field string, ids []string) ([]primitive.ObjectID, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for i, id := range ids {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, blogerr.InvalidID(path, id)
+		}
+		if seen[oid] {
+			return nil, blogerr.InvalidArgument(path, "blog %s is listed more than once", id)
+		}
+		seen[oid] = true
+		oids = append(oids, oid)
+	}
+	return oids, nil
+}
+
+func seriesToPb(data *model.SeriesItem) *pb.Series {
+	ids := make([]string, 0, len(data.BlogIds))
+	for _, id := range data.BlogIds {
+		ids = append(ids, id.Hex())
+	}
+
+	return &pb.Series{
+		Id:          data.ID.Hex(),
+		Title:       data.Title,
+		Description: data.Description,
+		BlogIds:     ids,
+	}
+}
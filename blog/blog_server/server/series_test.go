@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+)
+
+// racingStore misses the series of the next blog checkBlogAvailable looks
+// up, like a request adding the blog to another series right after the check.
+type racingStore struct {
+	storage.Store
+	race bool
+}
+
+func (s *racingStore) SeriesOfBlog(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	if s.race {
+		s.race = false
+		return nil, storage.ErrNotFound
+	}
+	return s.Store.SeriesOfBlog(ctx, id)
+}
+
+func mustBeInSeries(t *testing.T, err error, series string) {
+	t.Helper()
+	if status.Code(err) != codes.FailedPrecondition || blogerr.Reason(err) != blogerr.ReasonAlreadyInSeries {
+		t.Fatalf("returned %v, want FailedPrecondition %s", err, blogerr.ReasonAlreadyInSeries)
+	}
+	if msg := status.Convert(err).Message(); !strings.Contains(msg, series) {
+		t.Fatalf("error %q does not name series %s", msg, series)
+	}
+}
+
+func TestBlogTakenByRacingSeries(t *testing.T) {
+	for backend := range testStores {
+		backend := backend
+		t.Run(backend, func(t *testing.T) {
+			s, _ := newTestServerOn(t, backend)
+			ctx := tenant.NewContext(context.Background(), "t1")
+			taken, free := createBlog(t, s, ctx, "taken"), createBlog(t, s, ctx, "free")
+			res, err := s.CreateSeries(ctx, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "first", BlogIds: []string{taken.GetId()}}})
+			if err != nil {
+				t.Fatalf("CreateSeries: %v", err)
+			}
+			first := res.GetSeries().GetId()
+
+			store := &racingStore{Store: s.Store}
+			s.Store = store
+
+			store.race = true
+			_, err = s.CreateSeries(ctx, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "second", BlogIds: []string{taken.GetId()}}})
+			mustBeInSeries(t, err, first)
+
+			res, err = s.CreateSeries(ctx, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "second", BlogIds: []string{free.GetId()}}})
+			if err != nil {
+				t.Fatalf("CreateSeries: %v", err)
+			}
+			store.race = true
+			_, err = s.AddBlogToSeries(ctx, &pb.AddBlogToSeriesRequest{SeriesId: res.GetSeries().GetId(), BlogId: taken.GetId()})
+			mustBeInSeries(t, err, first)
+		})
+	}
+}
+
+// txStore counts the blogs read outside a Tx.
+type txStore struct {
+	storage.Store
+	outside int
+}
+
+type inTxKey struct{}
+
+func (s *txStore) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.Store.Tx(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, inTxKey{}, true))
+	})
+}
+
+func (s *txStore) GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	if ctx.Value(inTxKey{}) == nil {
+		s.outside++
+	}
+	return s.Store.GetBlog(ctx, id)
+}
+
+// hold puts the blog back into moderation.
+func hold(t *testing.T, store storage.Store, ctx context.Context, id string) {
+	t.Helper()
+	oid, _ := primitive.ObjectIDFromHex(id)
+	_, _, err := store.UpdateBlog(ctx, oid, 0, func(b *model.BlogItem) error {
+		b.ModerationState = model.ModerationPending
+		b.Moderation = &model.ModerationItem{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+}
+
+func TestSeriesTakeOnlyPublishedBlogsInTx(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	published, held := createBlog(t, s, ctx, "published"), createBlog(t, s, ctx, "held")
+	hold(t, store, ctx, held.GetId())
+	checked := &txStore{Store: store}
+	s.Store = checked
+
+	_, err := s.CreateSeries(ctx, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "series", BlogIds: []string{published.GetId(), held.GetId()}}})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("CreateSeries with a held blog returned %v, want NotFound", err)
+	}
+	res, err := s.CreateSeries(ctx, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "series", BlogIds: []string{published.GetId()}}})
+	if err != nil {
+		t.Fatalf("CreateSeries: %v", err)
+	}
+	_, err = s.AddBlogToSeries(ctx, &pb.AddBlogToSeriesRequest{SeriesId: res.GetSeries().GetId(), BlogId: held.GetId()})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("AddBlogToSeries of a held blog returned %v, want NotFound", err)
+	}
+	if checked.outside != 0 {
+		t.Fatalf("%d blogs were checked outside the Tx", checked.outside)
+	}
+}
+
+func TestSeriesNavigationSkipsUnpublishedBlogs(t *testing.T) {
+	s, store := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+	first, middle, last := createBlog(t, s, ctx, "first"), createBlog(t, s, ctx, "middle"), createBlog(t, s, ctx, "last")
+	_, err := s.CreateSeries(ctx, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "series", BlogIds: []string{first.GetId(), middle.GetId(), last.GetId()}}})
+	if err != nil {
+		t.Fatalf("CreateSeries: %v", err)
+	}
+	hold(t, store, ctx, middle.GetId())
+
+	nav := func(id string) *pb.SeriesNavigation {
+		t.Helper()
+		res, err := s.ReadBlog(ctx, &pb.ReadBlogRequest{BlogId: id})
+		if err != nil {
+			t.Fatalf("ReadBlog: %v", err)
+		}
+		return res.GetSeries()
+	}
+	if n := nav(first.GetId()); n.GetNextBlogId() != last.GetId() || n.GetTotal() != 2 || n.GetPosition() != 1 {
+		t.Fatalf("navigation of the first blog is %v, want the last one next of 2", n)
+	}
+	if n := nav(last.GetId()); n.GetPreviousBlogId() != first.GetId() || n.GetNextBlogId() != "" || n.GetPosition() != 2 {
+		t.Fatalf("navigation of the last blog is %v, want the first one before it at 2", n)
+	}
+}
@@ -4,50 +4,141 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/analytics"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/blogstats"
 	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/moderation"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/outbox"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/related"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
 	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/dbielecki97/grpc-go-course/interceptor/readonly"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"log"
+	"strings"
 	"time"
 )
 
 type Server struct {
-	Collection *mongo.Collection
+	Store storage.Store
+	Views *analytics.Views
+	Audit *audit.Log
+	// Outbox receives an event in the transaction of every blog change.
+	Outbox *outbox.Outbox
+	// Related is told about every blog change.
+	Related *related.Index
+	// Stats answers BlogStatsService from a cache refreshed by its Run.
+	Stats *blogstats.Cache
+	// Moderation checks created and changed blogs, flagged blogs are not
+	// published until they are approved.
+	Moderation moderation.Chain
+	// ReadOnly rejects the methods changing data while it is switched on by
+	// SetReadOnly, it has to be chained into the interceptors of the servers.
+	ReadOnly *readonly.Interceptor
+	// AdminToken authenticates admins, empty disables admin access.
+	AdminToken string
+	// Languages is the fallback chain used when none of the preferred languages
+	// is available, the first one is the default language of new blogs.
+	Languages []language.Tag
+	// ListBatchSize and ListBatchBytes limit the blogs sent in one ListBlog
+	// response, zero uses DefaultListBatchSize and DefaultListBatchBytes.
+	ListBatchSize  int
+	ListBatchBytes int
+	// ListTimeout is the deadline of ListBlog streams sent without one and
+	// without a throughput cap, zero uses DefaultListTimeout and a negative
+	// one disables it.
+	ListTimeout time.Duration
 }
 
-func New(collection *mongo.Collection) *Server {
-	return &Server{Collection: collection}
+func New(store storage.Store, views *analytics.Views, auditLog *audit.Log) *Server {
+	return &Server{
+		Store:      store,
+		Views:      views,
+		Audit:      auditLog,
+		Outbox:     outbox.New(store),
+		Related:    related.New(store),
+		Stats:      blogstats.New(store),
+		Moderation: moderation.Default(),
+		ReadOnly:   newReadOnly(),
+	}
+}
+
+func newReadOnly() *readonly.Interceptor {
+	ro := readonly.New(append(append([]string(nil), MutatingMethods...), AdminMutatingMethods...)...)
+	ro.Err = blogerr.New(codes.Unavailable, blogerr.ReasonReadOnly, "server is read-only for maintenance, try again later")
+	return ro
 }
 
 func (s *Server) CreateBlog(ctx context.Context, r *pb.CreateBlogRequest) (*pb.CreateBlogResponse, error) {
 	blog := r.GetBlog()
+	if blog == nil {
+		return nil, blogerr.MissingField("blog")
+	}
 
-	data := model.BlogItem{
-		AuthorId: blog.AuthorId,
-		Content:  blog.Content,
-		Title:    blog.Title,
+	lang := s.defaultLanguage()
+	if blog.GetLanguage() != "" {
+		var err error
+		lang, err = normalizeLanguage("blog.language", blog.GetLanguage())
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	result, err := s.Collection.InsertOne(context.Background(), data)
+	t, err := tenant.FromContext(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, fmt.Sprintf("internal error: %v", err))
+		return nil, databaseError(ctx)
 	}
 
-	id, ok := result.InsertedID.(primitive.ObjectID)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, fmt.Sprintf("cannot cast OID"))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	// the id is chosen here, so the outbox event can refer to the blog
+	data := model.BlogItem{
+		ID:        primitive.NewObjectID(),
+		TenantID:  t,
+		AuthorId:  blog.AuthorId,
+		Content:   blog.Content,
+		Title:     blog.Title,
+		Slug:      model.Slugify(blog.Title),
+		Language:  lang,
+		Tags:      normalizeTags(blog.GetTags()),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
 	}
+	s.applyModeration(ctx, &data, s.moderate(ctx, blog.AuthorId, now, true, blog.Title, blog.Content), now)
 
-	res := &pb.CreateBlogResponse{Blog: &pb.Blog{
-		Id:       id.Hex(),
-		AuthorId: data.AuthorId,
-		Title:    data.Title,
-		Content:  data.Content,
-	}}
+	// the blog, its outbox event and audit record are stored together, after
+	// the quota was checked in the same Tx
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		if err := s.checkPostQuota(ctx, t); err != nil {
+			return err
+		}
+		if err := s.Store.CreateBlog(ctx, &data); err != nil {
+			return err
+		}
+		if err := s.addBlogEvent(ctx, nil, &data); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: t, BlogID: data.ID.Hex(), After: &data})
+	})
+	if err != nil {
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not insert BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	s.Related.Put(t, &data)
+
+	res := &pb.CreateBlogResponse{Blog: s.localize(&data, nil)}
 
 	return res, nil
 }
@@ -55,112 +146,303 @@ func (s *Server) CreateBlog(ctx context.Context, r *pb.CreateBlogRequest) (*pb.C
 func (s *Server) ReadBlog(ctx context.Context, r *pb.ReadBlogRequest) (*pb.ReadBlogResponse, error) {
 	oid, err := primitive.ObjectIDFromHex(r.GetBlogId())
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("could not create OID from blog_id string: %v", err))
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+
+	prefs, err := preferredLanguages(ctx, r.GetLanguages())
+	if err != nil {
+		return nil, err
 	}
 
-	result := s.Collection.FindOne(context.Background(), &bson.D{{"_id", oid}})
-	mErr := result.Err()
-	if mErr != nil {
-		if errors.As(mErr, &mongo.ErrNoDocuments) {
-			return nil, status.Errorf(codes.NotFound, fmt.Sprintf("cannot find blog with specified id"))
+	data, err := s.Store.GetBlog(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogNotFound(r.GetBlogId())
 		}
+		log.Printf("Could not find BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	if !data.Published() {
+		return nil, blogNotFound(r.GetBlogId())
 	}
 
-	var data model.BlogItem
-	err = result.Decode(&data)
+	nav, err := s.seriesNavigation(ctx, data.ID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, fmt.Sprintf("cannot decode BlogItem: %v", err))
+		log.Printf("Could not read series of BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	res := &pb.ReadBlogResponse{Blog: s.localize(data, prefs), Series: nav}
+	if res.Etag, err = etag(res); err != nil {
+		log.Printf("Could not compute etag of BlogItem: %v", err)
+		return nil, blogerr.New(codes.Internal, blogerr.ReasonInternal, "could not compute etag")
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs("etag", res.Etag)); err != nil {
+		log.Printf("Could not set etag header: %v", err)
+	}
+	if etagMatches(ifNoneMatch(ctx, r.GetIfNoneMatch()), res.Etag) {
+		return &pb.ReadBlogResponse{Etag: res.Etag, NotModified: true}, nil
 	}
 
-	res := &pb.ReadBlogResponse{Blog: &pb.Blog{
-		Id:       data.ID.Hex(),
-		AuthorId: data.AuthorId,
-		Title:    data.Title,
-		Content:  data.Content,
-	}}
+	s.Views.Record(data.TenantID, data.ID, viewer(ctx), time.Now())
 	return res, nil
 }
 
 func (s *Server) UpdateBlog(ctx context.Context, r *pb.UpdateBlogRequest) (*pb.UpdateBlogResponse, error) {
 	blog := r.GetBlog()
+	if blog == nil {
+		return nil, blogerr.MissingField("blog")
+	}
 	oid, err := primitive.ObjectIDFromHex(blog.GetId())
 	if err != nil {
-		log.Printf("Could not create OID from string: %v", err)
-		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("id is not a hex format"))
+		return nil, blogerr.InvalidID("blog.id", blog.GetId())
 	}
 
-	item := &model.BlogItem{
-		ID:       oid,
-		AuthorId: blog.AuthorId,
-		Content:  blog.Content,
-		Title:    blog.Title,
+	lang := ""
+	if blog.GetLanguage() != "" {
+		lang, err = normalizeLanguage("blog.language", blog.GetLanguage())
+		if err != nil {
+			return nil, err
+		}
 	}
-	result, err := s.Collection.ReplaceOne(context.Background(), &bson.D{{"_id", oid}}, item)
-	if err != nil {
-		log.Printf("could not replace BlogItem: %v", err)
-		return nil, status.Errorf(codes.Internal, "unexpected database error")
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	flags := s.moderate(ctx, blog.AuthorId, now, false, blog.Title, blog.Content)
+
+	// translations are managed separately, so only the original is replaced
+	update := func(data *model.BlogItem) error {
+		data.AuthorId = blog.AuthorId
+		data.Content = blog.Content
+		data.Title = blog.Title
+		data.Slug = model.Slugify(blog.Title)
+		data.Tags = normalizeTags(blog.GetTags())
+		data.UpdatedAt = now
+		if lang != "" {
+			data.Language = lang
+		}
+		s.applyModeration(ctx, data, flags, now)
+		return nil
 	}
 
-	if result.MatchedCount == 0 {
-		return nil, status.Errorf(codes.NotFound, "blog with specified id could not be found")
+	var before, data *model.BlogItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		before, data, err = s.Store.UpdateBlog(ctx, oid, blog.GetVersion(), update)
+		if err != nil {
+			return err
+		}
+		if err := s.addBlogEvent(ctx, before, data); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: data.TenantID, BlogID: oid.Hex(), Before: before, After: data})
+	})
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, blogNotFound(blog.GetId())
+	case errors.Is(err, storage.ErrVersionConflict):
+		return nil, s.updateConflict(ctx, oid, blog.GetVersion())
+	case err != nil:
+		log.Printf("could not update BlogItem: %v", err)
+		return nil, databaseError(ctx)
 	}
+	s.Related.Put(data.TenantID, data)
 
-	res := &pb.UpdateBlogResponse{Blog: &pb.Blog{
-		Id:       blog.Id,
-		AuthorId: blog.AuthorId,
-		Title:    blog.Title,
-		Content:  blog.Content,
-	}}
+	res := &pb.UpdateBlogResponse{Blog: s.localize(data, nil)}
 	return res, nil
 }
 
 func (s *Server) DeleteBlog(ctx context.Context, r *pb.DeleteBlogRequest) (*pb.DeleteBlogResponse, error) {
 	oid, err := primitive.ObjectIDFromHex(r.BlogId)
 	if err != nil {
-		log.Printf("Could not create OID from string: %v", err)
-		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("id is not a hex format"))
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
 	}
 
-	result, err := s.Collection.DeleteOne(context.Background(), &bson.D{{"_id", oid}})
+	var before *model.BlogItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.Store.DeleteBlog(ctx, oid); err != nil {
+			return err
+		}
+		if err := s.addBlogEvent(ctx, before, nil); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, BlogID: oid.Hex(), Before: before})
+	})
 	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogNotFound(r.GetBlogId())
+		}
 		log.Printf("Could not delete BlogItem: %v", err)
-		return nil, status.Errorf(codes.Internal, fmt.Sprintf("unexpected database error"))
-	}
-
-	if result.DeletedCount == 0 {
-		return nil, status.Errorf(codes.NotFound, "blog with specified id could not be found")
+		return nil, databaseError(ctx)
 	}
+	s.Related.Remove(before.TenantID, oid)
 
 	return &pb.DeleteBlogResponse{BlogId: r.BlogId}, nil
 }
 
 func (s *Server) ListBlog(r *pb.ListBlogRequest, stream pb.BlogService_ListBlogServer) error {
-	cur, err := s.Collection.Find(context.Background(), &bson.D{})
+	ctx := stream.Context()
+	prefs, err := preferredLanguages(ctx, r.GetLanguages())
 	if err != nil {
-		log.Printf("Could not list BlogItem: %v", err)
-		return status.Errorf(codes.Internal, fmt.Sprintf("unexpected database error"))
+		return err
 	}
-	defer cur.Close(context.Background())
 
-	for cur.Next(context.Background()) {
-		time.Sleep(time.Second)
-		var data model.BlogItem
-		err := cur.Decode(&data)
-		if err != nil {
-			log.Printf("Could not decode BlogItem")
-			return status.Errorf(codes.Internal, fmt.Sprintf("error while decoding data: %v", err))
+	if r.GetBatchSize() < 0 {
+		return blogerr.InvalidArgument("batch_size", "cannot be negative")
+	}
+	if r.GetMaxBytesPerSecond() < 0 {
+		return blogerr.InvalidArgument("max_bytes_per_second", "cannot be negative")
+	}
+
+	batchSize := s.listBatchSize()
+	if n := int(r.GetBatchSize()); n > 0 && n < batchSize {
+		batchSize = n
+	}
+	batchBytes := s.listBatchBytes()
+
+	ctx, cancel := s.listContext(ctx, r)
+	defer cancel()
+
+	limiter := newByteLimiter(r.GetMaxBytesPerSecond())
+	var batch []*pb.Blog
+	size := 0
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := limiter.wait(ctx, size); err != nil {
+			return databaseError(ctx)
 		}
+		if err := stream.Send(&pb.ListBlogResponse{Blogs: batch}); err != nil {
+			log.Printf("Could not send BlogItem to stream: %v", err)
+			return err
+		}
+		batch, size = nil, 0
+		return nil
+	}
 
-		err = stream.Send(&pb.ListBlogResponse{Blog: &pb.Blog{
-			Id:       data.ID.Hex(),
-			AuthorId: data.AuthorId,
-			Title:    data.Title,
-			Content:  data.Content,
-		}})
-		if err != nil {
-			log.Printf("Could not send BlogItem to stream")
-			return status.Errorf(codes.Internal, fmt.Sprintf("error while decoding data: %v", err))
+	// errors of send are returned unchanged, everything else failed in the storage
+	var sendErr error
+	q := storage.BlogQuery{
+		AuthorID:  r.GetAuthorId(),
+		Tag:       strings.ToLower(strings.TrimSpace(r.GetTag())),
+		Text:      r.GetText(),
+		BatchSize: batchSize,
+	}
+	err = s.Store.ListBlogs(ctx, q, func(data *model.BlogItem) error {
+		blog := s.localize(data, prefs)
+		n := proto.Size(blog)
+		// a blog larger than the byte limit is sent on its own
+		if len(batch) > 0 && size+n > batchBytes {
+			if sendErr = send(); sendErr != nil {
+				return sendErr
+			}
+		}
+		batch = append(batch, blog)
+		size += n
+		if len(batch) >= batchSize {
+			sendErr = send()
 		}
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		log.Printf("Could not list BlogItem: %v", err)
+		return databaseError(ctx)
+	}
+	return send()
+}
+
+// updateConflict tells apart a missing blog from a stale version after a
+// versioned update matched nothing.
+func (s *Server) updateConflict(ctx context.Context, oid primitive.ObjectID, version int64) error {
+	if version == 0 {
+		return blogNotFound(oid.Hex())
+	}
+
+	data, err := s.Store.GetBlog(ctx, oid)
+	if errors.Is(err, storage.ErrNotFound) {
+		return blogNotFound(oid.Hex())
+	}
+	if err != nil {
+		log.Printf("Could not find BlogItem: %v", err)
+		return databaseError(ctx)
+	}
+
+	msg := fmt.Sprintf("blog was changed, version %d is not the current version %d", version, data.Version)
+	return blogerr.FailedPrecondition(blogerr.ReasonVersionConflict, msg,
+		blogerr.PreconditionViolation(blogerr.ReasonVersionConflict, oid.Hex(), msg))
+}
+
+// addBlogEvent adds the outbox event of a change of a blog from before to
+// after, nil when it did not exist. Webhooks only see published blogs, so
+// content held by moderation never reaches them: a blog becoming published
+// is created for them, a blog no longer published is deleted with the
+// version they saw, and changes of unpublished blogs send nothing.
+func (s *Server) addBlogEvent(ctx context.Context, before, after *model.BlogItem) error {
+	published := func(b *model.BlogItem) bool {
+		return b != nil && b.Published()
+	}
+	switch {
+	case published(before) && published(after):
+		return s.Outbox.Add(ctx, after.TenantID, outbox.EventBlogUpdated, after.ID.Hex(), s.localize(after, nil))
+	case published(after):
+		return s.Outbox.Add(ctx, after.TenantID, outbox.EventBlogCreated, after.ID.Hex(), s.localize(after, nil))
+	case published(before):
+		return s.Outbox.Add(ctx, before.TenantID, outbox.EventBlogDeleted, before.ID.Hex(), s.localize(before, nil))
 	}
 	return nil
 }
+
+// databaseError reports a failed storage call of a tenant scoped request,
+// scoped calls of a principal without a tenant always fail.
+func databaseError(ctx context.Context) error {
+	if _, err := tenant.FromContext(ctx); err != nil {
+		return blogerr.New(codes.PermissionDenied, blogerr.ReasonNoTenant, "request is not authenticated as a tenant")
+	}
+	return storageError(ctx)
+}
+
+// storageError reports a failed storage call, telling apart calls stopped by
+// a cancelled or expired request.
+func storageError(ctx context.Context) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return blogerr.New(codes.Canceled, blogerr.ReasonCanceled, "request was cancelled")
+	case context.DeadlineExceeded:
+		return blogerr.New(codes.DeadlineExceeded, blogerr.ReasonDeadlineExceeded, "request deadline exceeded")
+	}
+	return blogerr.New(codes.Internal, blogerr.ReasonDatabase, "unexpected database error")
+}
+
+func blogNotFound(id string) error {
+	return blogerr.NotFound(blogerr.ReasonBlogNotFound, blogerr.ResourceBlog, id, "blog with specified id could not be found")
+}
+
+func seriesNotFound(id string) error {
+	return blogerr.NotFound(blogerr.ReasonSeriesNotFound, blogerr.ResourceSeries, id, "series with specified id could not be found")
+}
+
+// isStatus reports whether err is a status error, such as one returned by the
+// update func of a handler, which is passed on unchanged.
+func isStatus(err error) bool {
+	_, ok := status.FromError(err)
+	return ok
+}
+
+// normalizeTags lower-cases and trims tags, dropping empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var res []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	return res
+}
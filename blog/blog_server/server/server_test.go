@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/analytics"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/grpc"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testStores open an empty store in a temporary directory for every backend
+// that runs without a database server.
+var testStores = map[string]func(t *testing.T) (storage.Store, error){
+	"bolt": func(t *testing.T) (storage.Store, error) {
+		return storage.OpenBolt(filepath.Join(t.TempDir(), "blog.bolt"))
+	},
+	storage.DriverSQLite: func(t *testing.T) (storage.Store, error) {
+		return storage.OpenSQL(context.Background(), storage.DriverSQLite, filepath.Join(t.TempDir(), "blog.db"))
+	},
+}
+
+// newTestServer serves a bolt store with the active tenants t1 and t2.
+func newTestServer(t *testing.T) (*Server, storage.Store) {
+	return newTestServerOn(t, "bolt")
+}
+
+// newTestServerOn serves a store of testStores with the active tenants t1 and t2.
+func newTestServerOn(t *testing.T, backend string) (*Server, storage.Store) {
+	t.Helper()
+	store, err := testStores[backend](t)
+	if err != nil {
+		t.Fatalf("could not open %s store: %v", backend, err)
+	}
+	views := analytics.New(store)
+	t.Cleanup(func() {
+		views.Close()
+		store.Close(context.Background())
+	})
+
+	for _, id := range []string{"t1", "t2"} {
+		err := store.CreateTenant(context.Background(), &model.TenantItem{ID: id, State: model.TenantActive, CreatedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("CreateTenant: %v", err)
+		}
+	}
+	return New(store, views, audit.New(store)), store
+}
+
+func createBlog(t *testing.T, s *Server, ctx context.Context, title string) *pb.Blog {
+	t.Helper()
+	res, err := s.CreateBlog(ctx, &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Title: title, Content: "content of " + title}})
+	if err != nil {
+		t.Fatalf("CreateBlog: %v", err)
+	}
+	return res.GetBlog()
+}
+
+// countBlogs counts the blogs of the tenant in every moderation state.
+func countBlogs(t *testing.T, store storage.Store, tenantID string) int64 {
+	t.Helper()
+	n, err := store.CountBlogs(tenant.NewContext(context.Background(), tenantID))
+	if err != nil {
+		t.Fatalf("CountBlogs: %v", err)
+	}
+	return n
+}
+
+// listStream collects the blogs ListBlog sends.
+type listStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	blogs []*pb.Blog
+}
+
+func (s *listStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *listStream) Send(res *pb.ListBlogResponse) error {
+	s.blogs = append(s.blogs, res.GetBlogs()...)
+	return nil
+}
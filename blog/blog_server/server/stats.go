@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"net"
+	"time"
+)
+
+func (s *Server) GetBlogStats(ctx context.Context, r *pb.GetBlogStatsRequest) (*pb.GetBlogStatsResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetBlogId())
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+
+	from, to, err := timeRange(r.GetFrom(), r.GetTo(), 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	// views carry the tenant, a blog of another tenant has no views here
+	days, err := s.Views.Daily(ctx, oid, from, to)
+	if err != nil {
+		log.Printf("Could not aggregate daily views: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	res := &pb.GetBlogStatsResponse{BlogId: r.GetBlogId()}
+	for _, d := range days {
+		res.Days = append(res.Days, &pb.DailyViews{
+			Day:         d.Day,
+			TotalViews:  d.Total,
+			UniqueViews: d.Unique,
+		})
+	}
+	return res, nil
+}
+
+func (s *Server) TopBlogs(ctx context.Context, r *pb.TopBlogsRequest) (*pb.TopBlogsResponse, error) {
+	from, to, err := timeRange(r.GetFrom(), r.GetTo(), 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(r.GetLimit())
+	if limit < 0 || limit > 100 {
+		return nil, blogerr.InvalidArgument("limit", "must be between 0 and 100")
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	// views of deleted and held blogs are counted too, more blogs are read
+	// until limit of them are published or the views run out
+	res := &pb.TopBlogsResponse{}
+	for fetch := limit; ; fetch *= 2 {
+		top, err := s.Views.Top(ctx, from, to, fetch)
+		if err != nil {
+			log.Printf("Could not aggregate top views: %v", err)
+			return nil, databaseError(ctx)
+		}
+		if res.Blogs, err = s.publishedViews(ctx, top, limit); err != nil {
+			log.Printf("Could not find BlogItem: %v", err)
+			return nil, databaseError(ctx)
+		}
+		if len(res.Blogs) == limit || len(top) < fetch {
+			return res, nil
+		}
+	}
+}
+
+// publishedViews returns the views of at most limit published blogs of top.
+func (s *Server) publishedViews(ctx context.Context, top []storage.BlogViews, limit int) ([]*pb.BlogViews, error) {
+	ids := make([]primitive.ObjectID, 0, len(top))
+	for _, b := range top {
+		ids = append(ids, b.BlogID)
+	}
+
+	items, err := s.Store.GetBlogs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[primitive.ObjectID]string, len(items))
+	for _, item := range items {
+		if item.Published() {
+			titles[item.ID] = item.Title
+		}
+	}
+
+	var blogs []*pb.BlogViews
+	for _, b := range top {
+		title, ok := titles[b.BlogID]
+		if !ok {
+			continue
+		}
+		blogs = append(blogs, &pb.BlogViews{
+			BlogId:      b.BlogID.Hex(),
+			Title:       title,
+			TotalViews:  b.Total,
+			UniqueViews: b.Unique,
+		})
+		if len(blogs) == limit {
+			break
+		}
+	}
+	return blogs, nil
+}
+
+// timeRange defaults to to being now and from being span before to.
+func timeRange(from, to *timestamppb.Timestamp, span time.Duration) (time.Time, time.Time, error) {
+	end := time.Now().UTC()
+	if to != nil {
+		if err := to.CheckValid(); err != nil {
+			return time.Time{}, time.Time{}, blogerr.InvalidArgument("to", "%v", err)
+		}
+		end = to.AsTime()
+	}
+
+	start := end.Add(-span)
+	if from != nil {
+		if err := from.CheckValid(); err != nil {
+			return time.Time{}, time.Time{}, blogerr.InvalidArgument("from", "%v", err)
+		}
+		start = from.AsTime()
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, blogerr.InvalidArgument("from", "must be before to")
+	}
+	return start, end, nil
+}
+
+// viewer identifies the reader by the viewer-id metadata, falling back to the peer address.
+func viewer(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("viewer-id"); len(v) > 0 && v[0] != "" {
+			return v[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return host
+	}
+
+	return "unknown"
+}
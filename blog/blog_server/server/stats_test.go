@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTopBlogsFillsLimitWithPublishedBlogs(t *testing.T) {
+	for backend := range testStores {
+		t.Run(backend, func(t *testing.T) {
+			s, store := newTestServerOn(t, backend)
+			ctx := tenant.NewContext(context.Background(), "t1")
+
+			// the most viewed blogs are held or deleted
+			var events []model.ViewEvent
+			view := func(id string, n int) {
+				oid, _ := primitive.ObjectIDFromHex(id)
+				for i := 0; i < n; i++ {
+					events = append(events, model.ViewEvent{ID: primitive.NewObjectID().Hex(), TenantID: "t1", BlogID: oid, Viewer: fmt.Sprint("viewer", i), Time: time.Now().Add(-time.Minute)})
+				}
+			}
+			for i := 0; i < 4; i++ {
+				held := createBlog(t, s, ctx, "held")
+				hold(t, store, ctx, held.GetId())
+				view(held.GetId(), 20+i)
+			}
+			deleted := createBlog(t, s, ctx, "deleted")
+			view(deleted.GetId(), 30)
+			if _, err := s.DeleteBlog(ctx, &pb.DeleteBlogRequest{BlogId: deleted.GetId()}); err != nil {
+				t.Fatalf("DeleteBlog: %v", err)
+			}
+			for i, title := range []string{"third", "second", "first"} {
+				view(createBlog(t, s, ctx, title).GetId(), 10+i)
+			}
+			if err := store.InsertViews(ctx, events); err != nil {
+				t.Fatalf("InsertViews: %v", err)
+			}
+
+			for _, c := range []struct {
+				limit int32
+				want  string
+			}{
+				{2, "first second"},
+				{3, "first second third"},
+				{10, "first second third"},
+			} {
+				res, err := s.TopBlogs(ctx, &pb.TopBlogsRequest{Limit: c.limit})
+				if err != nil {
+					t.Fatalf("TopBlogs: %v", err)
+				}
+				var titles []string
+				for _, b := range res.GetBlogs() {
+					titles = append(titles, b.GetTitle())
+				}
+				if got := strings.Join(titles, " "); got != c.want {
+					t.Errorf("TopBlogs of %d returned %q, want %q", c.limit, got, c.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestTenantsAreIsolated(t *testing.T) {
+	for backend := range testStores {
+		t.Run(backend, func(t *testing.T) {
+			s, _ := newTestServerOn(t, backend)
+			ctx1 := tenant.NewContext(context.Background(), "t1")
+			ctx2 := tenant.NewContext(context.Background(), "t2")
+
+			blog := createBlog(t, s, ctx1, "of t1")
+			series, err := s.CreateSeries(ctx1, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "series", BlogIds: []string{blog.GetId()}}})
+			if err != nil {
+				t.Fatalf("CreateSeries: %v", err)
+			}
+			webhook, err := s.CreateWebhook(ctx1, &pb.CreateWebhookRequest{Webhook: &pb.Webhook{Url: "https://93.184.216.34/hook"}})
+			if err != nil {
+				t.Fatalf("CreateWebhook: %v", err)
+			}
+			sid, wid := series.GetSeries().GetId(), webhook.GetWebhook().GetId()
+
+			notFound := func(method string, err error) {
+				t.Helper()
+				if status.Code(err) != codes.NotFound {
+					t.Errorf("%s of t2 returned %v, want NotFound", method, err)
+				}
+			}
+			_, err = s.ReadBlog(ctx2, &pb.ReadBlogRequest{BlogId: blog.GetId()})
+			notFound("ReadBlog", err)
+			_, err = s.UpdateBlog(ctx2, &pb.UpdateBlogRequest{Blog: &pb.Blog{Id: blog.GetId(), AuthorId: "t2", Title: "taken", Content: "taken"}})
+			notFound("UpdateBlog", err)
+			_, err = s.DeleteBlog(ctx2, &pb.DeleteBlogRequest{BlogId: blog.GetId()})
+			notFound("DeleteBlog", err)
+			_, err = s.ReadSeries(ctx2, &pb.ReadSeriesRequest{SeriesId: sid})
+			notFound("ReadSeries", err)
+			_, err = s.CreateSeries(ctx2, &pb.CreateSeriesRequest{Series: &pb.Series{Title: "series", BlogIds: []string{blog.GetId()}}})
+			notFound("CreateSeries with a blog of t1", err)
+			own := createBlog(t, s, ctx2, "of t2")
+			_, err = s.AddBlogToSeries(ctx2, &pb.AddBlogToSeriesRequest{SeriesId: sid, BlogId: own.GetId()})
+			notFound("AddBlogToSeries", err)
+			_, err = s.DeleteSeries(ctx2, &pb.DeleteSeriesRequest{SeriesId: sid})
+			notFound("DeleteSeries", err)
+			_, err = s.GetWebhook(ctx2, &pb.GetWebhookRequest{WebhookId: wid})
+			notFound("GetWebhook", err)
+			_, err = s.DeleteWebhook(ctx2, &pb.DeleteWebhookRequest{WebhookId: wid})
+			notFound("DeleteWebhook", err)
+
+			webhooks, err := s.ListWebhooks(ctx2, &pb.ListWebhooksRequest{})
+			if err != nil || len(webhooks.GetWebhooks()) != 0 {
+				t.Errorf("ListWebhooks of t2 returned %v, %v, want no webhooks", webhooks.GetWebhooks(), err)
+			}
+			stream := &listStream{ctx: ctx2}
+			if err := s.ListBlog(&pb.ListBlogRequest{}, stream); err != nil {
+				t.Fatalf("ListBlog: %v", err)
+			}
+			if len(stream.blogs) != 1 || stream.blogs[0].GetId() != own.GetId() {
+				t.Errorf("ListBlog of t2 sent %v, want only its own blog", stream.blogs)
+			}
+
+			// nothing of t1 changed
+			got, err := s.ReadBlog(ctx1, &pb.ReadBlogRequest{BlogId: blog.GetId()})
+			if err != nil || got.GetBlog().GetTitle() != "of t1" {
+				t.Errorf("ReadBlog of t1 returned %v, %v", got.GetBlog(), err)
+			}
+			read, err := s.ReadSeries(ctx1, &pb.ReadSeriesRequest{SeriesId: sid})
+			if err != nil || len(read.GetSeries().GetBlogIds()) != 1 {
+				t.Errorf("ReadSeries of t1 returned %v, %v", read.GetSeries(), err)
+			}
+			if _, err := s.GetWebhook(ctx1, &pb.GetWebhookRequest{WebhookId: wid}); err != nil {
+				t.Errorf("GetWebhook of t1: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"sort"
+	"time"
+)
+
+// DefaultLanguage is used when the server has no fallback chain configured.
+var DefaultLanguage = language.English
+
+func (s *Server) PutTranslation(ctx context.Context, r *pb.PutTranslationRequest) (*pb.PutTranslationResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetBlogId())
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+
+	t := r.GetTranslation()
+	if t == nil {
+		return nil, blogerr.MissingField("translation")
+	}
+	lang, err := normalizeLanguage("translation.language", t.GetLanguage())
+	if err != nil {
+		return nil, err
+	}
+
+	item := model.TranslationItem{
+		Title:   t.GetTitle(),
+		Content: t.GetContent(),
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	// the flood stage counts new translations of the author of the blog
+	current, err := s.Store.GetBlog(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogNotFound(r.GetBlogId())
+		}
+		log.Printf("Could not find BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	_, exists := current.Translations[lang]
+	flags := s.moderate(ctx, current.AuthorId, now, !exists, item.Title, item.Content)
+
+	update := func(data *model.BlogItem) error {
+		// the original is changed with UpdateBlog, a translation must not shadow it
+		if data.Language == lang {
+			msg := fmt.Sprintf("%s is the original language of the blog, use UpdateBlog instead", lang)
+			return blogerr.FailedPrecondition(blogerr.ReasonOriginalLanguage, msg,
+				blogerr.PreconditionViolation(blogerr.ReasonOriginalLanguage, r.GetBlogId(), msg))
+		}
+		if data.Translations == nil {
+			data.Translations = make(map[string]model.TranslationItem)
+		}
+		data.Translations[lang] = item
+		data.UpdatedAt = now
+		s.applyModeration(ctx, data, flags, now)
+		return nil
+	}
+
+	var before, after *model.BlogItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		before, after, err = s.Store.UpdateBlog(ctx, oid, 0, update)
+		if err != nil {
+			return err
+		}
+		if err := s.addBlogEvent(ctx, before, after); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, BlogID: r.GetBlogId(), Before: before, After: after})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogNotFound(r.GetBlogId())
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not put translation of BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.PutTranslationResponse{BlogId: r.GetBlogId(), Translation: &pb.Translation{
+		Language: lang,
+		Title:    t.GetTitle(),
+		Content:  t.GetContent(),
+	}}, nil
+}
+
+func (s *Server) DeleteTranslation(ctx context.Context, r *pb.DeleteTranslationRequest) (*pb.DeleteTranslationResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetBlogId())
+	if err != nil {
+		return nil, blogerr.InvalidID("blog_id", r.GetBlogId())
+	}
+
+	lang, err := normalizeLanguage("language", r.GetLanguage())
+	if err != nil {
+		return nil, err
+	}
+
+	notFound := blogerr.NotFound(blogerr.ReasonTranslationNotFound, blogerr.ResourceTranslation, r.GetBlogId()+"/"+lang,
+		fmt.Sprintf("blog with specified id could not be found or has no %s translation", lang))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	update := func(data *model.BlogItem) error {
+		if _, ok := data.Translations[lang]; !ok {
+			return notFound
+		}
+		delete(data.Translations, lang)
+		data.UpdatedAt = now
+		return nil
+	}
+
+	var before, after *model.BlogItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		before, after, err = s.Store.UpdateBlog(ctx, oid, 0, update)
+		if err != nil {
+			return err
+		}
+		if err := s.addBlogEvent(ctx, before, after); err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, BlogID: r.GetBlogId(), Before: before, After: after})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, notFound
+		}
+		if isStatus(err) {
+			return nil, err
+		}
+		log.Printf("Could not delete translation of BlogItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.DeleteTranslationResponse{BlogId: r.GetBlogId(), Language: lang}, nil
+}
+
+func (s *Server) defaultLanguage() string {
+	if len(s.Languages) == 0 {
+		return DefaultLanguage.String()
+	}
+	return s.Languages[0].String()
+}
+
+// localize picks the title and content in the best available language, trying
+// the preferred languages first, then the server's fallback chain and finally
+// the original language of the blog.
+func (s *Server) localize(data *model.BlogItem, prefs []language.Tag) *pb.Blog {
+	original := data.Language
+	if original == "" {
+		original = s.defaultLanguage()
+	}
+
+	available := []string{original}
+	translated := make([]string, 0, len(data.Translations))
+	for lang := range data.Translations {
+		translated = append(translated, lang)
+	}
+	sort.Strings(translated)
+	available = append(available, translated...)
+
+	blog := &pb.Blog{
+		Id:                 data.ID.Hex(),
+		AuthorId:           data.AuthorId,
+		Title:              data.Title,
+		Content:            data.Content,
+		Slug:               data.Slug,
+		Version:            data.Version,
+		Language:           original,
+		AvailableLanguages: available,
+		Tags:               data.Tags,
+		CreateTime:         timestamppb.New(data.CreateTime()),
+		UpdateTime:         timestamppb.New(data.UpdateTime()),
+		Moderation:         moderationToPb(data),
+	}
+
+	candidates := make([]language.Tag, 0, len(prefs)+len(s.Languages))
+	candidates = append(candidates, prefs...)
+	candidates = append(candidates, s.Languages...)
+
+	lang := matchLanguage(available, candidates)
+	if t, ok := data.Translations[lang]; ok {
+		blog.Title = t.Title
+		blog.Content = t.Content
+		blog.Language = lang
+	}
+
+	return blog
+}
+
+// matchLanguage returns the first available language matching one of the
+// candidates, or the first available language when none matches.
+func matchLanguage(available []string, candidates []language.Tag) string {
+	tags := make([]language.Tag, 0, len(available))
+	for _, lang := range available {
+		tags = append(tags, language.Make(lang))
+	}
+
+	m := language.NewMatcher(tags)
+	for _, c := range candidates {
+		_, i, conf := m.Match(c)
+		if conf >= language.High {
+			return available[i]
+		}
+	}
+
+	return available[0]
+}
+
+// preferredLanguages parses the languages sent in the request, falling back to
+// the accept-language metadata when there are none.
+func preferredLanguages(ctx context.Context, requested []string) ([]language.Tag, error) {
+	if len(requested) > 0 {
+		prefs := make([]language.Tag, 0, len(requested))
+		for i, lang := range requested {
+			tag, err := language.Parse(lang)
+			if err != nil {
+				return nil, blogerr.InvalidArgument(fmt.Sprintf("languages[%d]", i), "%q is not a valid BCP-47 language tag", lang)
+			}
+			prefs = append(prefs, tag)
+		}
+		return prefs, nil
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	var prefs []language.Tag
+	for _, v := range md.Get("accept-language") {
+		tags, _, err := language.ParseAcceptLanguage(v)
+		if err != nil {
+			log.Printf("Ignoring invalid accept-language %q: %v", v, err)
+			continue
+		}
+		prefs = append(prefs, tags...)
+	}
+	return prefs, nil
+}
+
+// normalizeLanguage reports an invalid tag as a violation of field.
+func normalizeLanguage(field, lang string) (string, error) {
+	tag, err := language.Parse(lang)
+	if err != nil || tag == language.Und {
+		return "", blogerr.InvalidArgument(field, "%q is not a valid BCP-47 language tag", lang)
+	}
+	return tag.String(), nil
+}
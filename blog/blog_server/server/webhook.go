@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/audit"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/outbox"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/storage"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"github.com/dbielecki97/grpc-go-course/blog/blogerr"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"net/url"
+	"time"
+)
+
+func (s *Server) CreateWebhook(ctx context.Context, r *pb.CreateWebhookRequest) (*pb.CreateWebhookResponse, error) {
+	w := r.GetWebhook()
+	events, err := checkWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Could not generate webhook secret: %v", err)
+		return nil, blogerr.New(codes.Internal, blogerr.ReasonInternal, "cannot generate webhook secret")
+	}
+
+	data := model.WebhookItem{
+		ID:          primitive.NewObjectID(),
+		URL:         w.GetUrl(),
+		Events:      events,
+		Description: w.GetDescription(),
+		Secret:      "whsec_" + hex.EncodeToString(b),
+		CreatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		if err := s.Store.CreateWebhook(ctx, &data); err != nil {
+			return err
+		}
+		data.TenantID, _ = tenant.FromContext(ctx)
+		return s.Audit.Record(ctx, audit.Entry{TenantID: data.TenantID, After: &data})
+	})
+	if err != nil {
+		log.Printf("Could not insert WebhookItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.CreateWebhookResponse{Webhook: webhookToPb(&data), Secret: data.Secret}, nil
+}
+
+func (s *Server) GetWebhook(ctx context.Context, r *pb.GetWebhookRequest) (*pb.GetWebhookResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetWebhookId())
+	if err != nil {
+		return nil, blogerr.InvalidID("webhook_id", r.GetWebhookId())
+	}
+
+	data, err := s.Store.GetWebhook(ctx, oid)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, webhookNotFound(r.GetWebhookId())
+		}
+		log.Printf("Could not find WebhookItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+	return &pb.GetWebhookResponse{Webhook: webhookToPb(data)}, nil
+}
+
+func (s *Server) ListWebhooks(ctx context.Context, _ *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	items, err := s.Store.ListWebhooks(ctx)
+	if err != nil {
+		log.Printf("Could not list WebhookItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	res := &pb.ListWebhooksResponse{}
+	for i := range items {
+		res.Webhooks = append(res.Webhooks, webhookToPb(&items[i]))
+	}
+	return res, nil
+}
+
+func (s *Server) UpdateWebhook(ctx context.Context, r *pb.UpdateWebhookRequest) (*pb.UpdateWebhookResponse, error) {
+	w := r.GetWebhook()
+	oid, err := primitive.ObjectIDFromHex(w.GetId())
+	if err != nil {
+		return nil, blogerr.InvalidID("webhook.id", w.GetId())
+	}
+	events, err := checkWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *model.WebhookItem
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		before, after, err := s.Store.UpdateWebhook(ctx, oid, func(data *model.WebhookItem) error {
+			data.URL = w.GetUrl()
+			data.Events = events
+			data.Description = w.GetDescription()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		data = after
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, Before: before, After: after})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, webhookNotFound(w.GetId())
+		}
+		log.Printf("Could not update WebhookItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.UpdateWebhookResponse{Webhook: webhookToPb(data)}, nil
+}
+
+// DeleteWebhook keeps the deliveries, pending ones fail on their next attempt.
+func (s *Server) DeleteWebhook(ctx context.Context, r *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetWebhookId())
+	if err != nil {
+		return nil, blogerr.InvalidID("webhook_id", r.GetWebhookId())
+	}
+
+	err = s.Store.Tx(ctx, func(ctx context.Context) error {
+		before, err := s.Store.DeleteWebhook(ctx, oid)
+		if err != nil {
+			return err
+		}
+		return s.Audit.Record(ctx, audit.Entry{TenantID: before.TenantID, Before: before})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, webhookNotFound(r.GetWebhookId())
+		}
+		log.Printf("Could not delete WebhookItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.DeleteWebhookResponse{WebhookId: r.GetWebhookId()}, nil
+}
+
+func (s *Server) ListDeliveries(ctx context.Context, r *pb.ListDeliveriesRequest) (*pb.ListDeliveriesResponse, error) {
+	q := storage.DeliveryQuery{Limit: int(r.GetLimit())}
+	if r.GetWebhookId() != "" {
+		oid, err := primitive.ObjectIDFromHex(r.GetWebhookId())
+		if err != nil {
+			return nil, blogerr.InvalidID("webhook_id", r.GetWebhookId())
+		}
+		q.WebhookID = oid
+	}
+	if r.GetState() != pb.Delivery_STATE_UNSPECIFIED {
+		q.State = deliveryStates[r.GetState()]
+	}
+	if q.Limit == 0 {
+		q.Limit = 50
+	}
+
+	items, err := s.Store.ListDeliveries(ctx, q)
+	if err != nil {
+		log.Printf("Could not list DeliveryItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	res := &pb.ListDeliveriesResponse{}
+	for i := range items {
+		res.Deliveries = append(res.Deliveries, deliveryToPb(&items[i]))
+	}
+	return res, nil
+}
+
+func (s *Server) Redeliver(ctx context.Context, r *pb.RedeliverRequest) (*pb.RedeliverResponse, error) {
+	oid, err := primitive.ObjectIDFromHex(r.GetDeliveryId())
+	if err != nil {
+		return nil, blogerr.InvalidID("delivery_id", r.GetDeliveryId())
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	data, err := s.Store.UpdateDelivery(ctx, oid, func(d *model.DeliveryItem) error {
+		d.State = model.DeliveryPending
+		d.Attempts = 0
+		d.NextAttemptAt = now
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, blogerr.NotFound(blogerr.ReasonDeliveryNotFound, blogerr.ResourceDelivery, r.GetDeliveryId(), "delivery with specified id could not be found")
+		}
+		log.Printf("Could not update DeliveryItem: %v", err)
+		return nil, databaseError(ctx)
+	}
+
+	return &pb.RedeliverResponse{Delivery: deliveryToPb(data)}, nil
+}
+
+// checkWebhook returns the deduplicated event types of w.
+func checkWebhook(ctx context.Context, w *pb.Webhook) ([]string, error) {
+	u, err := url.Parse(w.GetUrl())
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, blogerr.InvalidArgument("webhook.url", "must be an absolute http or https URL")
+	}
+	if err := outbox.CheckHost(ctx, u.Hostname()); err != nil {
+		return nil, blogerr.InvalidArgument("webhook.url", "must not point to a loopback, link-local or private address")
+	}
+
+	known := make(map[string]bool, len(outbox.EventTypes))
+	for _, t := range outbox.EventTypes {
+		known[t] = true
+	}
+	seen := make(map[string]bool)
+	events := []string{}
+	for i, e := range w.GetEvents() {
+		if !known[e] {
+			return nil, blogerr.InvalidArgument(fmt.Sprintf("webhook.events[%d]", i), "%q is not one of %v", e, outbox.EventTypes)
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+var deliveryStates = map[pb.Delivery_State]string{
+	pb.Delivery_PENDING:   model.DeliveryPending,
+	pb.Delivery_DELIVERED: model.DeliveryDelivered,
+	pb.Delivery_DEAD:      model.DeliveryDead,
+}
+
+func webhookToPb(data *model.WebhookItem) *pb.Webhook {
+	return &pb.Webhook{
+		Id:          data.ID.Hex(),
+		Url:         data.URL,
+		Events:      data.Events,
+		Description: data.Description,
+		CreateTime:  timestamppb.New(data.CreatedAt),
+	}
+}
+
+func deliveryToPb(data *model.DeliveryItem) *pb.Delivery {
+	d := &pb.Delivery{
+		Id:              data.ID.Hex(),
+		WebhookId:       data.WebhookID.Hex(),
+		EventId:         data.EventID.Hex(),
+		EventType:       data.EventType,
+		BlogId:          data.BlogID,
+		Attempts:        data.Attempts,
+		LastError:       data.LastError,
+		NextAttemptTime: timestamppb.New(data.NextAttemptAt),
+		CreateTime:      timestamppb.New(data.CreatedAt),
+	}
+	for state, name := range deliveryStates {
+		if name == data.State {
+			d.State = state
+		}
+	}
+	return d
+}
+
+func webhookNotFound(id string) error {
+	return blogerr.NotFound(blogerr.ReasonWebhookNotFound, blogerr.ResourceWebhook, id, "webhook with specified id could not be found")
+}
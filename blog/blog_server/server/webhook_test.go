@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestCreateWebhookRejectsPrivateHosts(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := tenant.NewContext(context.Background(), "t1")
+
+	for _, u := range []string{
+		"http://127.0.0.1:50052/",
+		"http://localhost:8080/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/hook",
+		"https://10.0.0.5/hook",
+		"http://[::ffff:192.168.0.1]/hook",
+	} {
+		_, err := s.CreateWebhook(ctx, &pb.CreateWebhookRequest{Webhook: &pb.Webhook{Url: u}})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("CreateWebhook(%s) returned %v, want InvalidArgument", u, err)
+		}
+	}
+
+	res, err := s.CreateWebhook(ctx, &pb.CreateWebhookRequest{Webhook: &pb.Webhook{Url: "https://93.184.216.34/hook"}})
+	if err != nil {
+		t.Fatalf("CreateWebhook of a public address: %v", err)
+	}
+	_, err = s.UpdateWebhook(ctx, &pb.UpdateWebhookRequest{Webhook: &pb.Webhook{Id: res.GetWebhook().GetId(), Url: "http://127.0.0.1/"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateWebhook to a loopback address returned %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"io"
+	"time"
+)
+
+// BoltStore keeps the blog data in a single bbolt file, so the server needs no
+// database process. Values are BSON, keys of tenant data start with the
+// tenant id and a zero byte. Every write transaction is synced to disk before
+// it returns, a crash never leaves a partly written transaction behind.
+//
+// Blogs are keyed by their ObjectID, which starts with the creation time, so
+// the blogs bucket is also the index by time. Authors and tags have index
+// buckets of their own.
+type BoltStore struct {
+	DB *bolt.DB
+}
+
+var (
+	bucketTenants              = []byte("tenants")
+	bucketBlogs                = []byte("blogs")
+	bucketBlogsByAuthor        = []byte("blogs_by_author")
+	bucketBlogsByTag           = []byte("blogs_by_tag")
+	bucketSeries               = []byte("series")
+	bucketSeriesByBlog         = []byte("series_by_blog")
+	bucketAuditLog             = []byte("audit_log")
+	bucketAuditByTime          = []byte("audit_log_by_time")
+	bucketOutbox               = []byte("outbox")
+	bucketOutboxPending        = []byte("outbox_pending")
+	bucketOutboxDispatched     = []byte("outbox_dispatched")
+	bucketWebhooks             = []byte("webhooks")
+	bucketDeliveries           = []byte("webhook_deliveries")
+	bucketDeliveriesByEvent    = []byte("webhook_deliveries_by_event")
+	bucketDeliveriesByTenant   = []byte("webhook_deliveries_by_tenant")
+	bucketDeliveriesDue        = []byte("webhook_deliveries_due")
+	bucketViews                = []byte("views")
+	bucketViewsByBlog          = []byte("views_by_blog")
+	bucketViewsByTime          = []byte("views_by_time")
+	bucketReadingLists         = []byte("reading_lists")
+	bucketReadingListsByReader = []byte("reading_lists_by_reader")
+)
+
+var buckets = [][]byte{
+	bucketTenants, bucketBlogs, bucketBlogsByAuthor, bucketBlogsByTag, bucketSeries, bucketSeriesByBlog,
+	bucketAuditLog, bucketAuditByTime, bucketOutbox, bucketOutboxPending, bucketOutboxDispatched,
+	bucketWebhooks, bucketDeliveries, bucketDeliveriesByEvent, bucketDeliveriesByTenant, bucketDeliveriesDue,
+	bucketViews, bucketViewsByBlog, bucketViewsByTime, bucketReadingLists, bucketReadingListsByReader,
+}
+
+// OpenBolt opens or creates the database file at path. It fails when another
+// process has the file open for longer than a second.
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("could not create bucket %s: %v", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{DB: db}, nil
+}
+
+func (s *BoltStore) Close(ctx context.Context) error {
+	return s.DB.Close()
+}
+
+type boltTxKey struct{}
+
+// Tx runs fn in the transaction of ctx when there is one already. bbolt has
+// a single writer, so fn should not wait for anything but the store.
+func (s *BoltStore) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(boltTxKey{}).(*bolt.Tx); ok {
+		return fn(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		return fn(context.WithValue(ctx, boltTxKey{}, tx))
+	})
+}
+
+// update runs fn in the write transaction of ctx or a new one.
+func (s *BoltStore) update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	return s.Tx(ctx, func(ctx context.Context) error {
+		return fn(ctx.Value(boltTxKey{}).(*bolt.Tx))
+	})
+}
+
+// view runs fn in the write transaction of ctx or a new read-only one.
+func (s *BoltStore) view(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if tx, ok := ctx.Value(boltTxKey{}).(*bolt.Tx); ok {
+		return fn(tx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.DB.View(fn)
+}
+
+// Snapshot writes a consistent copy of the database file to w, writes go on
+// while it runs.
+func (s *BoltStore) Snapshot(ctx context.Context, w io.Writer) (int64, error) {
+	var n int64
+	err := s.DB.View(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(&ctxWriter{ctx: ctx, w: w})
+		return err
+	})
+	return n, err
+}
+
+// ctxWriter stops a copy once ctx is done.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *ctxWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// key joins parts with zero bytes. Fixed size parts like ObjectIDs and
+// times may come last without a separator, use append for them.
+func key(parts ...string) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+		b = append(b, 0)
+	}
+	return b
+}
+
+// timeKey sorts like the time it encodes.
+func timeKey(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(millis(t)))
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func putValue(b *bolt.Bucket, k []byte, v interface{}) error {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, data)
+}
+
+func getValue(b *bolt.Bucket, k []byte, v interface{}) error {
+	data := b.Get(k)
+	if data == nil {
+		return ErrNotFound
+	}
+	return bson.Unmarshal(data, v)
+}
+
+// deletePrefix deletes every key of b starting with prefix.
+func deletePrefix(b *bolt.Bucket, prefix []byte) error {
+	var keys [][]byte
+	c := prefixCursor{c: b.Cursor(), prefix: prefix}
+	for k, _ := c.seek(nil); k != nil; k, _ = c.next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixCursor walks the keys starting with prefix, backwards when desc is set.
+type prefixCursor struct {
+	c      *bolt.Cursor
+	prefix []byte
+	desc   bool
+}
+
+// seek moves to the first key after after, or to the first key when after is nil.
+func (p prefixCursor) seek(after []byte) ([]byte, []byte) {
+	var k, v []byte
+	switch {
+	case p.desc:
+		from := after
+		if from == nil {
+			from = prefixEnd(p.prefix)
+		}
+		if from == nil {
+			k, v = p.c.Last()
+		} else if k, _ = p.c.Seek(from); k == nil {
+			k, v = p.c.Last()
+		} else {
+			k, v = p.c.Prev()
+		}
+	case after == nil:
+		k, v = p.c.Seek(p.prefix)
+	default:
+		if k, v = p.c.Seek(after); bytes.Equal(k, after) {
+			k, v = p.c.Next()
+		}
+	}
+	return p.check(k, v)
+}
+
+// seekTo moves to the first key not before k, which starts with the prefix.
+func (p prefixCursor) seekTo(k []byte) ([]byte, []byte) {
+	return p.check(p.c.Seek(k))
+}
+
+func (p prefixCursor) next() ([]byte, []byte) {
+	if p.desc {
+		return p.check(p.c.Prev())
+	}
+	return p.check(p.c.Next())
+}
+
+func (p prefixCursor) check(k, v []byte) ([]byte, []byte) {
+	if k == nil || !bytes.HasPrefix(k, p.prefix) {
+		return nil, nil
+	}
+	return k, v
+}
+
+// prefixEnd returns the smallest key greater than every key starting with
+// prefix, or nil when there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
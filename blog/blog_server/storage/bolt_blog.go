@@ -0,0 +1,490 @@
+package storage
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"unicode/utf8"
+)
+
+func (s *BoltStore) CreateBlog(ctx context.Context, b *model.BlogItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketBlogs).Get(concat(key(t), b.ID[:])) != nil {
+			return ErrExists
+		}
+		stored := *b
+		stored.TenantID = t
+		return putBlog(tx, &stored)
+	})
+}
+
+// putBlog stores b and adds it to the indexes.
+func putBlog(tx *bolt.Tx, b *model.BlogItem) error {
+	if err := putValue(tx.Bucket(bucketBlogs), concat(key(b.TenantID), b.ID[:]), b); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketBlogsByAuthor).Put(concat(key(b.TenantID, b.AuthorId), b.ID[:]), nil); err != nil {
+		return err
+	}
+	for _, tag := range b.Tags {
+		if err := tx.Bucket(bucketBlogsByTag).Put(concat(key(b.TenantID, tag), b.ID[:]), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBlog removes b and its index entries.
+func deleteBlog(tx *bolt.Tx, b *model.BlogItem) error {
+	if err := tx.Bucket(bucketBlogs).Delete(concat(key(b.TenantID), b.ID[:])); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketBlogsByAuthor).Delete(concat(key(b.TenantID, b.AuthorId), b.ID[:])); err != nil {
+		return err
+	}
+	for _, tag := range b.Tags {
+		if err := tx.Bucket(bucketBlogsByTag).Delete(concat(key(b.TenantID, tag), b.ID[:])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getBlog(tx *bolt.Tx, tenantID string, id primitive.ObjectID) (*model.BlogItem, error) {
+	var b model.BlogItem
+	if err := getValue(tx.Bucket(bucketBlogs), concat(key(tenantID), id[:]), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *BoltStore) GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var b *model.BlogItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		b, err = getBlog(tx, t, id)
+		return err
+	})
+	return b, err
+}
+
+func (s *BoltStore) GetBlogs(ctx context.Context, ids []primitive.ObjectID) ([]model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.BlogItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			b, err := getBlog(tx, t, id)
+			if err == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			items = append(items, *b)
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (s *BoltStore) UpdateBlog(ctx context.Context, id primitive.ObjectID, version int64, fn func(b *model.BlogItem) error) (*model.BlogItem, *model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, after *model.BlogItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getBlog(tx, t, id); err != nil {
+			return err
+		}
+		if version != 0 && before.Version != version {
+			return ErrVersionConflict
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = t
+		after.Version = before.Version + 1
+
+		if err := deleteBlog(tx, before); err != nil {
+			return err
+		}
+		return putBlog(tx, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *BoltStore) DeleteBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *model.BlogItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getBlog(tx, t, id); err != nil {
+			return err
+		}
+		if err := deleteBlog(tx, before); err != nil {
+			return err
+		}
+
+		series, err := seriesOfBlog(tx, t, id)
+		if err == ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		changed := series.Clone()
+		changed.BlogIds = changed.BlogIds[:0]
+		for _, blogID := range series.BlogIds {
+			if blogID != id {
+				changed.BlogIds = append(changed.BlogIds, blogID)
+			}
+		}
+		return replaceSeries(tx, series, changed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+func (s *BoltStore) CountBlogs(ctx context.Context) (int64, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		c := prefixCursor{c: tx.Bucket(bucketBlogs).Cursor(), prefix: key(t)}
+		for k, _ := c.seek(nil); k != nil; k, _ = c.next() {
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// ListBlogs walks the tag or author index when the query has one, otherwise
+// the blogs themselves. It reads in pages, a read transaction is not held
+// while the caller handles the blogs.
+func (s *BoltStore) ListBlogs(ctx context.Context, q BlogQuery, fn func(b *model.BlogItem) error) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	bucket, prefix := bucketBlogs, key(t)
+	switch {
+	case q.Tag != "":
+		bucket, prefix = bucketBlogsByTag, key(t, q.Tag)
+	case q.AuthorID != "":
+		bucket, prefix = bucketBlogsByAuthor, key(t, q.AuthorID)
+	}
+
+	words := textWords(q.Text)
+	page := listPage
+	if q.BatchSize > 0 {
+		page = q.BatchSize
+	}
+	var last []byte
+	listed := 0
+	for {
+		var items []model.BlogItem
+		done := false
+		err := s.view(ctx, func(tx *bolt.Tx) error {
+			blogs := tx.Bucket(bucketBlogs)
+			c := prefixCursor{c: tx.Bucket(bucket).Cursor(), prefix: prefix, desc: q.Newest}
+			k, _ := c.seek(last)
+			for ; k != nil && len(items) < page; k, _ = c.next() {
+				last = append(last[:0], k...)
+				var b model.BlogItem
+				if err := getValue(blogs, concat(key(t), k[len(k)-len(b.ID):]), &b); err != nil {
+					return err
+				}
+				if (q.AuthorID != "" && b.AuthorId != q.AuthorID) || b.ModerationState != q.Moderation || !containsWord(&b, words) {
+					continue
+				}
+				items = append(items, b)
+			}
+			done = k == nil
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range items {
+			if q.Limit > 0 && listed == q.Limit {
+				return nil
+			}
+			if err := fn(&items[i]); err != nil {
+				return err
+			}
+			listed++
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// containsWord reports whether the title or content of b holds one of words,
+// which is true without words.
+func containsWord(b *model.BlogItem, words []string) bool {
+	if len(words) == 0 {
+		return true
+	}
+	for _, w := range append(textWords(b.Title), textWords(b.Content)...) {
+		for _, want := range words {
+			if w == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *BoltStore) CreateSeries(ctx context.Context, series *model.SeriesItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketSeries).Get(concat(key(t), series.ID[:])) != nil {
+			return ErrExists
+		}
+		stored := series.Clone()
+		stored.TenantID = t
+		return replaceSeries(tx, nil, stored)
+	})
+}
+
+// replaceSeries stores after in place of before, which is nil for a new
+// series. A blog of after in another series is ErrExists.
+func replaceSeries(tx *bolt.Tx, before, after *model.SeriesItem) error {
+	byBlog := tx.Bucket(bucketSeriesByBlog)
+	if before != nil {
+		for _, id := range before.BlogIds {
+			if err := byBlog.Delete(concat(key(before.TenantID), id[:])); err != nil {
+				return err
+			}
+		}
+	}
+	for _, id := range after.BlogIds {
+		k := concat(key(after.TenantID), id[:])
+		if byBlog.Get(k) != nil {
+			return ErrExists
+		}
+		if err := byBlog.Put(k, after.ID[:]); err != nil {
+			return err
+		}
+	}
+	return putValue(tx.Bucket(bucketSeries), concat(key(after.TenantID), after.ID[:]), after)
+}
+
+func getSeries(tx *bolt.Tx, tenantID string, id primitive.ObjectID) (*model.SeriesItem, error) {
+	var series model.SeriesItem
+	if err := getValue(tx.Bucket(bucketSeries), concat(key(tenantID), id[:]), &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func seriesOfBlog(tx *bolt.Tx, tenantID string, blogID primitive.ObjectID) (*model.SeriesItem, error) {
+	v := tx.Bucket(bucketSeriesByBlog).Get(concat(key(tenantID), blogID[:]))
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	var id primitive.ObjectID
+	copy(id[:], v)
+	return getSeries(tx, tenantID, id)
+}
+
+func (s *BoltStore) GetSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var series *model.SeriesItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		series, err = getSeries(tx, t, id)
+		return err
+	})
+	return series, err
+}
+
+func (s *BoltStore) SeriesOfBlog(ctx context.Context, blogID primitive.ObjectID) (*model.SeriesItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var series *model.SeriesItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		series, err = seriesOfBlog(tx, t, blogID)
+		return err
+	})
+	return series, err
+}
+
+func (s *BoltStore) UpdateSeries(ctx context.Context, id primitive.ObjectID, fn func(s *model.SeriesItem) error) (*model.SeriesItem, *model.SeriesItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, after *model.SeriesItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getSeries(tx, t, id); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = t
+		return replaceSeries(tx, before, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *BoltStore) DeleteSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *model.SeriesItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getSeries(tx, t, id); err != nil {
+			return err
+		}
+		for _, blogID := range before.BlogIds {
+			if err := tx.Bucket(bucketSeriesByBlog).Delete(concat(key(t), blogID[:])); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketSeries).Delete(concat(key(t), id[:]))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// BlogStats reads every matching blog in one read transaction, writers are not blocked meanwhile.
+func (s *BoltStore) BlogStats(ctx context.Context, q StatsQuery) (*BlogStats, error) {
+	var prefix []byte
+	if q.TenantID != "" {
+		prefix = key(q.TenantID)
+	}
+
+	stats := &BlogStats{}
+	authors := map[AuthorPosts]int64{}
+	tags := map[string]int64{}
+	months := map[string]int64{}
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		c := prefixCursor{c: tx.Bucket(bucketBlogs).Cursor(), prefix: prefix}
+		for k, v := c.seek(nil); k != nil; k, v = c.next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var b model.BlogItem
+			if err := bson.Unmarshal(v, &b); err != nil {
+				return err
+			}
+			created := b.CreateTime().UTC()
+			if !b.Published() || (!q.From.IsZero() && created.Before(q.From)) || (!q.To.IsZero() && !created.Before(q.To)) {
+				continue
+			}
+
+			stats.Posts++
+			stats.ContentLength += int64(utf8.RuneCountInString(b.Content))
+			authors[AuthorPosts{TenantID: b.TenantID, AuthorID: b.AuthorId}]++
+			for _, tag := range b.Tags {
+				tags[tag]++
+			}
+			months[created.Format("2006-01")]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for a, n := range authors {
+		a.Posts = n
+		stats.Authors = append(stats.Authors, a)
+	}
+	sort.Slice(stats.Authors, func(i, j int) bool {
+		a, b := stats.Authors[i], stats.Authors[j]
+		if a.Posts != b.Posts {
+			return a.Posts > b.Posts
+		}
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		return a.AuthorID < b.AuthorID
+	})
+	if len(stats.Authors) > StatsRows {
+		stats.Authors = stats.Authors[:StatsRows]
+	}
+
+	for tag, n := range tags {
+		stats.Tags = append(stats.Tags, TagPosts{Tag: tag, Posts: n})
+	}
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		if stats.Tags[i].Posts != stats.Tags[j].Posts {
+			return stats.Tags[i].Posts > stats.Tags[j].Posts
+		}
+		return stats.Tags[i].Tag < stats.Tags[j].Tag
+	})
+	if len(stats.Tags) > StatsRows {
+		stats.Tags = stats.Tags[:StatsRows]
+	}
+
+	for month, n := range months {
+		stats.Months = append(stats.Months, MonthPosts{Month: month, Posts: n})
+	}
+	sort.Slice(stats.Months, func(i, j int) bool { return stats.Months[i].Month < stats.Months[j].Month })
+	return stats, nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// tenantBuckets are checked by Orphans, under the names of the other backends.
+var tenantBuckets = []struct {
+	name   string
+	bucket []byte
+}{
+	{"blogs", bucketBlogs},
+	{"series", bucketSeries},
+	{"reading_lists", bucketReadingLists},
+	{"webhooks", bucketWebhooks},
+}
+
+// Orphans checks everything in one read transaction, DeleteTenant and
+// DeleteBlog are transactions, so it finds nothing unless the file was
+// changed by hand.
+func (s *BoltStore) Orphans(ctx context.Context, fn func(o *Orphan) error) error {
+	return s.view(ctx, func(tx *bolt.Tx) error {
+		tenants := tx.Bucket(bucketTenants)
+		for _, tb := range tenantBuckets {
+			c := tx.Bucket(tb.bucket).Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				t, id := splitTenantKey(k)
+				if tenants.Get([]byte(t)) != nil {
+					continue
+				}
+				if err := fn(&Orphan{Kind: OrphanTenant, Collection: tb.name, TenantID: t, ID: id, MissingID: t}); err != nil {
+					return err
+				}
+			}
+		}
+
+		blogs := tx.Bucket(bucketBlogs)
+		c := tx.Bucket(bucketSeries).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var series model.SeriesItem
+			if err := bson.Unmarshal(v, &series); err != nil {
+				return err
+			}
+			for _, id := range series.BlogIds {
+				if blogs.Get(concat(key(series.TenantID), id[:])) != nil {
+					continue
+				}
+				o := &Orphan{Kind: OrphanSeriesBlog, Collection: "series", TenantID: series.TenantID, ID: series.ID.Hex(), MissingID: id.Hex()}
+				if err := fn(o); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// splitTenantKey returns the tenant and the hex ObjectID of a key(tenant)+id key.
+func splitTenantKey(k []byte) (string, string) {
+	i := bytes.IndexByte(k, 0)
+	if i < 0 {
+		return "", ""
+	}
+	var id primitive.ObjectID
+	copy(id[:], k[i+1:])
+	return string(k[:i]), id.Hex()
+}
+
+// Sizes counts the keys of every bucket, Bytes are the pages they use.
+func (s *BoltStore) Sizes(ctx context.Context) (*Sizes, error) {
+	sizes := &Sizes{}
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		sizes.TotalBytes = tx.Size()
+		for _, name := range buckets {
+			stats := tx.Bucket(name).Stats()
+			sizes.Collections = append(sizes.Collections, CollectionSize{
+				Name:  string(name),
+				Items: int64(stats.KeyN),
+				Bytes: int64(stats.BranchAlloc + stats.LeafAlloc + stats.InlineBucketInuse),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
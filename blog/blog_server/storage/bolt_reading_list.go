@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *BoltStore) CreateReadingList(ctx context.Context, l *model.ReadingListItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketReadingLists).Get(concat(key(t), l.ID[:])) != nil {
+			return ErrExists
+		}
+		stored := l.Clone()
+		stored.TenantID = t
+		return replaceReadingList(tx, nil, stored)
+	})
+}
+
+// replaceReadingList stores after in place of before, which is nil for a new list.
+func replaceReadingList(tx *bolt.Tx, before, after *model.ReadingListItem) error {
+	byReader := tx.Bucket(bucketReadingListsByReader)
+	if before != nil && before.ReaderID != after.ReaderID {
+		if err := byReader.Delete(concat(key(before.TenantID, before.ReaderID), before.ID[:])); err != nil {
+			return err
+		}
+	}
+	if err := byReader.Put(concat(key(after.TenantID, after.ReaderID), after.ID[:]), nil); err != nil {
+		return err
+	}
+	return putValue(tx.Bucket(bucketReadingLists), concat(key(after.TenantID), after.ID[:]), after)
+}
+
+func getReadingList(tx *bolt.Tx, tenantID string, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	var l model.ReadingListItem
+	if err := getValue(tx.Bucket(bucketReadingLists), concat(key(tenantID), id[:]), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (s *BoltStore) GetReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var l *model.ReadingListItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		l, err = getReadingList(tx, t, id)
+		return err
+	})
+	return l, err
+}
+
+func (s *BoltStore) ReadingListsOf(ctx context.Context, readerID string) ([]model.ReadingListItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.ReadingListItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		prefix := key(t, readerID)
+		c := prefixCursor{c: tx.Bucket(bucketReadingListsByReader).Cursor(), prefix: prefix}
+		for k, _ := c.seek(nil); k != nil; k, _ = c.next() {
+			var id primitive.ObjectID
+			copy(id[:], k[len(prefix):])
+			l, err := getReadingList(tx, t, id)
+			if err != nil {
+				return err
+			}
+			items = append(items, *l)
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (s *BoltStore) UpdateReadingList(ctx context.Context, id primitive.ObjectID, fn func(l *model.ReadingListItem) error) (*model.ReadingListItem, *model.ReadingListItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, after *model.ReadingListItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getReadingList(tx, t, id); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = t
+		return replaceReadingList(tx, before, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *BoltStore) DeleteReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *model.ReadingListItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getReadingList(tx, t, id); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketReadingListsByReader).Delete(concat(key(t, before.ReaderID), id[:])); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketReadingLists).Delete(concat(key(t), id[:]))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
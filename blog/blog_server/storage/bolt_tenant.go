@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+func (s *BoltStore) CreateTenant(ctx context.Context, t *model.TenantItem) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTenants)
+		if b.Get([]byte(t.ID)) != nil {
+			return ErrExists
+		}
+		return putValue(b, []byte(t.ID), t)
+	})
+}
+
+func (s *BoltStore) GetTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	var t model.TenantItem
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		return getValue(tx.Bucket(bucketTenants), []byte(id), &t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// LockTenant only reads the tenant, write transactions of bolt run one after
+// another.
+func (s *BoltStore) LockTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	return s.GetTenant(ctx, id)
+}
+
+func (s *BoltStore) ListTenants(ctx context.Context) ([]model.TenantItem, error) {
+	var items []model.TenantItem
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTenants)
+		return b.ForEach(func(k, _ []byte) error {
+			var t model.TenantItem
+			if err := getValue(b, k, &t); err != nil {
+				return err
+			}
+			items = append(items, t)
+			return nil
+		})
+	})
+	return items, err
+}
+
+func (s *BoltStore) UpdateTenant(ctx context.Context, id string, fn func(t *model.TenantItem) error) (*model.TenantItem, *model.TenantItem, error) {
+	var before, after model.TenantItem
+	err := s.update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTenants)
+		if err := getValue(b, []byte(id), &before); err != nil {
+			return err
+		}
+
+		after = before
+		if err := fn(&after); err != nil {
+			return err
+		}
+		after.ID = id
+		return putValue(b, []byte(id), &after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &before, &after, nil
+}
+
+// DeleteTenant deletes the tenant and its data in one transaction.
+func (s *BoltStore) DeleteTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	var before model.TenantItem
+	err := s.update(ctx, func(tx *bolt.Tx) error {
+		if err := getValue(tx.Bucket(bucketTenants), []byte(id), &before); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketTenants).Delete([]byte(id)); err != nil {
+			return err
+		}
+
+		prefix := key(id)
+		scoped := [][]byte{
+			bucketBlogs, bucketBlogsByAuthor, bucketBlogsByTag, bucketSeries, bucketSeriesByBlog, bucketWebhooks,
+			bucketReadingLists, bucketReadingListsByReader,
+		}
+		for _, name := range scoped {
+			if err := deletePrefix(tx.Bucket(name), prefix); err != nil {
+				return err
+			}
+		}
+		if err := deleteTenantViews(tx, id); err != nil {
+			return err
+		}
+		if err := deleteTenantDeliveries(tx, id); err != nil {
+			return err
+		}
+		return deleteTenantEvents(tx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &before, nil
+}
+
+func (s *BoltStore) AppendAudit(ctx context.Context, rec *model.AuditRecord) error {
+	if rec.ID.IsZero() {
+		rec.ID = primitive.NewObjectID()
+	}
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		if err := putValue(tx.Bucket(bucketAuditLog), rec.ID[:], rec); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketAuditByTime).Put(concat(timeKey(rec.Time), rec.ID[:]), nil)
+	})
+}
+
+// ListAudit walks the index by time in pages.
+func (s *BoltStore) ListAudit(ctx context.Context, f AuditFilter, fn func(rec *model.AuditRecord) error) error {
+	var last []byte
+	for {
+		var records []model.AuditRecord
+		done := false
+		err := s.view(ctx, func(tx *bolt.Tx) error {
+			log := tx.Bucket(bucketAuditLog)
+			c := prefixCursor{c: tx.Bucket(bucketAuditByTime).Cursor()}
+			k, _ := c.seekTo(timeKey(f.From))
+			if last != nil {
+				k, _ = c.seek(last)
+			}
+			for ; k != nil && len(records) < listPage; k, _ = c.next() {
+				last = append(last[:0], k...)
+				var rec model.AuditRecord
+				if err := getValue(log, k[8:], &rec); err != nil {
+					return err
+				}
+				if !f.To.IsZero() && !rec.Time.Before(f.To) {
+					k = nil
+					break
+				}
+				if (f.Principal != "" && rec.Principal != f.Principal) ||
+					(f.TenantID != "" && rec.TenantID != f.TenantID) ||
+					(f.BlogID != "" && rec.BlogID != f.BlogID) {
+					continue
+				}
+				records = append(records, rec)
+			}
+			done = k == nil
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range records {
+			if err := fn(&records[i]); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+func (s *BoltStore) InsertViews(ctx context.Context, events []model.ViewEvent) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		views := tx.Bucket(bucketViews)
+		for i := range events {
+			e := &events[i]
+			if views.Get([]byte(e.ID)) != nil {
+				continue
+			}
+			if err := putValue(views, []byte(e.ID), e); err != nil {
+				return err
+			}
+			err := tx.Bucket(bucketViewsByBlog).Put(concat(key(e.TenantID), e.BlogID[:], timeKey(e.Time), []byte(e.ID)), []byte(e.Viewer))
+			if err != nil {
+				return err
+			}
+			err = tx.Bucket(bucketViewsByTime).Put(concat(key(e.TenantID), timeKey(e.Time), []byte(e.ID)), concat(e.BlogID[:], []byte(e.Viewer)))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteTenantViews deletes the views the indexes of the tenant point to.
+func deleteTenantViews(tx *bolt.Tx, tenantID string) error {
+	prefix := key(tenantID)
+	c := prefixCursor{c: tx.Bucket(bucketViewsByTime).Cursor(), prefix: prefix}
+	for k, _ := c.seek(nil); k != nil; k, _ = c.next() {
+		if err := tx.Bucket(bucketViews).Delete(k[len(prefix)+8:]); err != nil {
+			return err
+		}
+	}
+	if err := deletePrefix(tx.Bucket(bucketViewsByBlog), prefix); err != nil {
+		return err
+	}
+	return deletePrefix(tx.Bucket(bucketViewsByTime), prefix)
+}
+
+func (s *BoltStore) DailyViews(ctx context.Context, blogID primitive.ObjectID, from, to time.Time) ([]DailyViews, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []DailyViews
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		prefix := concat(key(t), blogID[:])
+		end := concat(prefix, timeKey(to))
+		c := prefixCursor{c: tx.Bucket(bucketViewsByBlog).Cursor(), prefix: prefix}
+		k, v := c.seekTo(concat(prefix, timeKey(from)))
+		viewers := map[string]bool{}
+		for ; k != nil && string(k) < string(end); k, v = c.next() {
+			day := fromMillis(int64(binary.BigEndian.Uint64(k[len(prefix):]))).Format("2006-01-02")
+			if len(days) == 0 || days[len(days)-1].Day != day {
+				days = append(days, DailyViews{Day: day})
+				viewers = map[string]bool{}
+			}
+			d := &days[len(days)-1]
+			d.Total++
+			if !viewers[string(v)] {
+				viewers[string(v)] = true
+				d.Unique++
+			}
+		}
+		return nil
+	})
+	return days, err
+}
+
+func (s *BoltStore) TopViews(ctx context.Context, from, to time.Time, limit int) ([]BlogViews, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]*BlogViews{}
+	viewers := map[primitive.ObjectID]map[string]bool{}
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		prefix := key(t)
+		end := concat(prefix, timeKey(to))
+		c := prefixCursor{c: tx.Bucket(bucketViewsByTime).Cursor(), prefix: prefix}
+		k, v := c.seekTo(concat(prefix, timeKey(from)))
+		for ; k != nil && string(k) < string(end); k, v = c.next() {
+			var id primitive.ObjectID
+			copy(id[:], v)
+			viewer := string(v[len(id):])
+			b, ok := counts[id]
+			if !ok {
+				b = &BlogViews{BlogID: id}
+				counts[id] = b
+				viewers[id] = map[string]bool{}
+			}
+			b.Total++
+			if !viewers[id][viewer] {
+				viewers[id][viewer] = true
+				b.Unique++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blogs := make([]BlogViews, 0, len(counts))
+	for _, b := range counts {
+		blogs = append(blogs, *b)
+	}
+	sort.Slice(blogs, func(i, j int) bool {
+		if blogs[i].Total != blogs[j].Total {
+			return blogs[i].Total > blogs[j].Total
+		}
+		return blogs[i].BlogID.Hex() < blogs[j].BlogID.Hex()
+	})
+	if len(blogs) > limit {
+		blogs = blogs[:limit]
+	}
+	return blogs, nil
+}
@@ -0,0 +1,382 @@
+package storage
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (s *BoltStore) AddEvent(ctx context.Context, e *model.OutboxEvent) error {
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		if err := putValue(tx.Bucket(bucketOutbox), e.ID[:], e); err != nil {
+			return err
+		}
+		if e.DispatchedAt != nil {
+			return tx.Bucket(bucketOutboxDispatched).Put(concat(timeKey(*e.DispatchedAt), e.ID[:]), nil)
+		}
+		return tx.Bucket(bucketOutboxPending).Put(e.ID[:], nil)
+	})
+}
+
+func (s *BoltStore) PendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		outbox := tx.Bucket(bucketOutbox)
+		c := tx.Bucket(bucketOutboxPending).Cursor()
+		for k, _ := c.First(); k != nil && len(events) < limit; k, _ = c.Next() {
+			var e model.OutboxEvent
+			if err := getValue(outbox, k, &e); err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (s *BoltStore) GetEvent(ctx context.Context, id primitive.ObjectID) (*model.OutboxEvent, error) {
+	var e model.OutboxEvent
+	err := s.view(ctx, func(tx *bolt.Tx) error {
+		return getValue(tx.Bucket(bucketOutbox), id[:], &e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// DispatchEvent also deletes events dispatched longer than OutboxRetention ago.
+func (s *BoltStore) DispatchEvent(ctx context.Context, eventID primitive.ObjectID, deliveries []model.DeliveryItem) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		for _, d := range deliveries {
+			if tx.Bucket(bucketDeliveriesByEvent).Get(concat(d.EventID[:], d.WebhookID[:])) != nil {
+				continue
+			}
+			d.ID = primitive.NewObjectID()
+			if err := putDelivery(tx, nil, &d); err != nil {
+				return err
+			}
+		}
+
+		var e model.OutboxEvent
+		err := getValue(tx.Bucket(bucketOutbox), eventID[:], &e)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if err == nil && e.DispatchedAt == nil {
+			e.DispatchedAt = &now
+			if err := putValue(tx.Bucket(bucketOutbox), eventID[:], &e); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketOutboxPending).Delete(eventID[:]); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketOutboxDispatched).Put(concat(timeKey(now), eventID[:]), nil); err != nil {
+				return err
+			}
+		}
+		return purgeEvents(tx, now.Add(-OutboxRetention))
+	})
+}
+
+// purgeEvents deletes the events dispatched before t.
+func purgeEvents(tx *bolt.Tx, t time.Time) error {
+	var keys [][]byte
+	end := string(timeKey(t))
+	c := tx.Bucket(bucketOutboxDispatched).Cursor()
+	for k, _ := c.First(); k != nil && string(k) < end; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := tx.Bucket(bucketOutbox).Delete(k[8:]); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketOutboxDispatched).Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTenantEvents deletes the events of a tenant, the outbox is not keyed by tenant.
+func deleteTenantEvents(tx *bolt.Tx, tenantID string) error {
+	var events []model.OutboxEvent
+	outbox := tx.Bucket(bucketOutbox)
+	err := outbox.ForEach(func(k, _ []byte) error {
+		var e model.OutboxEvent
+		if err := getValue(outbox, k, &e); err != nil {
+			return err
+		}
+		if e.TenantID == tenantID {
+			events = append(events, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if err := outbox.Delete(e.ID[:]); err != nil {
+			return err
+		}
+		if e.DispatchedAt != nil {
+			err = tx.Bucket(bucketOutboxDispatched).Delete(concat(timeKey(*e.DispatchedAt), e.ID[:]))
+		} else {
+			err = tx.Bucket(bucketOutboxPending).Delete(e.ID[:])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimDelivery takes the first delivery of the due index, which holds
+// pending deliveries only.
+func (s *BoltStore) ClaimDelivery(ctx context.Context, now, leaseUntil time.Time) (*model.DeliveryItem, error) {
+	var d *model.DeliveryItem
+	err := s.update(ctx, func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(bucketDeliveriesDue).Cursor().First()
+		if k == nil || string(k[:8]) > string(timeKey(now)) {
+			return ErrNotFound
+		}
+
+		before, err := getDelivery(tx, k[8:])
+		if err != nil {
+			return err
+		}
+		claimed := *before
+		claimed.NextAttemptAt = leaseUntil
+		d = &claimed
+		return putDelivery(tx, before, d)
+	})
+	return d, err
+}
+
+func getDelivery(tx *bolt.Tx, id []byte) (*model.DeliveryItem, error) {
+	var d model.DeliveryItem
+	if err := getValue(tx.Bucket(bucketDeliveries), id, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// putDelivery stores d in place of before, which is nil for a new delivery,
+// and keeps the indexes in sync.
+func putDelivery(tx *bolt.Tx, before, d *model.DeliveryItem) error {
+	due := tx.Bucket(bucketDeliveriesDue)
+	if before == nil {
+		if err := tx.Bucket(bucketDeliveriesByEvent).Put(concat(d.EventID[:], d.WebhookID[:]), d.ID[:]); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketDeliveriesByTenant).Put(concat(key(d.TenantID), d.ID[:]), nil); err != nil {
+			return err
+		}
+	} else if before.State == model.DeliveryPending {
+		if err := due.Delete(concat(timeKey(before.NextAttemptAt), d.ID[:])); err != nil {
+			return err
+		}
+	}
+	if d.State == model.DeliveryPending {
+		if err := due.Put(concat(timeKey(d.NextAttemptAt), d.ID[:]), nil); err != nil {
+			return err
+		}
+	}
+	return putValue(tx.Bucket(bucketDeliveries), d.ID[:], d)
+}
+
+// deleteTenantDeliveries deletes the deliveries the tenant index points to.
+func deleteTenantDeliveries(tx *bolt.Tx, tenantID string) error {
+	prefix := key(tenantID)
+	c := prefixCursor{c: tx.Bucket(bucketDeliveriesByTenant).Cursor(), prefix: prefix}
+	for k, _ := c.seek(nil); k != nil; k, _ = c.next() {
+		d, err := getDelivery(tx, k[len(prefix):])
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketDeliveries).Delete(d.ID[:]); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketDeliveriesByEvent).Delete(concat(d.EventID[:], d.WebhookID[:])); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketDeliveriesDue).Delete(concat(timeKey(d.NextAttemptAt), d.ID[:])); err != nil {
+			return err
+		}
+	}
+	return deletePrefix(tx.Bucket(bucketDeliveriesByTenant), prefix)
+}
+
+func (s *BoltStore) CreateWebhook(ctx context.Context, w *model.WebhookItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketWebhooks)
+		k := concat(key(t), w.ID[:])
+		if b.Get(k) != nil {
+			return ErrExists
+		}
+		stored := w.Clone()
+		stored.TenantID = t
+		return putValue(b, k, stored)
+	})
+}
+
+func getWebhook(tx *bolt.Tx, tenantID string, id primitive.ObjectID) (*model.WebhookItem, error) {
+	var w model.WebhookItem
+	if err := getValue(tx.Bucket(bucketWebhooks), concat(key(tenantID), id[:]), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (s *BoltStore) GetWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var w *model.WebhookItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		w, err = getWebhook(tx, t, id)
+		return err
+	})
+	return w, err
+}
+
+func (s *BoltStore) ListWebhooks(ctx context.Context) ([]model.WebhookItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.WebhookItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		c := prefixCursor{c: tx.Bucket(bucketWebhooks).Cursor(), prefix: key(t)}
+		for k, v := c.seek(nil); k != nil; k, v = c.next() {
+			var w model.WebhookItem
+			if err := bson.Unmarshal(v, &w); err != nil {
+				return err
+			}
+			items = append(items, w)
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (s *BoltStore) UpdateWebhook(ctx context.Context, id primitive.ObjectID, fn func(w *model.WebhookItem) error) (*model.WebhookItem, *model.WebhookItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var before, after *model.WebhookItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getWebhook(tx, t, id); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = t
+		return putValue(tx.Bucket(bucketWebhooks), concat(key(t), id[:]), after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *BoltStore) DeleteWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *model.WebhookItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if before, err = getWebhook(tx, t, id); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketWebhooks).Delete(concat(key(t), id[:]))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+func (s *BoltStore) ListDeliveries(ctx context.Context, q DeliveryQuery) ([]model.DeliveryItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.DeliveryItem
+	err = s.view(ctx, func(tx *bolt.Tx) error {
+		prefix := key(t)
+		c := prefixCursor{c: tx.Bucket(bucketDeliveriesByTenant).Cursor(), prefix: prefix, desc: true}
+		for k, _ := c.seek(nil); k != nil; k, _ = c.next() {
+			if q.Limit > 0 && len(items) == q.Limit {
+				return nil
+			}
+			d, err := getDelivery(tx, k[len(prefix):])
+			if err != nil {
+				return err
+			}
+			if (!q.WebhookID.IsZero() && d.WebhookID != q.WebhookID) || (q.State != "" && d.State != q.State) {
+				continue
+			}
+			items = append(items, *d)
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (s *BoltStore) UpdateDelivery(ctx context.Context, id primitive.ObjectID, fn func(d *model.DeliveryItem) error) (*model.DeliveryItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var d *model.DeliveryItem
+	err = s.update(ctx, func(tx *bolt.Tx) error {
+		before, err := getDelivery(tx, id[:])
+		if err != nil {
+			return err
+		}
+		if before.TenantID != t {
+			return ErrNotFound
+		}
+
+		changed := *before
+		d = &changed
+		if err := fn(d); err != nil {
+			return err
+		}
+		d.ID = id
+		d.TenantID = t
+		return putDelivery(tx, before, d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
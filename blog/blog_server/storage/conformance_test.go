@@ -0,0 +1,791 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/db"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/migrate"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The conformance tests run against every backend. Bolt and SQLite always
+// run, Mongo and PostgreSQL only when BLOG_TEST_MONGO_URI or
+// BLOG_TEST_POSTGRES_DSN are set. They use a database or schema of their own,
+// which is dropped afterwards.
+
+func TestBoltConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) Store {
+		s, err := OpenBolt(filepath.Join(t.TempDir(), "blog.bolt"))
+		if err != nil {
+			t.Fatalf("OpenBolt: %v", err)
+		}
+		return s
+	})
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) Store {
+		s, err := OpenSQL(context.Background(), DriverSQLite, filepath.Join(t.TempDir(), "blog.db"))
+		if err != nil {
+			t.Fatalf("OpenSQL: %v", err)
+		}
+		return s
+	})
+}
+
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv("BLOG_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("BLOG_TEST_POSTGRES_DSN is not set")
+	}
+	testConformance(t, func(t *testing.T) Store {
+		ctx := context.Background()
+		admin, err := OpenSQL(ctx, DriverPostgres, dsn)
+		if err != nil {
+			t.Fatalf("OpenSQL: %v", err)
+		}
+		schema := "blog_test_" + primitive.NewObjectID().Hex()
+		if _, err := admin.DB.ExecContext(ctx, `CREATE SCHEMA `+schema); err != nil {
+			t.Fatalf("could not create schema: %v", err)
+		}
+		t.Cleanup(func() {
+			admin.DB.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`)
+			admin.Close(ctx)
+		})
+
+		sep := " "
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			sep = "?"
+			if strings.Contains(dsn, "?") {
+				sep = "&"
+			}
+		}
+		s, err := OpenSQL(ctx, DriverPostgres, dsn+sep+"search_path="+schema)
+		if err != nil {
+			t.Fatalf("OpenSQL: %v", err)
+		}
+		return s
+	})
+}
+
+func TestMongoConformance(t *testing.T) {
+	uri := os.Getenv("BLOG_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("BLOG_TEST_MONGO_URI is not set")
+	}
+	testConformance(t, func(t *testing.T) Store {
+		ctx := context.Background()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			t.Fatalf("could not connect to mongo: %v", err)
+		}
+		database := client.Database("blog_test_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() {
+			database.Drop(ctx)
+			client.Disconnect(ctx)
+		})
+
+		if _, err := migrate.New(database, db.DefaultCollections).Up(ctx, 0); err != nil {
+			t.Fatalf("could not migrate: %v", err)
+		}
+		s := NewMongoStore(database, db.DefaultCollections)
+		if err := s.CheckTransactions(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+// conformance tests get a store of their own with the active tenants t1 and t2.
+var conformance = []struct {
+	name string
+	test func(t *testing.T, s Store)
+}{
+	{"Blogs", testBlogs},
+	{"ListBlogs", testListBlogs},
+	{"ListBlogsText", testListBlogsText},
+	{"Series", testSeries},
+	{"Tenants", testTenants},
+	{"Tx", testTx},
+	{"Audit", testAudit},
+	{"Outbox", testOutbox},
+	{"Views", testViews},
+	{"ReadingLists", testReadingLists},
+	{"Stats", testStats},
+	{"Maintenance", testMaintenance},
+}
+
+func testConformance(t *testing.T, open func(t *testing.T) Store) {
+	for _, c := range conformance {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			s := open(t)
+			t.Cleanup(func() { s.Close(context.Background()) })
+			for _, id := range []string{"t1", "t2"} {
+				if err := s.CreateTenant(context.Background(), &model.TenantItem{ID: id, State: model.TenantActive, CreatedAt: now()}); err != nil {
+					t.Fatalf("CreateTenant: %v", err)
+				}
+			}
+			c.test(t, s)
+		})
+	}
+}
+
+var (
+	ctx1 = tenant.NewContext(context.Background(), "t1")
+	ctx2 = tenant.NewContext(context.Background(), "t2")
+)
+
+// now is truncated like the times the server stores.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func newBlog(t *testing.T, s Store, ctx context.Context, author string, tags ...string) *model.BlogItem {
+	t.Helper()
+	b := &model.BlogItem{
+		ID:        primitive.NewObjectID(),
+		AuthorId:  author,
+		Title:     "title of " + author,
+		Content:   "content of " + author,
+		Slug:      "title-of-" + author,
+		Language:  "en",
+		Tags:      tags,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+		Version:   1,
+	}
+	if err := s.CreateBlog(ctx, b); err != nil {
+		t.Fatalf("CreateBlog: %v", err)
+	}
+	return b
+}
+
+func mustErr(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("%s returned %v, want %v", what, err, want)
+	}
+}
+
+func testBlogs(t *testing.T, s Store) {
+	b := newBlog(t, s, ctx1, "ann", "go", "grpc")
+	b.Translations = map[string]model.TranslationItem{}
+
+	got, err := s.GetBlog(ctx1, b.ID)
+	if err != nil {
+		t.Fatalf("GetBlog: %v", err)
+	}
+	if got.Title != b.Title || got.TenantID != "t1" || got.Version != 1 || !got.CreatedAt.Equal(b.CreatedAt) || strings.Join(got.Tags, ",") != "go,grpc" {
+		t.Fatalf("GetBlog returned %+v", got)
+	}
+	mustErr(t, "CreateBlog of a taken id", s.CreateBlog(ctx1, b), ErrExists)
+
+	_, err = s.GetBlog(ctx2, b.ID)
+	mustErr(t, "GetBlog of another tenant", err, ErrNotFound)
+	_, _, err = s.UpdateBlog(ctx2, b.ID, 0, func(*model.BlogItem) error { return nil })
+	mustErr(t, "UpdateBlog of another tenant", err, ErrNotFound)
+	_, err = s.DeleteBlog(ctx2, b.ID)
+	mustErr(t, "DeleteBlog of another tenant", err, ErrNotFound)
+
+	before, after, err := s.UpdateBlog(ctx1, b.ID, 1, func(b *model.BlogItem) error {
+		b.Title = "changed"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+	if before.Title != b.Title || after.Title != "changed" || after.Version != 2 {
+		t.Fatalf("UpdateBlog returned %q version %d before and %q version %d after", before.Title, before.Version, after.Title, after.Version)
+	}
+	_, _, err = s.UpdateBlog(ctx1, b.ID, 1, func(*model.BlogItem) error { return nil })
+	mustErr(t, "UpdateBlog of an old version", err, ErrVersionConflict)
+	failed := errors.New("fn failed")
+	_, _, err = s.UpdateBlog(ctx1, b.ID, 0, func(b *model.BlogItem) error {
+		b.Title = "lost"
+		return failed
+	})
+	mustErr(t, "UpdateBlog with a failing fn", err, failed)
+	if got, _ := s.GetBlog(ctx1, b.ID); got.Title != "changed" || got.Version != 2 {
+		t.Fatalf("blog is %q version %d after a failed update", got.Title, got.Version)
+	}
+
+	other := newBlog(t, s, ctx1, "bob")
+	newBlog(t, s, ctx2, "cid")
+	blogs, err := s.GetBlogs(ctx1, []primitive.ObjectID{b.ID, other.ID, primitive.NewObjectID()})
+	if err != nil || len(blogs) != 2 {
+		t.Fatalf("GetBlogs returned %d blogs, %v, want 2", len(blogs), err)
+	}
+	if n, err := s.CountBlogs(ctx1); err != nil || n != 2 {
+		t.Fatalf("CountBlogs returned %d, %v, want 2", n, err)
+	}
+
+	if _, err := s.DeleteBlog(ctx1, b.ID); err != nil {
+		t.Fatalf("DeleteBlog: %v", err)
+	}
+	_, err = s.GetBlog(ctx1, b.ID)
+	mustErr(t, "GetBlog of a deleted blog", err, ErrNotFound)
+	_, err = s.DeleteBlog(ctx1, b.ID)
+	mustErr(t, "DeleteBlog of a deleted blog", err, ErrNotFound)
+}
+
+func listBlogs(t *testing.T, s Store, ctx context.Context, q BlogQuery) []string {
+	t.Helper()
+	var authors []string
+	err := s.ListBlogs(ctx, q, func(b *model.BlogItem) error {
+		authors = append(authors, b.AuthorId)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListBlogs: %v", err)
+	}
+	return authors
+}
+
+func testListBlogs(t *testing.T, s Store) {
+	for i, author := range []string{"a1", "a2", "a1", "a3", "a1"} {
+		tags := []string{"all"}
+		if i%2 == 0 {
+			tags = append(tags, "even")
+		}
+		newBlog(t, s, ctx1, author, tags...)
+	}
+	pending := newBlog(t, s, ctx1, "held")
+	_, _, err := s.UpdateBlog(ctx1, pending.ID, 0, func(b *model.BlogItem) error {
+		b.ModerationState = model.ModerationPending
+		b.Moderation = &model.ModerationItem{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+	newBlog(t, s, ctx2, "other")
+
+	for _, c := range []struct {
+		name string
+		q    BlogQuery
+		want string
+	}{
+		{"all", BlogQuery{}, "a1 a2 a1 a3 a1"},
+		{"in batches", BlogQuery{BatchSize: 2}, "a1 a2 a1 a3 a1"},
+		{"newest", BlogQuery{Newest: true, BatchSize: 2}, "a1 a3 a1 a2 a1"},
+		{"limit", BlogQuery{Limit: 2}, "a1 a2"},
+		{"author", BlogQuery{AuthorID: "a1", BatchSize: 1}, "a1 a1 a1"},
+		{"tag", BlogQuery{Tag: "even"}, "a1 a1 a1"},
+		{"pending", BlogQuery{Moderation: model.ModerationPending}, "held"},
+	} {
+		if got := strings.Join(listBlogs(t, s, ctx1, c.q), " "); got != c.want {
+			t.Errorf("ListBlogs %s listed %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = s.ListBlogs(ctx1, BlogQuery{BatchSize: 2}, func(*model.BlogItem) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Fatalf("ListBlogs called fn %d times and returned %v, want 1 and the error of fn", n, err)
+	}
+}
+
+func testListBlogsText(t *testing.T, s Store) {
+	create := func(ctx context.Context, author, title, content string) *model.BlogItem {
+		b := &model.BlogItem{ID: primitive.NewObjectID(), AuthorId: author, Title: title, Content: content, Language: "en", CreatedAt: now(), UpdatedAt: now(), Version: 1}
+		if err := s.CreateBlog(ctx, b); err != nil {
+			t.Fatalf("CreateBlog: %v", err)
+		}
+		return b
+	}
+	create(ctx1, "space", "Gophers in Space", "grpc streams to orbit")
+	create(ctx1, "kitchen", "Cooking", "pasta with basil")
+	create(ctx1, "garden", "Basil", "grows in pots")
+	create(ctx2, "other", "gophers", "of another tenant")
+	held := create(ctx1, "held", "gophers", "held by moderation")
+	_, _, err := s.UpdateBlog(ctx1, held.ID, 0, func(b *model.BlogItem) error {
+		b.ModerationState = model.ModerationPending
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+
+	for _, c := range []struct {
+		name string
+		q    BlogQuery
+		want string
+	}{
+		{"title", BlogQuery{Text: "gophers"}, "space"},
+		{"content, ignoring case", BlogQuery{Text: "PASTA"}, "kitchen"},
+		{"any word", BlogQuery{Text: "orbit pasta"}, "space kitchen"},
+		{"title or content", BlogQuery{Text: "basil"}, "kitchen garden"},
+		{"punctuation", BlogQuery{Text: `"-basil!"`}, "kitchen garden"},
+		{"no word", BlogQuery{Text: " ?! "}, "space kitchen garden"},
+		{"with author", BlogQuery{Text: "basil", AuthorID: "garden"}, "garden"},
+		{"newest with limit", BlogQuery{Text: "basil gophers", Newest: true, Limit: 2}, "garden kitchen"},
+		{"pending", BlogQuery{Text: "gophers", Moderation: model.ModerationPending}, "held"},
+		{"no match", BlogQuery{Text: "zebra"}, ""},
+	} {
+		if got := strings.Join(listBlogs(t, s, ctx1, c.q), " "); got != c.want {
+			t.Errorf("ListBlogs %s listed %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func testSeries(t *testing.T, s Store) {
+	b1, b2, b3 := newBlog(t, s, ctx1, "a"), newBlog(t, s, ctx1, "b"), newBlog(t, s, ctx1, "c")
+	series := &model.SeriesItem{ID: primitive.NewObjectID(), Title: "series", BlogIds: []primitive.ObjectID{b1.ID, b2.ID}}
+	if err := s.CreateSeries(ctx1, series); err != nil {
+		t.Fatalf("CreateSeries: %v", err)
+	}
+
+	got, err := s.GetSeries(ctx1, series.ID)
+	if err != nil || got.TenantID != "t1" || len(got.BlogIds) != 2 || got.BlogIds[0] != b1.ID {
+		t.Fatalf("GetSeries returned %+v, %v", got, err)
+	}
+	_, err = s.GetSeries(ctx2, series.ID)
+	mustErr(t, "GetSeries of another tenant", err, ErrNotFound)
+	if got, err := s.SeriesOfBlog(ctx1, b2.ID); err != nil || got.ID != series.ID {
+		t.Fatalf("SeriesOfBlog returned %+v, %v", got, err)
+	}
+	_, err = s.SeriesOfBlog(ctx1, b3.ID)
+	mustErr(t, "SeriesOfBlog of a blog in no series", err, ErrNotFound)
+
+	// a blog belongs to at most one series
+	taken := &model.SeriesItem{ID: primitive.NewObjectID(), Title: "taken", BlogIds: []primitive.ObjectID{b3.ID, b1.ID}}
+	mustErr(t, "CreateSeries with a blog of another series", s.CreateSeries(ctx1, taken), ErrExists)
+	second := &model.SeriesItem{ID: primitive.NewObjectID(), Title: "second", BlogIds: []primitive.ObjectID{b3.ID}}
+	if err := s.CreateSeries(ctx1, second); err != nil {
+		t.Fatalf("CreateSeries: %v", err)
+	}
+	_, _, err = s.UpdateSeries(ctx1, second.ID, func(series *model.SeriesItem) error {
+		series.BlogIds = append(series.BlogIds, b2.ID)
+		return nil
+	})
+	mustErr(t, "UpdateSeries adding a blog of another series", err, ErrExists)
+
+	_, after, err := s.UpdateSeries(ctx1, series.ID, func(series *model.SeriesItem) error {
+		series.BlogIds = []primitive.ObjectID{b2.ID, b1.ID}
+		return nil
+	})
+	if err != nil || after.BlogIds[0] != b2.ID {
+		t.Fatalf("UpdateSeries returned %+v, %v", after, err)
+	}
+
+	// a deleted blog leaves its series
+	if _, err := s.DeleteBlog(ctx1, b2.ID); err != nil {
+		t.Fatalf("DeleteBlog: %v", err)
+	}
+	if got, _ := s.GetSeries(ctx1, series.ID); len(got.BlogIds) != 1 || got.BlogIds[0] != b1.ID {
+		t.Fatalf("series holds %v after its blog was deleted, want only %s", got.BlogIds, b1.ID.Hex())
+	}
+
+	_, err = s.DeleteSeries(ctx2, series.ID)
+	mustErr(t, "DeleteSeries of another tenant", err, ErrNotFound)
+	if _, err := s.DeleteSeries(ctx1, series.ID); err != nil {
+		t.Fatalf("DeleteSeries: %v", err)
+	}
+	_, err = s.SeriesOfBlog(ctx1, b1.ID)
+	mustErr(t, "SeriesOfBlog after DeleteSeries", err, ErrNotFound)
+}
+
+func testTenants(t *testing.T, s Store) {
+	ctx := context.Background()
+	mustErr(t, "CreateTenant of a taken id", s.CreateTenant(ctx, &model.TenantItem{ID: "t1", State: model.TenantActive}), ErrExists)
+
+	_, after, err := s.UpdateTenant(ctx, "t1", func(t *model.TenantItem) error {
+		t.Quota.MaxPosts = 3
+		return nil
+	})
+	if err != nil || after.Quota.MaxPosts != 3 {
+		t.Fatalf("UpdateTenant returned %+v, %v", after, err)
+	}
+	err = s.Tx(ctx, func(ctx context.Context) error {
+		got, err := s.LockTenant(ctx, "t1")
+		if err != nil {
+			return err
+		}
+		if got.Quota.MaxPosts != 3 {
+			return fmt.Errorf("LockTenant returned %+v", got)
+		}
+		_, err = s.LockTenant(ctx, "missing")
+		mustErr(t, "LockTenant of a missing tenant", err, ErrNotFound)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("LockTenant: %v", err)
+	}
+
+	items, err := s.ListTenants(ctx)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("ListTenants returned %d tenants, %v, want 2", len(items), err)
+	}
+
+	b := newBlog(t, s, ctx1, "a")
+	if err := s.CreateSeries(ctx1, &model.SeriesItem{ID: primitive.NewObjectID(), BlogIds: []primitive.ObjectID{b.ID}}); err != nil {
+		t.Fatalf("CreateSeries: %v", err)
+	}
+	if err := s.CreateWebhook(ctx1, &model.WebhookItem{ID: primitive.NewObjectID(), URL: "https://example.com", CreatedAt: now()}); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if err := s.AppendAudit(ctx, &model.AuditRecord{TenantID: "t1", Method: "/test", Time: now()}); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+	kept := newBlog(t, s, ctx2, "b")
+
+	if _, err := s.DeleteTenant(ctx, "t1"); err != nil {
+		t.Fatalf("DeleteTenant: %v", err)
+	}
+	_, err = s.GetTenant(ctx, "t1")
+	mustErr(t, "GetTenant of a deleted tenant", err, ErrNotFound)
+	if n, _ := s.CountBlogs(ctx1); n != 0 {
+		t.Fatalf("deleted tenant has %d blogs", n)
+	}
+	if hooks, _ := s.ListWebhooks(ctx1); len(hooks) != 0 {
+		t.Fatalf("deleted tenant has %d webhooks", len(hooks))
+	}
+	if _, err := s.GetBlog(ctx2, kept.ID); err != nil {
+		t.Fatalf("blog of another tenant is gone: %v", err)
+	}
+	n := 0
+	s.ListAudit(ctx, AuditFilter{TenantID: "t1"}, func(*model.AuditRecord) error {
+		n++
+		return nil
+	})
+	if n != 1 {
+		t.Fatalf("deleted tenant has %d audit records, want them kept", n)
+	}
+	_, err = s.DeleteTenant(ctx, "t1")
+	mustErr(t, "DeleteTenant of a deleted tenant", err, ErrNotFound)
+}
+
+func testTx(t *testing.T, s Store) {
+	failed := errors.New("rolled back")
+	b := &model.BlogItem{ID: primitive.NewObjectID(), AuthorId: "a", Title: "t", CreatedAt: now(), UpdatedAt: now(), Version: 1}
+	err := s.Tx(ctx1, func(ctx context.Context) error {
+		if err := s.CreateBlog(ctx, b); err != nil {
+			return err
+		}
+		// nested calls join the transaction
+		err := s.Tx(ctx, func(ctx context.Context) error {
+			return s.AddEvent(ctx, &model.OutboxEvent{ID: primitive.NewObjectID(), TenantID: "t1", Type: "test", Payload: "{}", CreatedAt: now()})
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := s.GetBlog(ctx, b.ID); err != nil {
+			return fmt.Errorf("blog is not visible in its Tx: %v", err)
+		}
+		return failed
+	})
+	mustErr(t, "Tx", err, failed)
+
+	_, err = s.GetBlog(ctx1, b.ID)
+	mustErr(t, "GetBlog of a rolled back blog", err, ErrNotFound)
+	if events, _ := s.PendingEvents(context.Background(), 10); len(events) != 0 {
+		t.Fatalf("%d events of a rolled back Tx are pending", len(events))
+	}
+
+	err = s.Tx(ctx1, func(ctx context.Context) error {
+		return s.CreateBlog(ctx, b)
+	})
+	if err != nil {
+		t.Fatalf("Tx: %v", err)
+	}
+	if _, err := s.GetBlog(ctx1, b.ID); err != nil {
+		t.Fatalf("GetBlog of a committed blog: %v", err)
+	}
+}
+
+func testAudit(t *testing.T, s Store) {
+	ctx := context.Background()
+	start := now().Add(-time.Hour)
+	for i, rec := range []model.AuditRecord{
+		{Principal: "admin", TenantID: "t1", Method: "/CreateTenant"},
+		{Principal: "t1", TenantID: "t1", Method: "/CreateBlog", BlogID: "b1"},
+		{Principal: "t2", TenantID: "t2", Method: "/CreateBlog", BlogID: "b2"},
+		{Principal: "t1", TenantID: "t1", Method: "/UpdateBlog", BlogID: "b1"},
+	} {
+		rec.Time = start.Add(time.Duration(i) * time.Minute)
+		if err := s.AppendAudit(ctx, &rec); err != nil {
+			t.Fatalf("AppendAudit: %v", err)
+		}
+	}
+
+	for _, c := range []struct {
+		name string
+		f    AuditFilter
+		want string
+	}{
+		{"all", AuditFilter{}, "/CreateTenant /CreateBlog /CreateBlog /UpdateBlog"},
+		{"tenant", AuditFilter{TenantID: "t1"}, "/CreateTenant /CreateBlog /UpdateBlog"},
+		{"principal", AuditFilter{Principal: "t2"}, "/CreateBlog"},
+		{"blog", AuditFilter{BlogID: "b1"}, "/CreateBlog /UpdateBlog"},
+		{"time", AuditFilter{From: start.Add(time.Minute), To: start.Add(3 * time.Minute)}, "/CreateBlog /CreateBlog"},
+	} {
+		var methods []string
+		err := s.ListAudit(ctx, c.f, func(rec *model.AuditRecord) error {
+			methods = append(methods, rec.Method)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ListAudit: %v", err)
+		}
+		if got := strings.Join(methods, " "); got != c.want {
+			t.Errorf("ListAudit %s listed %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func testOutbox(t *testing.T, s Store) {
+	ctx := context.Background()
+	hook := &model.WebhookItem{ID: primitive.NewObjectID(), URL: "https://example.com/a", Events: []string{"blog.created"}, Secret: "s", CreatedAt: now()}
+	if err := s.CreateWebhook(ctx1, hook); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if got, err := s.GetWebhook(ctx1, hook.ID); err != nil || got.URL != hook.URL || got.TenantID != "t1" || len(got.Events) != 1 {
+		t.Fatalf("GetWebhook returned %+v, %v", got, err)
+	}
+	_, err := s.GetWebhook(ctx2, hook.ID)
+	mustErr(t, "GetWebhook of another tenant", err, ErrNotFound)
+	if hooks, _ := s.ListWebhooks(ctx2); len(hooks) != 0 {
+		t.Fatalf("ListWebhooks of another tenant returned %d webhooks", len(hooks))
+	}
+	_, after, err := s.UpdateWebhook(ctx1, hook.ID, func(w *model.WebhookItem) error {
+		w.URL = "https://example.com/b"
+		return nil
+	})
+	if err != nil || after.URL != "https://example.com/b" || after.Secret != "s" {
+		t.Fatalf("UpdateWebhook returned %+v, %v", after, err)
+	}
+
+	event := &model.OutboxEvent{ID: primitive.NewObjectID(), TenantID: "t1", Type: "blog.created", BlogID: "b1", Payload: `{"id":1}`, CreatedAt: now()}
+	if err := s.AddEvent(ctx, event); err != nil {
+		t.Fatalf("AddEvent: %v", err)
+	}
+	events, err := s.PendingEvents(ctx, 10)
+	if err != nil || len(events) != 1 || events[0].Payload != event.Payload {
+		t.Fatalf("PendingEvents returned %+v, %v", events, err)
+	}
+	if got, err := s.GetEvent(ctx, event.ID); err != nil || got.Type != event.Type {
+		t.Fatalf("GetEvent returned %+v, %v", got, err)
+	}
+
+	delivery := model.DeliveryItem{
+		TenantID: "t1", EventID: event.ID, WebhookID: hook.ID, EventType: event.Type, BlogID: "b1",
+		State: model.DeliveryPending, NextAttemptAt: now().Add(-time.Minute), CreatedAt: now(),
+	}
+	// repeating an interrupted fan out does not duplicate deliveries
+	for i := 0; i < 2; i++ {
+		if err := s.DispatchEvent(ctx, event.ID, []model.DeliveryItem{delivery}); err != nil {
+			t.Fatalf("DispatchEvent: %v", err)
+		}
+	}
+	if events, _ := s.PendingEvents(ctx, 10); len(events) != 0 {
+		t.Fatalf("%d events are pending after DispatchEvent", len(events))
+	}
+	deliveries, err := s.ListDeliveries(ctx1, DeliveryQuery{WebhookID: hook.ID})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListDeliveries returned %d deliveries, %v, want 1", len(deliveries), err)
+	}
+	if other, _ := s.ListDeliveries(ctx2, DeliveryQuery{}); len(other) != 0 {
+		t.Fatalf("ListDeliveries of another tenant returned %d deliveries", len(other))
+	}
+
+	lease := now().Add(time.Minute)
+	claimed, err := s.ClaimDelivery(ctx, now(), lease)
+	if err != nil || claimed.ID != deliveries[0].ID || !claimed.NextAttemptAt.Equal(lease) {
+		t.Fatalf("ClaimDelivery returned %+v, %v", claimed, err)
+	}
+	_, err = s.ClaimDelivery(ctx, now(), lease)
+	mustErr(t, "ClaimDelivery of a leased delivery", err, ErrNotFound)
+
+	updated, err := s.UpdateDelivery(ctx1, claimed.ID, func(d *model.DeliveryItem) error {
+		d.State = model.DeliveryDead
+		d.Attempts = 8
+		d.LastError = "gone"
+		return nil
+	})
+	if err != nil || updated.State != model.DeliveryDead {
+		t.Fatalf("UpdateDelivery returned %+v, %v", updated, err)
+	}
+	if dead, _ := s.ListDeliveries(ctx1, DeliveryQuery{State: model.DeliveryDead}); len(dead) != 1 || dead[0].LastError != "gone" {
+		t.Fatalf("ListDeliveries of dead deliveries returned %+v", dead)
+	}
+	if pending, _ := s.ListDeliveries(ctx1, DeliveryQuery{State: model.DeliveryPending}); len(pending) != 0 {
+		t.Fatalf("ListDeliveries of pending deliveries returned %d deliveries", len(pending))
+	}
+
+	if _, err := s.DeleteWebhook(ctx1, hook.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if kept, _ := s.ListDeliveries(ctx1, DeliveryQuery{}); len(kept) != 1 {
+		t.Fatalf("DeleteWebhook left %d deliveries, want them kept", len(kept))
+	}
+}
+
+func testViews(t *testing.T, s Store) {
+	b1, b2 := newBlog(t, s, ctx1, "a"), newBlog(t, s, ctx1, "b")
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	view := func(b primitive.ObjectID, tenantID, viewer string, at time.Time) model.ViewEvent {
+		return model.ViewEvent{
+			ID:       fmt.Sprintf("%s:%s:%s", b.Hex(), viewer, at.Format("2006010215")),
+			TenantID: tenantID, BlogID: b, Viewer: viewer, Time: at,
+		}
+	}
+	events := []model.ViewEvent{
+		view(b1.ID, "t1", "v1", day),
+		view(b1.ID, "t1", "v1", day.Add(time.Hour)),
+		view(b1.ID, "t1", "v2", day.Add(2*time.Hour)),
+		view(b1.ID, "t1", "v1", day.Add(24*time.Hour)),
+		view(b2.ID, "t1", "v1", day),
+	}
+	// stored events are skipped
+	for i := 0; i < 2; i++ {
+		if err := s.InsertViews(context.Background(), events); err != nil {
+			t.Fatalf("InsertViews: %v", err)
+		}
+	}
+
+	days, err := s.DailyViews(ctx1, b1.ID, day.Add(-time.Hour), day.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("DailyViews: %v", err)
+	}
+	if got := fmt.Sprint(days); got != "[{2024-03-01 3 2} {2024-03-02 1 1}]" {
+		t.Fatalf("DailyViews returned %s", got)
+	}
+	if days, _ := s.DailyViews(ctx2, b1.ID, day.Add(-time.Hour), day.Add(48*time.Hour)); len(days) != 0 {
+		t.Fatalf("DailyViews of another tenant returned %v", days)
+	}
+
+	top, err := s.TopViews(ctx1, day.Add(-time.Hour), day.Add(48*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("TopViews: %v", err)
+	}
+	if len(top) != 2 || top[0].BlogID != b1.ID || top[0].Total != 4 || top[0].Unique != 2 || top[1].Total != 1 {
+		t.Fatalf("TopViews returned %+v", top)
+	}
+	if top, _ := s.TopViews(ctx1, day.Add(-time.Hour), day.Add(48*time.Hour), 1); len(top) != 1 {
+		t.Fatalf("TopViews with limit 1 returned %d blogs", len(top))
+	}
+}
+
+func testReadingLists(t *testing.T, s Store) {
+	first := &model.ReadingListItem{ID: primitive.NewObjectID(), ReaderID: "r1", Name: "first", CreatedAt: now(), UpdatedAt: now()}
+	second := &model.ReadingListItem{ID: primitive.NewObjectID(), ReaderID: "r1", Name: "second", CreatedAt: now(), UpdatedAt: now()}
+	for _, l := range []*model.ReadingListItem{first, second} {
+		if err := s.CreateReadingList(ctx1, l); err != nil {
+			t.Fatalf("CreateReadingList: %v", err)
+		}
+	}
+	if err := s.CreateReadingList(ctx2, &model.ReadingListItem{ID: primitive.NewObjectID(), ReaderID: "r1", Name: "other", CreatedAt: now()}); err != nil {
+		t.Fatalf("CreateReadingList: %v", err)
+	}
+
+	lists, err := s.ReadingListsOf(ctx1, "r1")
+	if err != nil || len(lists) != 2 || lists[0].Name != "first" {
+		t.Fatalf("ReadingListsOf returned %+v, %v", lists, err)
+	}
+	_, err = s.GetReadingList(ctx2, first.ID)
+	mustErr(t, "GetReadingList of another tenant", err, ErrNotFound)
+
+	entry := model.ReadingListEntry{BlogID: primitive.NewObjectID(), Title: "saved", AddedAt: now()}
+	_, after, err := s.UpdateReadingList(ctx1, first.ID, func(l *model.ReadingListItem) error {
+		l.Entries = append(l.Entries, entry)
+		return nil
+	})
+	if err != nil || len(after.Entries) != 1 {
+		t.Fatalf("UpdateReadingList returned %+v, %v", after, err)
+	}
+	got, err := s.GetReadingList(ctx1, first.ID)
+	if err != nil || len(got.Entries) != 1 || got.Entries[0].Title != "saved" || !got.Entries[0].AddedAt.Equal(entry.AddedAt) {
+		t.Fatalf("GetReadingList returned %+v, %v", got, err)
+	}
+
+	_, err = s.DeleteReadingList(ctx2, first.ID)
+	mustErr(t, "DeleteReadingList of another tenant", err, ErrNotFound)
+	if _, err := s.DeleteReadingList(ctx1, first.ID); err != nil {
+		t.Fatalf("DeleteReadingList: %v", err)
+	}
+	if lists, _ := s.ReadingListsOf(ctx1, "r1"); len(lists) != 1 {
+		t.Fatalf("ReadingListsOf returned %d lists after a delete, want 1", len(lists))
+	}
+}
+
+func testStats(t *testing.T, s Store) {
+	newBlog(t, s, ctx1, "a", "go")
+	newBlog(t, s, ctx1, "a", "go", "grpc")
+	newBlog(t, s, ctx1, "b", "grpc")
+	pending := newBlog(t, s, ctx1, "c", "go")
+	_, _, err := s.UpdateBlog(ctx1, pending.ID, 0, func(b *model.BlogItem) error {
+		b.ModerationState = model.ModerationPending
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateBlog: %v", err)
+	}
+	newBlog(t, s, ctx2, "d", "go")
+
+	stats, err := s.BlogStats(context.Background(), StatsQuery{TenantID: "t1"})
+	if err != nil {
+		t.Fatalf("BlogStats: %v", err)
+	}
+	if stats.Posts != 3 || len(stats.Authors) != 2 || stats.Authors[0].AuthorID != "a" || stats.Authors[0].Posts != 2 {
+		t.Fatalf("BlogStats returned %d posts by %+v", stats.Posts, stats.Authors)
+	}
+	tags := map[string]int64{}
+	for _, tag := range stats.Tags {
+		tags[tag.Tag] = tag.Posts
+	}
+	if len(tags) != 2 || tags["go"] != 2 || tags["grpc"] != 2 {
+		t.Fatalf("BlogStats returned tags %+v", stats.Tags)
+	}
+	if len(stats.Months) != 1 || stats.Months[0].Month != now().Format("2006-01") || stats.Months[0].Posts != 3 {
+		t.Fatalf("BlogStats returned months %+v", stats.Months)
+	}
+
+	all, err := s.BlogStats(context.Background(), StatsQuery{})
+	if err != nil || all.Posts != 4 {
+		t.Fatalf("BlogStats of every tenant returned %+v, %v", all, err)
+	}
+}
+
+func testMaintenance(t *testing.T, s Store) {
+	b := newBlog(t, s, ctx1, "a")
+	if err := s.CreateSeries(ctx1, &model.SeriesItem{ID: primitive.NewObjectID(), BlogIds: []primitive.ObjectID{b.ID}}); err != nil {
+		t.Fatalf("CreateSeries: %v", err)
+	}
+	err := s.Orphans(context.Background(), func(o *Orphan) error {
+		return fmt.Errorf("unexpected orphan %+v", o)
+	})
+	if err != nil {
+		t.Fatalf("Orphans: %v", err)
+	}
+
+	sizes, err := s.Sizes(context.Background())
+	if err != nil {
+		t.Fatalf("Sizes: %v", err)
+	}
+	items := map[string]int64{}
+	var names []string
+	for _, c := range sizes.Collections {
+		items[c.Name] = c.Items
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	if items["tenants"] != 2 || items["series"] != 1 {
+		t.Fatalf("Sizes counted %v in %v", items, names)
+	}
+}
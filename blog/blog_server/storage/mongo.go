@@ -0,0 +1,919 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/db"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"strings"
+	"time"
+)
+
+// MongoStore keeps every kind of item in its own collection, the indexes are
+// created by the migrations of package migrate. Transactions need a replica set.
+type MongoStore struct {
+	// Blogs, Series, Webhooks, Deliveries, Views and ReadingLists are limited to the tenant of the context.
+	Blogs        *tenant.Collection
+	Series       *tenant.Collection
+	Webhooks     *tenant.Collection
+	Deliveries   *tenant.Collection
+	Views        *tenant.Collection
+	ReadingLists *tenant.Collection
+	Tenants      *mongo.Collection
+	AuditLog     *mongo.Collection
+	Outbox       *mongo.Collection
+
+	client *mongo.Client
+}
+
+func NewMongoStore(database *mongo.Database, c db.Collections) *MongoStore {
+	return &MongoStore{
+		Blogs:        tenant.NewCollection(database.Collection(c.Blog)),
+		Series:       tenant.NewCollection(database.Collection(c.Series)),
+		Webhooks:     tenant.NewCollection(database.Collection(c.Webhooks)),
+		Deliveries:   tenant.NewCollection(database.Collection(c.Deliveries)),
+		Views:        tenant.NewCollection(database.Collection(c.Views)),
+		ReadingLists: tenant.NewCollection(database.Collection(c.ReadingLists)),
+		Tenants:      database.Collection(c.Tenants),
+		AuditLog:     database.Collection(c.AuditLog),
+		Outbox:       database.Collection(c.Outbox),
+		client:       database.Client(),
+	}
+}
+
+// Tx runs fn in the transaction of ctx when there is one already.
+func (s *MongoStore) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// CheckTransactions fails when the deployment cannot run the transactions of
+// Tx, which a standalone mongod can't. Use a replica set, a single node one
+// started with --replSet and rs.initiate() is enough, or a sharded cluster.
+func (s *MongoStore) CheckTransactions(ctx context.Context) error {
+	var hello bson.M
+	if err := s.client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	return transactionsSupported(hello)
+}
+
+// transactionsSupported checks the reply of isMaster, members of a replica set
+// report its setName and the routers of a sharded cluster msg isdbgrid.
+func transactionsSupported(hello bson.M) error {
+	if name, _ := hello["setName"].(string); name != "" {
+		return nil
+	}
+	if msg, _ := hello["msg"].(string); msg == "isdbgrid" {
+		return nil
+	}
+	return errors.New("mongo is a standalone server, transactions need a replica set or a sharded cluster, start mongod with --replSet and run rs.initiate()")
+}
+
+// Close does nothing, the client is disconnected by whoever connected it.
+func (s *MongoStore) Close(ctx context.Context) error {
+	return nil
+}
+
+func (s *MongoStore) CreateBlog(ctx context.Context, b *model.BlogItem) error {
+	_, err := s.Blogs.InsertOne(ctx, b)
+	return insertError(err)
+}
+
+func (s *MongoStore) GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	var b model.BlogItem
+	if err := s.Blogs.FindOne(ctx, bson.M{"_id": id}).Decode(&b); err != nil {
+		return nil, notFound(err)
+	}
+	return &b, nil
+}
+
+func (s *MongoStore) GetBlogs(ctx context.Context, ids []primitive.ObjectID) ([]model.BlogItem, error) {
+	cur, err := s.Blogs.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var items []model.BlogItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *MongoStore) UpdateBlog(ctx context.Context, id primitive.ObjectID, version int64, fn func(b *model.BlogItem) error) (*model.BlogItem, *model.BlogItem, error) {
+	var before, after *model.BlogItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.GetBlog(ctx, id); err != nil {
+			return err
+		}
+		if version != 0 && before.Version != version {
+			return ErrVersionConflict
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.Version = before.Version + 1
+		_, err = s.Blogs.ReplaceOne(ctx, bson.M{"_id": id}, after)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *MongoStore) DeleteBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	var before model.BlogItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		if err := s.Blogs.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&before); err != nil {
+			return notFound(err)
+		}
+		_, err := s.Series.UpdateMany(ctx, bson.M{"blog_ids": id}, bson.M{"$pull": bson.M{"blog_ids": id}})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &before, nil
+}
+
+func (s *MongoStore) CountBlogs(ctx context.Context) (int64, error) {
+	return s.Blogs.CountDocuments(ctx, bson.D{})
+}
+
+func (s *MongoStore) ListBlogs(ctx context.Context, q BlogQuery, fn func(b *model.BlogItem) error) error {
+	// published blogs have no moderation state, null matches a missing field
+	filter := bson.M{"moderation_state": nil}
+	if q.Moderation != "" {
+		filter["moderation_state"] = q.Moderation
+	}
+	if q.AuthorID != "" {
+		filter["author_id"] = q.AuthorID
+	}
+	if q.Tag != "" {
+		filter["tags"] = q.Tag
+	}
+	// the words only, $search would read quotes as phrases and a dash as negation
+	if words := textWords(q.Text); len(words) > 0 {
+		filter["$text"] = bson.M{"$search": strings.Join(words, " ")}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	if q.Newest {
+		opts.SetSort(bson.M{"_id": -1})
+	}
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+	if q.BatchSize > 0 {
+		opts.SetBatchSize(int32(q.BatchSize))
+	}
+
+	cur, err := s.Blogs.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	// the request context may be done already, the server cursor is killed anyway
+	defer closeCursor(cur)
+
+	for cur.Next(ctx) {
+		var b model.BlogItem
+		if err := cur.Decode(&b); err != nil {
+			return err
+		}
+		if err := fn(&b); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (s *MongoStore) CreateSeries(ctx context.Context, series *model.SeriesItem) error {
+	_, err := s.Series.InsertOne(ctx, series)
+	return insertError(err)
+}
+
+func (s *MongoStore) GetSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	return s.findSeries(ctx, bson.M{"_id": id})
+}
+
+func (s *MongoStore) SeriesOfBlog(ctx context.Context, blogID primitive.ObjectID) (*model.SeriesItem, error) {
+	return s.findSeries(ctx, bson.M{"blog_ids": blogID})
+}
+
+func (s *MongoStore) findSeries(ctx context.Context, filter bson.M) (*model.SeriesItem, error) {
+	var series model.SeriesItem
+	if err := s.Series.FindOne(ctx, filter).Decode(&series); err != nil {
+		return nil, notFound(err)
+	}
+	return &series, nil
+}
+
+func (s *MongoStore) UpdateSeries(ctx context.Context, id primitive.ObjectID, fn func(s *model.SeriesItem) error) (*model.SeriesItem, *model.SeriesItem, error) {
+	var before, after *model.SeriesItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.GetSeries(ctx, id); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		_, err = s.Series.ReplaceOne(ctx, bson.M{"_id": id}, after)
+		return insertError(err)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *MongoStore) DeleteSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	var before model.SeriesItem
+	if err := s.Series.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&before); err != nil {
+		return nil, notFound(err)
+	}
+	return &before, nil
+}
+
+func (s *MongoStore) CreateReadingList(ctx context.Context, l *model.ReadingListItem) error {
+	_, err := s.ReadingLists.InsertOne(ctx, l)
+	return insertError(err)
+}
+
+func (s *MongoStore) GetReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	var l model.ReadingListItem
+	if err := s.ReadingLists.FindOne(ctx, bson.M{"_id": id}).Decode(&l); err != nil {
+		return nil, notFound(err)
+	}
+	return &l, nil
+}
+
+func (s *MongoStore) ReadingListsOf(ctx context.Context, readerID string) ([]model.ReadingListItem, error) {
+	cur, err := s.ReadingLists.Find(ctx, bson.M{"reader_id": readerID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var items []model.ReadingListItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *MongoStore) UpdateReadingList(ctx context.Context, id primitive.ObjectID, fn func(l *model.ReadingListItem) error) (*model.ReadingListItem, *model.ReadingListItem, error) {
+	var before, after *model.ReadingListItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.GetReadingList(ctx, id); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		_, err = s.ReadingLists.ReplaceOne(ctx, bson.M{"_id": id}, after)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *MongoStore) DeleteReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	var before model.ReadingListItem
+	if err := s.ReadingLists.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&before); err != nil {
+		return nil, notFound(err)
+	}
+	return &before, nil
+}
+
+func (s *MongoStore) CreateTenant(ctx context.Context, t *model.TenantItem) error {
+	_, err := s.Tenants.InsertOne(ctx, t)
+	return insertError(err)
+}
+
+func (s *MongoStore) GetTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	var t model.TenantItem
+	if err := s.Tenants.FindOne(ctx, bson.M{"_id": id}).Decode(&t); err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (s *MongoStore) ListTenants(ctx context.Context) ([]model.TenantItem, error) {
+	cur, err := s.Tenants.Find(ctx, bson.D{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var items []model.TenantItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *MongoStore) UpdateTenant(ctx context.Context, id string, fn func(t *model.TenantItem) error) (*model.TenantItem, *model.TenantItem, error) {
+	var before, after *model.TenantItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.GetTenant(ctx, id); err != nil {
+			return err
+		}
+
+		changed := *before
+		after = &changed
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		_, err = s.Tenants.ReplaceOne(ctx, bson.M{"_id": id}, after)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// LockTenant increments the locks field of the tenant, so transactions
+// locking it concurrently have a write conflict and are retried by Tx.
+func (s *MongoStore) LockTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	var t model.TenantItem
+	res := s.Tenants.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"locks": 1}})
+	if err := res.Decode(&t); err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+// DeleteTenant removes the tenant first, so its key stops working before its
+// data is gone.
+func (s *MongoStore) DeleteTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	var before model.TenantItem
+	if err := s.Tenants.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&before); err != nil {
+		return nil, notFound(err)
+	}
+
+	filter := bson.M{tenant.Field: id}
+	collections := []*mongo.Collection{
+		s.Blogs.Unscoped(), s.Series.Unscoped(), s.Views.Unscoped(),
+		s.Webhooks.Unscoped(), s.Deliveries.Unscoped(), s.ReadingLists.Unscoped(), s.Outbox,
+	}
+	for _, c := range collections {
+		if _, err := c.DeleteMany(ctx, filter); err != nil {
+			log.Printf("Could not delete data of tenant %s from %s: %v", id, c.Name(), err)
+			return nil, err
+		}
+	}
+	return &before, nil
+}
+
+func (s *MongoStore) AppendAudit(ctx context.Context, rec *model.AuditRecord) error {
+	if rec.ID.IsZero() {
+		rec.ID = primitive.NewObjectID()
+	}
+	_, err := s.AuditLog.InsertOne(ctx, rec)
+	return err
+}
+
+func (s *MongoStore) ListAudit(ctx context.Context, f AuditFilter, fn func(rec *model.AuditRecord) error) error {
+	filter := bson.M{}
+	if f.Principal != "" {
+		filter["principal"] = f.Principal
+	}
+	if f.TenantID != "" {
+		filter["tenant_id"] = f.TenantID
+	}
+	if f.BlogID != "" {
+		filter["blog_id"] = f.BlogID
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		t := bson.M{}
+		if !f.From.IsZero() {
+			t["$gte"] = f.From
+		}
+		if !f.To.IsZero() {
+			t["$lt"] = f.To
+		}
+		filter["time"] = t
+	}
+
+	cur, err := s.AuditLog.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "time", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer closeCursor(cur)
+
+	for cur.Next(ctx) {
+		var rec model.AuditRecord
+		if err := cur.Decode(&rec); err != nil {
+			return err
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (s *MongoStore) AddEvent(ctx context.Context, e *model.OutboxEvent) error {
+	_, err := s.Outbox.InsertOne(ctx, e)
+	return err
+}
+
+func (s *MongoStore) PendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	cur, err := s.Outbox.Find(ctx,
+		bson.M{"dispatched_at": bson.M{"$exists": false}},
+		options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var events []model.OutboxEvent
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *MongoStore) GetEvent(ctx context.Context, id primitive.ObjectID) (*model.OutboxEvent, error) {
+	var e model.OutboxEvent
+	if err := s.Outbox.FindOne(ctx, bson.M{"_id": id}).Decode(&e); err != nil {
+		return nil, notFound(err)
+	}
+	return &e, nil
+}
+
+// DispatchEvent upserts the deliveries by event and webhook, the unique index
+// on both keeps a repeated fan out from creating a delivery twice.
+func (s *MongoStore) DispatchEvent(ctx context.Context, eventID primitive.ObjectID, deliveries []model.DeliveryItem) error {
+	for _, d := range deliveries {
+		_, err := s.Deliveries.Unscoped().UpdateOne(ctx,
+			bson.M{"event_id": d.EventID, "webhook_id": d.WebhookID},
+			bson.M{"$setOnInsert": bson.M{
+				"tenant_id":       d.TenantID,
+				"event_type":      d.EventType,
+				"blog_id":         d.BlogID,
+				"state":           d.State,
+				"attempts":        d.Attempts,
+				"next_attempt_at": d.NextAttemptAt,
+				"created_at":      d.CreatedAt,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	_, err := s.Outbox.UpdateOne(ctx, bson.M{"_id": eventID}, bson.M{"$set": bson.M{"dispatched_at": now}})
+	return err
+}
+
+func (s *MongoStore) ClaimDelivery(ctx context.Context, now, leaseUntil time.Time) (*model.DeliveryItem, error) {
+	var d model.DeliveryItem
+	err := s.Deliveries.Unscoped().FindOneAndUpdate(ctx,
+		bson.M{"state": model.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_attempt_at": leaseUntil}},
+		options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1}).SetReturnDocument(options.After),
+	).Decode(&d)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &d, nil
+}
+
+func (s *MongoStore) CreateWebhook(ctx context.Context, w *model.WebhookItem) error {
+	_, err := s.Webhooks.InsertOne(ctx, w)
+	return insertError(err)
+}
+
+func (s *MongoStore) GetWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error) {
+	var w model.WebhookItem
+	if err := s.Webhooks.FindOne(ctx, bson.M{"_id": id}).Decode(&w); err != nil {
+		return nil, notFound(err)
+	}
+	return &w, nil
+}
+
+func (s *MongoStore) ListWebhooks(ctx context.Context) ([]model.WebhookItem, error) {
+	cur, err := s.Webhooks.Find(ctx, nil, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var items []model.WebhookItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *MongoStore) UpdateWebhook(ctx context.Context, id primitive.ObjectID, fn func(w *model.WebhookItem) error) (*model.WebhookItem, *model.WebhookItem, error) {
+	var before, after *model.WebhookItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.GetWebhook(ctx, id); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		_, err = s.Webhooks.ReplaceOne(ctx, bson.M{"_id": id}, after)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *MongoStore) DeleteWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error) {
+	var before model.WebhookItem
+	if err := s.Webhooks.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&before); err != nil {
+		return nil, notFound(err)
+	}
+	return &before, nil
+}
+
+func (s *MongoStore) ListDeliveries(ctx context.Context, q DeliveryQuery) ([]model.DeliveryItem, error) {
+	filter := bson.M{}
+	if !q.WebhookID.IsZero() {
+		filter["webhook_id"] = q.WebhookID
+	}
+	if q.State != "" {
+		filter["state"] = q.State
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1})
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+
+	cur, err := s.Deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var items []model.DeliveryItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *MongoStore) UpdateDelivery(ctx context.Context, id primitive.ObjectID, fn func(d *model.DeliveryItem) error) (*model.DeliveryItem, error) {
+	var after model.DeliveryItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		if err := s.Deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&after); err != nil {
+			return notFound(err)
+		}
+		if err := fn(&after); err != nil {
+			return err
+		}
+		after.ID = id
+		_, err := s.Deliveries.ReplaceOne(ctx, bson.M{"_id": id}, after)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+func (s *MongoStore) InsertViews(ctx context.Context, events []model.ViewEvent) error {
+	docs := make([]interface{}, 0, len(events))
+	for _, e := range events {
+		docs = append(docs, e)
+	}
+	_, err := s.Views.Unscoped().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicates(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *MongoStore) DailyViews(ctx context.Context, blogID primitive.ObjectID, from, to time.Time) ([]DailyViews, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"blog_id": blogID, "time": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"day": bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$time"}}, "viewer": "$viewer"},
+			"views": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$_id.day",
+			"total":  bson.M{"$sum": "$views"},
+			"unique": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cur, err := s.Views.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var days []DailyViews
+	if err := cur.All(ctx, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+func (s *MongoStore) TopViews(ctx context.Context, from, to time.Time, limit int) ([]BlogViews, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"time": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$blog_id",
+			"total":   bson.M{"$sum": 1},
+			"viewers": bson.M{"$addToSet": "$viewer"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"total": 1, "unique": bson.M{"$size": "$viewers"}}}},
+	}
+
+	cur, err := s.Views.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var blogs []BlogViews
+	if err := cur.All(ctx, &blogs); err != nil {
+		return nil, err
+	}
+	return blogs, nil
+}
+
+// BlogStats runs the aggregations in one pass over the blogs with $facet.
+func (s *MongoStore) BlogStats(ctx context.Context, q StatsQuery) (*BlogStats, error) {
+	match := bson.M{"moderation_state": nil}
+	if q.TenantID != "" {
+		match[tenant.Field] = q.TenantID
+	}
+	created := bson.M{}
+	if !q.From.IsZero() {
+		created["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		created["$lt"] = q.To
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// blogs stored without created_at were created with their ObjectID
+		{{Key: "$addFields", Value: bson.M{"created": bson.M{"$ifNull": bson.A{"$created_at", bson.M{"$toDate": "$_id"}}}}}},
+	}
+	if len(created) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"created": created}}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"totals": bson.A{
+			bson.M{"$group": bson.M{
+				"_id":            nil,
+				"posts":          bson.M{"$sum": 1},
+				"content_length": bson.M{"$sum": bson.M{"$strLenCP": bson.M{"$ifNull": bson.A{"$content", ""}}}},
+			}},
+		},
+		"authors": bson.A{
+			bson.M{"$group": bson.M{"_id": bson.M{"tenant_id": "$" + tenant.Field, "author_id": "$author_id"}, "posts": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "posts", Value: -1}, {Key: "_id.tenant_id", Value: 1}, {Key: "_id.author_id", Value: 1}}},
+			bson.M{"$limit": StatsRows},
+		},
+		"tags": bson.A{
+			bson.M{"$unwind": "$tags"},
+			bson.M{"$group": bson.M{"_id": "$tags", "posts": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "posts", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": StatsRows},
+		},
+		"months": bson.A{
+			bson.M{"$group": bson.M{"_id": bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$created"}}, "posts": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.M{"_id": 1}},
+		},
+	}}})
+
+	cur, err := s.Blogs.Unscoped().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var facets []struct {
+		Totals []struct {
+			Posts         int64 `bson:"posts"`
+			ContentLength int64 `bson:"content_length"`
+		} `bson:"totals"`
+		Authors []struct {
+			ID struct {
+				TenantID string `bson:"tenant_id"`
+				AuthorID string `bson:"author_id"`
+			} `bson:"_id"`
+			Posts int64 `bson:"posts"`
+		} `bson:"authors"`
+		Tags   []TagPosts   `bson:"tags"`
+		Months []MonthPosts `bson:"months"`
+	}
+	if err := cur.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+
+	stats := &BlogStats{}
+	if len(facets) == 0 {
+		return stats, nil
+	}
+	f := facets[0]
+	if len(f.Totals) > 0 {
+		stats.Posts, stats.ContentLength = f.Totals[0].Posts, f.Totals[0].ContentLength
+	}
+	for _, a := range f.Authors {
+		stats.Authors = append(stats.Authors, AuthorPosts{TenantID: a.ID.TenantID, AuthorID: a.ID.AuthorID, Posts: a.Posts})
+	}
+	stats.Tags, stats.Months = f.Tags, f.Months
+	return stats, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func insertError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrExists
+	}
+	return err
+}
+
+func onlyDuplicates(err error) bool {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil {
+		return false
+	}
+	for _, we := range bwe.WriteErrors {
+		if we.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+func closeCursor(cur *mongo.Cursor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cur.Close(ctx); err != nil {
+		log.Printf("Could not close cursor: %v", err)
+	}
+}
+
+// Orphans finds the tenants of the data that no longer exist first, so only
+// the orphans are read.
+func (s *MongoStore) Orphans(ctx context.Context, fn func(o *Orphan) error) error {
+	ids, err := s.Tenants.Distinct(ctx, "_id", bson.M{})
+	if err != nil {
+		return err
+	}
+	tenants := make(map[interface{}]bool, len(ids))
+	for _, id := range ids {
+		tenants[id] = true
+	}
+
+	// named like the SQL tables
+	collections := []struct {
+		name string
+		c    *mongo.Collection
+	}{
+		{"blogs", s.Blogs.Unscoped()},
+		{"series", s.Series.Unscoped()},
+		{"reading_lists", s.ReadingLists.Unscoped()},
+		{"webhooks", s.Webhooks.Unscoped()},
+	}
+	for _, coll := range collections {
+		name, c := coll.name, coll.c
+		used, err := c.Distinct(ctx, tenant.Field, bson.M{})
+		if err != nil {
+			return err
+		}
+		var missing bson.A
+		for _, id := range used {
+			if !tenants[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		opts := options.Find().SetProjection(bson.M{tenant.Field: 1}).SetSort(bson.D{{Key: tenant.Field, Value: 1}, {Key: "_id", Value: 1}})
+		cur, err := c.Find(ctx, bson.M{tenant.Field: bson.M{"$in": missing}}, opts)
+		if err != nil {
+			return err
+		}
+		err = eachOrphan(ctx, cur, func(doc *orphanDoc) error {
+			return fn(&Orphan{Kind: OrphanTenant, Collection: name, TenantID: doc.TenantID, ID: doc.ID.Hex(), MissingID: doc.TenantID})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cur, err := s.Series.Unscoped().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$blog_ids"}},
+		{{Key: "$lookup", Value: bson.M{"from": s.Blogs.Unscoped().Name(), "localField": "blog_ids", "foreignField": "_id", "as": "blogs"}}},
+		{{Key: "$match", Value: bson.M{"blogs": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{tenant.Field: 1, "blog_id": "$blog_ids"}}},
+	})
+	if err != nil {
+		return err
+	}
+	return eachOrphan(ctx, cur, func(doc *orphanDoc) error {
+		return fn(&Orphan{Kind: OrphanSeriesBlog, Collection: "series", TenantID: doc.TenantID, ID: doc.ID.Hex(), MissingID: doc.BlogID.Hex()})
+	})
+}
+
+type orphanDoc struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	TenantID string             `bson:"tenant_id"`
+	BlogID   primitive.ObjectID `bson:"blog_id"`
+}
+
+// eachOrphan calls fn for every document of cur and closes it.
+func eachOrphan(ctx context.Context, cur *mongo.Cursor, fn func(doc *orphanDoc) error) error {
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var doc orphanDoc
+		if err := cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(&doc); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// codeNamespaceNotFound is returned by collStats for a collection that does not exist yet.
+const codeNamespaceNotFound = 26
+
+// Sizes reports the storage and index sizes of collStats and dbStats.
+func (s *MongoStore) Sizes(ctx context.Context) (*Sizes, error) {
+	database := s.Tenants.Database()
+	var dbStats struct {
+		StorageSize int64 `bson:"storageSize"`
+		IndexSize   int64 `bson:"indexSize"`
+	}
+	if err := database.RunCommand(ctx, bson.D{{Key: "dbStats", Value: 1}}).Decode(&dbStats); err != nil {
+		return nil, err
+	}
+	sizes := &Sizes{TotalBytes: dbStats.StorageSize + dbStats.IndexSize}
+
+	collections := []*mongo.Collection{
+		s.Tenants, s.Blogs.Unscoped(), s.Series.Unscoped(), s.AuditLog, s.Outbox, s.Webhooks.Unscoped(),
+		s.Deliveries.Unscoped(), s.Views.Unscoped(), s.ReadingLists.Unscoped(),
+	}
+	for _, c := range collections {
+		var stats struct {
+			Count          int64 `bson:"count"`
+			StorageSize    int64 `bson:"storageSize"`
+			TotalIndexSize int64 `bson:"totalIndexSize"`
+		}
+		err := database.RunCommand(ctx, bson.D{{Key: "collStats", Value: c.Name()}}).Decode(&stats)
+		var cmdErr mongo.CommandError
+		if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Code == codeNamespaceNotFound) {
+			return nil, err
+		}
+		sizes.Collections = append(sizes.Collections, CollectionSize{
+			Name:  c.Name(),
+			Items: stats.Count,
+			Bytes: stats.StorageSize + stats.TotalIndexSize,
+		})
+	}
+	return sizes, nil
+}
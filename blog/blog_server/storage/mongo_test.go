@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestTransactionsSupported(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		hello bson.M
+		ok    bool
+	}{
+		{"standalone", bson.M{"ismaster": true, "maxWireVersion": int32(9)}, false},
+		{"replica set", bson.M{"ismaster": true, "setName": "rs0"}, true},
+		{"sharded cluster", bson.M{"ismaster": true, "msg": "isdbgrid"}, true},
+	} {
+		if err := transactionsSupported(c.hello); (err == nil) != c.ok {
+			t.Errorf("%s: transactionsSupported returned %v", c.name, err)
+		}
+	}
+}
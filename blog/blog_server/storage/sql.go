@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQL drivers supported by OpenSQL.
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// SQLStore keeps the blog data in SQLite or PostgreSQL. Ids are the hex
+// ObjectIDs clients see with the Mongo backend and times are stored as unix
+// milliseconds. The schema is created when the store is opened.
+type SQLStore struct {
+	DB *sql.DB
+
+	dialect dialect
+}
+
+type dialect struct {
+	// forUpdate locks rows read before they are changed, SQLite has a single writer anyway.
+	forUpdate string
+	// numbered placeholders are used instead of ?
+	numbered bool
+	isUnique func(err error) bool
+	// month formats a unix millisecond column as a UTC month like 2006-01
+	month string
+	// databaseBytes selects the size of the database, tableBytes that of the
+	// table named by its argument with its indexes, empty when unknown
+	databaseBytes string
+	tableBytes    string
+}
+
+var dialects = map[string]dialect{
+	DriverSQLite: {
+		month:         `strftime('%%Y-%%m', %s / 1000, 'unixepoch')`,
+		databaseBytes: `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`,
+		isUnique: func(err error) bool {
+			var e sqlite3.Error
+			return errors.As(err, &e) && (e.ExtendedCode == sqlite3.ErrConstraintUnique || e.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+		},
+	},
+	DriverPostgres: {
+		forUpdate:     " FOR UPDATE",
+		numbered:      true,
+		month:         `to_char(to_timestamp(%s / 1000) AT TIME ZONE 'UTC', 'YYYY-MM')`,
+		databaseBytes: `SELECT pg_database_size(current_database())`,
+		tableBytes:    `SELECT pg_total_relation_size(?)`,
+		isUnique: func(err error) bool {
+			var e *pq.Error
+			return errors.As(err, &e) && e.Code == "23505"
+		},
+	},
+}
+
+// OpenSQL connects to the database and creates the tables that do not exist yet.
+func OpenSQL(ctx context.Context, driver, dsn string) (*SQLStore, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unknown sql driver %q, expected %s or %s", driver, DriverSQLite, DriverPostgres)
+	}
+
+	if driver == DriverSQLite && !strings.Contains(dsn, "_busy_timeout") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "_busy_timeout=5000"
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == DriverSQLite {
+		// SQLite has a single writer, one connection also keeps an in-memory database alive
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLStore{DB: db, dialect: d}
+	for _, stmt := range schema {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("could not create schema: %v", err)
+		}
+	}
+	for _, c := range columns {
+		if err := s.addColumn(ctx, c); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("could not add column %s.%s: %v", c.table, c.name, err)
+		}
+	}
+	return s, nil
+}
+
+// addColumn adds c unless the table has it, selecting it is the check both
+// SQLite and PostgreSQL understand.
+func (s *SQLStore) addColumn(ctx context.Context, c column) error {
+	if rows, err := s.DB.QueryContext(ctx, `SELECT `+c.name+` FROM `+c.table+` LIMIT 0`); err == nil {
+		rows.Close()
+	} else if _, err := s.DB.ExecContext(ctx, `ALTER TABLE `+c.table+` ADD COLUMN `+c.name+` `+c.definition); err != nil {
+		return err
+	}
+	if c.index == "" {
+		return nil
+	}
+	_, err := s.DB.ExecContext(ctx, c.index)
+	return err
+}
+
+func (s *SQLStore) Close(ctx context.Context) error {
+	return s.DB.Close()
+}
+
+type txKey struct{}
+
+// Tx runs fn in the transaction of ctx when there is one already.
+func (s *SQLStore) Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *SQLStore) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.DB
+}
+
+func (s *SQLStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.querier(ctx).ExecContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.querier(ctx).QueryContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.querier(ctx).QueryRowContext(ctx, s.rebind(query), args...)
+}
+
+// rebind replaces the ? placeholders of query with $1, $2, ... when the dialect numbers them.
+func (s *SQLStore) rebind(query string) string {
+	if !s.dialect.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// mustAffect returns ErrNotFound when res changed no rows.
+func mustAffect(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) insertError(err error) error {
+	if err != nil && s.dialect.isUnique(err) {
+		return ErrExists
+	}
+	return err
+}
+
+func noRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// placeholders returns n comma separated placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func millis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+func nullMillis(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: millis(*t), Valid: true}
+}
+
+func fromNullMillis(ms sql.NullInt64) *time.Time {
+	if !ms.Valid {
+		return nil
+	}
+	t := fromMillis(ms.Int64)
+	return &t
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
@@ -0,0 +1,520 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+// listPage is the number of rows read by one query of a listing, connections
+// are not held while the caller handles the rows.
+const listPage = 100
+
+const blogColumns = `id, tenant_id, author_id, title, content, slug, language, translations, tags, created_at, updated_at, version, moderation_state, moderation`
+
+func scanBlog(row scanner) (*model.BlogItem, error) {
+	var b model.BlogItem
+	var id, translations, tags, moderation string
+	var created, updated int64
+	err := row.Scan(&id, &b.TenantID, &b.AuthorId, &b.Title, &b.Content, &b.Slug, &b.Language, &translations, &tags, &created, &updated, &b.Version, &b.ModerationState, &moderation)
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	if b.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(translations), &b.Translations); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &b.Tags); err != nil {
+		return nil, err
+	}
+	if moderation != "" {
+		if err := json.Unmarshal([]byte(moderation), &b.Moderation); err != nil {
+			return nil, err
+		}
+	}
+	b.CreatedAt = fromMillis(created)
+	b.UpdatedAt = fromMillis(updated)
+	return &b, nil
+}
+
+func (s *SQLStore) CreateBlog(ctx context.Context, b *model.BlogItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	translations, tags, moderation, err := blogJSON(b)
+	if err != nil {
+		return err
+	}
+
+	return s.Tx(ctx, func(ctx context.Context) error {
+		_, err := s.exec(ctx, `INSERT INTO blogs (`+blogColumns+`) VALUES (`+placeholders(14)+`)`,
+			b.ID.Hex(), t, b.AuthorId, b.Title, b.Content, b.Slug, b.Language, translations, tags, millis(b.CreatedAt), millis(b.UpdatedAt), b.Version, b.ModerationState, moderation)
+		if err != nil {
+			return s.insertError(err)
+		}
+		return s.insertTags(ctx, t, b)
+	})
+}
+
+// blogJSON encodes the translations, tags and moderation of b, the
+// moderation is empty when b has none.
+func blogJSON(b *model.BlogItem) (string, string, string, error) {
+	translations, err := json.Marshal(b.Translations)
+	if err != nil {
+		return "", "", "", err
+	}
+	tags, err := json.Marshal(b.Tags)
+	if err != nil {
+		return "", "", "", err
+	}
+	if b.Moderation == nil {
+		return string(translations), string(tags), "", nil
+	}
+	moderation, err := json.Marshal(b.Moderation)
+	if err != nil {
+		return "", "", "", err
+	}
+	return string(translations), string(tags), string(moderation), nil
+}
+
+func (s *SQLStore) insertTags(ctx context.Context, tenantID string, b *model.BlogItem) error {
+	for _, tag := range b.Tags {
+		_, err := s.exec(ctx, `INSERT INTO blog_tags (blog_id, tenant_id, tag) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`, b.ID.Hex(), tenantID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	return s.getBlog(ctx, id, "")
+}
+
+// getBlog reads the blog with lock appended to the query.
+func (s *SQLStore) getBlog(ctx context.Context, id primitive.ObjectID, lock string) (*model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scanBlog(s.queryRow(ctx, `SELECT `+blogColumns+` FROM blogs WHERE tenant_id = ? AND id = ?`+lock, t, id.Hex()))
+}
+
+func (s *SQLStore) GetBlogs(ctx context.Context, ids []primitive.ObjectID) ([]model.BlogItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{t}
+	for _, id := range ids {
+		args = append(args, id.Hex())
+	}
+	rows, err := s.query(ctx, `SELECT `+blogColumns+` FROM blogs WHERE tenant_id = ? AND id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.BlogItem
+	for rows.Next() {
+		b, err := scanBlog(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *b)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) UpdateBlog(ctx context.Context, id primitive.ObjectID, version int64, fn func(b *model.BlogItem) error) (*model.BlogItem, *model.BlogItem, error) {
+	var before, after *model.BlogItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getBlog(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+		if version != 0 && before.Version != version {
+			return ErrVersionConflict
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = before.TenantID
+		after.Version = before.Version + 1
+
+		translations, tags, moderation, err := blogJSON(after)
+		if err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `UPDATE blogs SET author_id = ?, title = ?, content = ?, slug = ?, language = ?, translations = ?, tags = ?, created_at = ?, updated_at = ?, version = ?, moderation_state = ?, moderation = ? WHERE tenant_id = ? AND id = ?`,
+			after.AuthorId, after.Title, after.Content, after.Slug, after.Language, translations, tags, millis(after.CreatedAt), millis(after.UpdatedAt), after.Version, after.ModerationState, moderation, after.TenantID, id.Hex())
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.exec(ctx, `DELETE FROM blog_tags WHERE blog_id = ?`, id.Hex()); err != nil {
+			return err
+		}
+		return s.insertTags(ctx, after.TenantID, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *SQLStore) DeleteBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error) {
+	var before *model.BlogItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getBlog(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+
+		for _, query := range []string{
+			`DELETE FROM blogs WHERE id = ?`,
+			`DELETE FROM blog_tags WHERE blog_id = ?`,
+			`DELETE FROM series_blogs WHERE blog_id = ?`,
+		} {
+			if _, err := s.exec(ctx, query, id.Hex()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+func (s *SQLStore) CountBlogs(ctx context.Context) (int64, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = s.queryRow(ctx, `SELECT COUNT(*) FROM blogs WHERE tenant_id = ?`, t).Scan(&n)
+	return n, err
+}
+
+// ListBlogs reads the blogs in pages ordered by id, which orders them by
+// creation like the ObjectIDs of the Mongo backend.
+func (s *SQLStore) ListBlogs(ctx context.Context, q BlogQuery, fn func(b *model.BlogItem) error) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	where := []string{"tenant_id = ?", "moderation_state = ?"}
+	args := []interface{}{t, q.Moderation}
+	if q.AuthorID != "" {
+		where = append(where, "author_id = ?")
+		args = append(args, q.AuthorID)
+	}
+	if q.Tag != "" {
+		where = append(where, "id IN (SELECT blog_id FROM blog_tags WHERE tenant_id = ? AND tag = ?)")
+		args = append(args, t, q.Tag)
+	}
+	if words := textWords(q.Text); len(words) > 0 {
+		// words are letters and digits, they hold no wildcards of LIKE
+		var or []string
+		for _, w := range words {
+			or = append(or, "LOWER(title) LIKE ?", "LOWER(content) LIKE ?")
+			args = append(args, "%"+w+"%", "%"+w+"%")
+		}
+		where = append(where, "("+strings.Join(or, " OR ")+")")
+	}
+	after, order := "id > ?", "id"
+	if q.Newest {
+		after, order = "id < ?", "id DESC"
+	}
+
+	page := listPage
+	if q.BatchSize > 0 {
+		page = q.BatchSize
+	}
+	last := ""
+	listed := 0
+	for {
+		n := page
+		if q.Limit > 0 && q.Limit-listed < n {
+			n = q.Limit - listed
+		}
+		if n <= 0 {
+			return nil
+		}
+
+		conds, pageArgs := where, args
+		if last != "" {
+			conds = append(append([]string(nil), where...), after)
+			pageArgs = append(append([]interface{}(nil), args...), last)
+		}
+		items, err := s.listBlogPage(ctx, `SELECT `+blogColumns+` FROM blogs WHERE `+strings.Join(conds, " AND ")+` ORDER BY `+order+` LIMIT ?`, append(pageArgs, n)...)
+		if err != nil {
+			return err
+		}
+
+		for i := range items {
+			if err := fn(&items[i]); err != nil {
+				return err
+			}
+		}
+		listed += len(items)
+		if len(items) < n {
+			return nil
+		}
+		last = items[len(items)-1].ID.Hex()
+	}
+}
+
+func (s *SQLStore) listBlogPage(ctx context.Context, query string, args ...interface{}) ([]model.BlogItem, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.BlogItem
+	for rows.Next() {
+		b, err := scanBlog(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *b)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) CreateSeries(ctx context.Context, series *model.SeriesItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.Tx(ctx, func(ctx context.Context) error {
+		_, err := s.exec(ctx, `INSERT INTO series (id, tenant_id, title, description) VALUES (?, ?, ?, ?)`,
+			series.ID.Hex(), t, series.Title, series.Description)
+		if err != nil {
+			return s.insertError(err)
+		}
+		return s.insertSeriesBlogs(ctx, t, series)
+	})
+}
+
+func (s *SQLStore) insertSeriesBlogs(ctx context.Context, tenantID string, series *model.SeriesItem) error {
+	for i, id := range series.BlogIds {
+		_, err := s.exec(ctx, `INSERT INTO series_blogs (blog_id, series_id, tenant_id, position) VALUES (?, ?, ?, ?)`,
+			id.Hex(), series.ID.Hex(), tenantID, i)
+		if err != nil {
+			return s.insertError(err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) GetSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	return s.getSeries(ctx, id, "")
+}
+
+func (s *SQLStore) getSeries(ctx context.Context, id primitive.ObjectID, lock string) (*model.SeriesItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	series := model.SeriesItem{ID: id}
+	err = s.queryRow(ctx, `SELECT tenant_id, title, description FROM series WHERE tenant_id = ? AND id = ?`+lock, t, id.Hex()).
+		Scan(&series.TenantID, &series.Title, &series.Description)
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	rows, err := s.query(ctx, `SELECT blog_id FROM series_blogs WHERE series_id = ? ORDER BY position`, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var blogID string
+		if err := rows.Scan(&blogID); err != nil {
+			return nil, err
+		}
+		oid, err := primitive.ObjectIDFromHex(blogID)
+		if err != nil {
+			return nil, err
+		}
+		series.BlogIds = append(series.BlogIds, oid)
+	}
+	return &series, rows.Err()
+}
+
+func (s *SQLStore) SeriesOfBlog(ctx context.Context, blogID primitive.ObjectID) (*model.SeriesItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var id string
+	err = s.queryRow(ctx, `SELECT series_id FROM series_blogs WHERE tenant_id = ? AND blog_id = ?`, t, blogID.Hex()).Scan(&id)
+	if err != nil {
+		return nil, noRows(err)
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(ctx, oid)
+}
+
+func (s *SQLStore) UpdateSeries(ctx context.Context, id primitive.ObjectID, fn func(s *model.SeriesItem) error) (*model.SeriesItem, *model.SeriesItem, error) {
+	var before, after *model.SeriesItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getSeries(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = before.TenantID
+
+		_, err = s.exec(ctx, `UPDATE series SET title = ?, description = ? WHERE tenant_id = ? AND id = ?`,
+			after.Title, after.Description, after.TenantID, id.Hex())
+		if err != nil {
+			return err
+		}
+		if _, err := s.exec(ctx, `DELETE FROM series_blogs WHERE series_id = ?`, id.Hex()); err != nil {
+			return err
+		}
+		return s.insertSeriesBlogs(ctx, after.TenantID, after)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *SQLStore) DeleteSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error) {
+	var before *model.SeriesItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getSeries(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+		if _, err := s.exec(ctx, `DELETE FROM series WHERE id = ?`, id.Hex()); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `DELETE FROM series_blogs WHERE series_id = ?`, id.Hex())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// BlogStats runs a query per statistic, they may see different changes made meanwhile.
+func (s *SQLStore) BlogStats(ctx context.Context, q StatsQuery) (*BlogStats, error) {
+	stats := &BlogStats{}
+	where, args := statsWhere(q, "")
+	err := s.queryRow(ctx, `SELECT COUNT(*), COALESCE(SUM(LENGTH(content)), 0) FROM blogs WHERE `+where, args...).
+		Scan(&stats.Posts, &stats.ContentLength)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.scanStats(ctx, func(rows *sql.Rows) error {
+		var a AuthorPosts
+		if err := rows.Scan(&a.TenantID, &a.AuthorID, &a.Posts); err != nil {
+			return err
+		}
+		stats.Authors = append(stats.Authors, a)
+		return nil
+	}, `SELECT tenant_id, author_id, COUNT(*) FROM blogs WHERE `+where+
+		` GROUP BY tenant_id, author_id ORDER BY COUNT(*) DESC, tenant_id, author_id LIMIT ?`, append(args, StatsRows)...)
+	if err != nil {
+		return nil, err
+	}
+
+	month := fmt.Sprintf(s.dialect.month, "created_at")
+	err = s.scanStats(ctx, func(rows *sql.Rows) error {
+		var m MonthPosts
+		if err := rows.Scan(&m.Month, &m.Posts); err != nil {
+			return err
+		}
+		stats.Months = append(stats.Months, m)
+		return nil
+	}, `SELECT `+month+` AS month, COUNT(*) FROM blogs WHERE `+where+` GROUP BY month ORDER BY month`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args = statsWhere(q, "b.")
+	err = s.scanStats(ctx, func(rows *sql.Rows) error {
+		var t TagPosts
+		if err := rows.Scan(&t.Tag, &t.Posts); err != nil {
+			return err
+		}
+		stats.Tags = append(stats.Tags, t)
+		return nil
+	}, `SELECT t.tag, COUNT(*) FROM blog_tags t JOIN blogs b ON b.id = t.blog_id WHERE `+where+
+		` GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag LIMIT ?`, append(args, StatsRows)...)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// statsWhere returns the conditions of q on the blogs table, whose columns start with prefix.
+func statsWhere(q StatsQuery, prefix string) (string, []interface{}) {
+	where := []string{prefix + "moderation_state = ''"}
+	var args []interface{}
+	if q.TenantID != "" {
+		where = append(where, prefix+"tenant_id = ?")
+		args = append(args, q.TenantID)
+	}
+	if !q.From.IsZero() {
+		where = append(where, prefix+"created_at >= ?")
+		args = append(args, millis(q.From))
+	}
+	if !q.To.IsZero() {
+		where = append(where, prefix+"created_at < ?")
+		args = append(args, millis(q.To))
+	}
+	return strings.Join(where, " AND "), args
+}
+
+func (s *SQLStore) scanStats(ctx context.Context, fn func(rows *sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// sqlTables are the tables of the schema.
+var sqlTables = []string{
+	"tenants", "blogs", "blog_tags", "series", "series_blogs", "audit_log", "outbox",
+	"webhooks", "webhook_deliveries", "views", "reading_lists",
+}
+
+// Orphans collects the orphans before calling fn, SQLite has a single connection.
+func (s *SQLStore) Orphans(ctx context.Context, fn func(o *Orphan) error) error {
+	var orphans []Orphan
+	for _, table := range []string{"blogs", "series", "reading_lists", "webhooks"} {
+		err := s.scanStats(ctx, func(rows *sql.Rows) error {
+			o := Orphan{Kind: OrphanTenant, Collection: table}
+			if err := rows.Scan(&o.TenantID, &o.ID); err != nil {
+				return err
+			}
+			o.MissingID = o.TenantID
+			orphans = append(orphans, o)
+			return nil
+		}, `SELECT tenant_id, id FROM `+table+` WHERE tenant_id NOT IN (SELECT id FROM tenants) ORDER BY tenant_id, id`)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.scanStats(ctx, func(rows *sql.Rows) error {
+		o := Orphan{Kind: OrphanSeriesBlog, Collection: "series"}
+		if err := rows.Scan(&o.TenantID, &o.ID, &o.MissingID); err != nil {
+			return err
+		}
+		orphans = append(orphans, o)
+		return nil
+	}, `SELECT sb.tenant_id, sb.series_id, sb.blog_id FROM series_blogs sb LEFT JOIN blogs b ON b.id = sb.blog_id
+		WHERE b.id IS NULL ORDER BY sb.series_id, sb.position`)
+	if err != nil {
+		return err
+	}
+
+	for i := range orphans {
+		if err := fn(&orphans[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sizes counts the rows of every table, SQLite does not tell the size of a table.
+func (s *SQLStore) Sizes(ctx context.Context) (*Sizes, error) {
+	sizes := &Sizes{}
+	if err := s.queryRow(ctx, s.dialect.databaseBytes).Scan(&sizes.TotalBytes); err != nil {
+		return nil, err
+	}
+	for _, table := range sqlTables {
+		size := CollectionSize{Name: table}
+		if err := s.queryRow(ctx, `SELECT COUNT(*) FROM `+table).Scan(&size.Items); err != nil {
+			return nil, err
+		}
+		if s.dialect.tableBytes != "" {
+			if err := s.queryRow(ctx, s.dialect.tableBytes, table).Scan(&size.Bytes); err != nil {
+				return nil, err
+			}
+		}
+		sizes.Collections = append(sizes.Collections, size)
+	}
+	return sizes, nil
+}
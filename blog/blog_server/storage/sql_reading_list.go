@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const readingListColumns = `id, tenant_id, reader_id, name, entries, share_token_hash, created_at, updated_at`
+
+func scanReadingList(row scanner) (*model.ReadingListItem, error) {
+	var l model.ReadingListItem
+	var id, entries string
+	var created, updated int64
+	if err := row.Scan(&id, &l.TenantID, &l.ReaderID, &l.Name, &entries, &l.ShareTokenHash, &created, &updated); err != nil {
+		return nil, noRows(err)
+	}
+	var err error
+	if l.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(entries), &l.Entries); err != nil {
+		return nil, err
+	}
+	l.CreatedAt = fromMillis(created)
+	l.UpdatedAt = fromMillis(updated)
+	return &l, nil
+}
+
+func (s *SQLStore) CreateReadingList(ctx context.Context, l *model.ReadingListItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entries, err := json.Marshal(l.Entries)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.exec(ctx, `INSERT INTO reading_lists (`+readingListColumns+`) VALUES (`+placeholders(8)+`)`,
+		l.ID.Hex(), t, l.ReaderID, l.Name, string(entries), l.ShareTokenHash, millis(l.CreatedAt), millis(l.UpdatedAt))
+	return s.insertError(err)
+}
+
+func (s *SQLStore) GetReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	return s.getReadingList(ctx, id, "")
+}
+
+func (s *SQLStore) getReadingList(ctx context.Context, id primitive.ObjectID, lock string) (*model.ReadingListItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scanReadingList(s.queryRow(ctx, `SELECT `+readingListColumns+` FROM reading_lists WHERE tenant_id = ? AND id = ?`+lock, t, id.Hex()))
+}
+
+func (s *SQLStore) ReadingListsOf(ctx context.Context, readerID string) ([]model.ReadingListItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, `SELECT `+readingListColumns+` FROM reading_lists WHERE tenant_id = ? AND reader_id = ? ORDER BY id`, t, readerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.ReadingListItem
+	for rows.Next() {
+		l, err := scanReadingList(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *l)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) UpdateReadingList(ctx context.Context, id primitive.ObjectID, fn func(l *model.ReadingListItem) error) (*model.ReadingListItem, *model.ReadingListItem, error) {
+	var before, after *model.ReadingListItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getReadingList(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = before.TenantID
+		entries, err := json.Marshal(after.Entries)
+		if err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `UPDATE reading_lists SET reader_id = ?, name = ?, entries = ?, share_token_hash = ?, created_at = ?, updated_at = ? WHERE tenant_id = ? AND id = ?`,
+			after.ReaderID, after.Name, string(entries), after.ShareTokenHash, millis(after.CreatedAt), millis(after.UpdatedAt), after.TenantID, id.Hex())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *SQLStore) DeleteReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error) {
+	var before *model.ReadingListItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getReadingList(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `DELETE FROM reading_lists WHERE id = ?`, id.Hex())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
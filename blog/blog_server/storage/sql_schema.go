@@ -0,0 +1,147 @@
+package storage
+
+// schema is valid in SQLite and PostgreSQL. Ids are hex ObjectIDs except for
+// tenants and views, times are unix milliseconds. Tags and the blogs of a
+// series have their own tables, so they can be queried.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS tenants (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		state TEXT NOT NULL,
+		max_posts BIGINT NOT NULL,
+		api_key_hash TEXT NOT NULL,
+		created_at BIGINT NOT NULL
+	)`,
+
+	`CREATE TABLE IF NOT EXISTS blogs (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		author_id TEXT NOT NULL,
+		title TEXT NOT NULL,
+		content TEXT NOT NULL,
+		slug TEXT NOT NULL,
+		language TEXT NOT NULL,
+		translations TEXT NOT NULL,
+		tags TEXT NOT NULL,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		version BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS blogs_tenant_id_id ON blogs (tenant_id, id)`,
+	`CREATE INDEX IF NOT EXISTS blogs_tenant_id_author_id ON blogs (tenant_id, author_id, id)`,
+	`CREATE TABLE IF NOT EXISTS blog_tags (
+		blog_id TEXT NOT NULL,
+		tenant_id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (blog_id, tag)
+	)`,
+	`CREATE INDEX IF NOT EXISTS blog_tags_tenant_id_tag ON blog_tags (tenant_id, tag, blog_id)`,
+
+	`CREATE TABLE IF NOT EXISTS series (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL
+	)`,
+	// a blog belongs to at most one series
+	`CREATE TABLE IF NOT EXISTS series_blogs (
+		blog_id TEXT PRIMARY KEY,
+		series_id TEXT NOT NULL,
+		tenant_id TEXT NOT NULL,
+		position INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS series_blogs_series_id ON series_blogs (series_id, position)`,
+
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id TEXT PRIMARY KEY,
+		principal TEXT NOT NULL,
+		tenant_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		blog_id TEXT NOT NULL,
+		series_id TEXT NOT NULL,
+		before_hash TEXT NOT NULL,
+		after_hash TEXT NOT NULL,
+		peer TEXT NOT NULL,
+		time BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_time ON audit_log (time, id)`,
+
+	`CREATE TABLE IF NOT EXISTS outbox (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		blog_id TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at BIGINT NOT NULL,
+		dispatched_at BIGINT
+	)`,
+	`CREATE INDEX IF NOT EXISTS outbox_dispatched_at ON outbox (dispatched_at, id)`,
+
+	`CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		events TEXT NOT NULL,
+		description TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhooks_tenant_id ON webhooks (tenant_id)`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		webhook_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		blog_id TEXT NOT NULL,
+		state TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL,
+		next_attempt_at BIGINT NOT NULL,
+		created_at BIGINT NOT NULL,
+		delivered_at BIGINT,
+		UNIQUE (event_id, webhook_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_state_next_attempt_at ON webhook_deliveries (state, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_tenant_id ON webhook_deliveries (tenant_id, id)`,
+
+	`CREATE TABLE IF NOT EXISTS views (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		blog_id TEXT NOT NULL,
+		viewer TEXT NOT NULL,
+		time BIGINT NOT NULL,
+		day TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS views_tenant_id_blog_id_time ON views (tenant_id, blog_id, time)`,
+	`CREATE INDEX IF NOT EXISTS views_tenant_id_time ON views (tenant_id, time)`,
+
+	// entries are only read with their list
+	`CREATE TABLE IF NOT EXISTS reading_lists (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		reader_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		entries TEXT NOT NULL,
+		share_token_hash TEXT NOT NULL,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS reading_lists_tenant_id_reader_id ON reading_lists (tenant_id, reader_id, id)`,
+}
+
+// column was added to a table of the schema later, it is added to databases
+// created before when they are opened.
+type column struct {
+	table, name, definition string
+	// index is created once the column exists
+	index string
+}
+
+var columns = []column{
+	{
+		table: "blogs", name: "moderation_state", definition: "TEXT NOT NULL DEFAULT ''",
+		index: `CREATE INDEX IF NOT EXISTS blogs_tenant_id_moderation_state ON blogs (tenant_id, moderation_state, id)`,
+	},
+	{table: "blogs", name: "moderation", definition: "TEXT NOT NULL DEFAULT ''"},
+}
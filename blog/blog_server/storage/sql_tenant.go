@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+const tenantColumns = `id, name, state, max_posts, api_key_hash, created_at`
+
+func scanTenant(row scanner) (*model.TenantItem, error) {
+	var t model.TenantItem
+	var created int64
+	if err := row.Scan(&t.ID, &t.Name, &t.State, &t.Quota.MaxPosts, &t.APIKeyHash, &created); err != nil {
+		return nil, noRows(err)
+	}
+	t.CreatedAt = fromMillis(created)
+	return &t, nil
+}
+
+func (s *SQLStore) CreateTenant(ctx context.Context, t *model.TenantItem) error {
+	_, err := s.exec(ctx, `INSERT INTO tenants (`+tenantColumns+`) VALUES (`+placeholders(6)+`)`,
+		t.ID, t.Name, t.State, t.Quota.MaxPosts, t.APIKeyHash, millis(t.CreatedAt))
+	return s.insertError(err)
+}
+
+func (s *SQLStore) GetTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	return scanTenant(s.queryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id))
+}
+
+func (s *SQLStore) ListTenants(ctx context.Context) ([]model.TenantItem, error) {
+	rows, err := s.query(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.TenantItem
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *t)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) UpdateTenant(ctx context.Context, id string, fn func(t *model.TenantItem) error) (*model.TenantItem, *model.TenantItem, error) {
+	var before, after *model.TenantItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		before, err = scanTenant(s.queryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = ?`+s.dialect.forUpdate, id))
+		if err != nil {
+			return err
+		}
+
+		changed := *before
+		after = &changed
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		_, err = s.exec(ctx, `UPDATE tenants SET name = ?, state = ?, max_posts = ?, api_key_hash = ?, created_at = ? WHERE id = ?`,
+			after.Name, after.State, after.Quota.MaxPosts, after.APIKeyHash, millis(after.CreatedAt), id)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// LockTenant writes the row of the tenant, which holds its lock in Postgres
+// and the write lock of the database in SQLite until the Tx ends.
+func (s *SQLStore) LockTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	res, err := s.exec(ctx, `UPDATE tenants SET id = id WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	return s.GetTenant(ctx, id)
+}
+
+// DeleteTenant deletes the tenant and its data in one transaction.
+func (s *SQLStore) DeleteTenant(ctx context.Context, id string) (*model.TenantItem, error) {
+	var before *model.TenantItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.GetTenant(ctx, id); err != nil {
+			return err
+		}
+
+		tables := []string{"tenants", "blogs", "blog_tags", "series", "series_blogs", "views", "webhooks", "webhook_deliveries", "reading_lists", "outbox"}
+		for _, table := range tables {
+			column := "tenant_id"
+			if table == "tenants" {
+				column = "id"
+			}
+			if _, err := s.exec(ctx, `DELETE FROM `+table+` WHERE `+column+` = ?`, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+const auditColumns = `id, principal, tenant_id, method, blog_id, series_id, before_hash, after_hash, peer, time`
+
+func (s *SQLStore) AppendAudit(ctx context.Context, rec *model.AuditRecord) error {
+	if rec.ID.IsZero() {
+		rec.ID = primitive.NewObjectID()
+	}
+	_, err := s.exec(ctx, `INSERT INTO audit_log (`+auditColumns+`) VALUES (`+placeholders(10)+`)`,
+		rec.ID.Hex(), rec.Principal, rec.TenantID, rec.Method, rec.BlogID, rec.SeriesID, rec.BeforeHash, rec.AfterHash, rec.Peer, millis(rec.Time))
+	return err
+}
+
+// ListAudit reads the records in pages, ordered by time and id.
+func (s *SQLStore) ListAudit(ctx context.Context, f AuditFilter, fn func(rec *model.AuditRecord) error) error {
+	var where []string
+	var args []interface{}
+	if f.Principal != "" {
+		where = append(where, "principal = ?")
+		args = append(args, f.Principal)
+	}
+	if f.TenantID != "" {
+		where = append(where, "tenant_id = ?")
+		args = append(args, f.TenantID)
+	}
+	if f.BlogID != "" {
+		where = append(where, "blog_id = ?")
+		args = append(args, f.BlogID)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, millis(f.From))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "time < ?")
+		args = append(args, millis(f.To))
+	}
+
+	var last *model.AuditRecord
+	for {
+		conds, pageArgs := where, args
+		if last != nil {
+			conds = append(append([]string(nil), where...), "(time > ? OR (time = ? AND id > ?))")
+			t := millis(last.Time)
+			pageArgs = append(append([]interface{}(nil), args...), t, t, last.ID.Hex())
+		}
+		query := `SELECT ` + auditColumns + ` FROM audit_log`
+		if len(conds) > 0 {
+			query += ` WHERE ` + strings.Join(conds, " AND ")
+		}
+		records, err := s.listAuditPage(ctx, query+` ORDER BY time, id LIMIT ?`, append(pageArgs, listPage)...)
+		if err != nil {
+			return err
+		}
+
+		for i := range records {
+			if err := fn(&records[i]); err != nil {
+				return err
+			}
+		}
+		if len(records) < listPage {
+			return nil
+		}
+		last = &records[len(records)-1]
+	}
+}
+
+func (s *SQLStore) listAuditPage(ctx context.Context, query string, args ...interface{}) ([]model.AuditRecord, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []model.AuditRecord
+	for rows.Next() {
+		var rec model.AuditRecord
+		var id string
+		var t int64
+		err := rows.Scan(&id, &rec.Principal, &rec.TenantID, &rec.Method, &rec.BlogID, &rec.SeriesID, &rec.BeforeHash, &rec.AfterHash, &rec.Peer, &t)
+		if err != nil {
+			return nil, err
+		}
+		if rec.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		rec.Time = fromMillis(t)
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+func (s *SQLStore) InsertViews(ctx context.Context, events []model.ViewEvent) error {
+	return s.Tx(ctx, func(ctx context.Context) error {
+		for _, e := range events {
+			_, err := s.exec(ctx, `INSERT INTO views (id, tenant_id, blog_id, viewer, time, day) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+				e.ID, e.TenantID, e.BlogID.Hex(), e.Viewer, millis(e.Time), e.Time.UTC().Format("2006-01-02"))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLStore) DailyViews(ctx context.Context, blogID primitive.ObjectID, from, to time.Time) ([]DailyViews, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, `SELECT day, COUNT(*), COUNT(DISTINCT viewer) FROM views
+		WHERE tenant_id = ? AND blog_id = ? AND time >= ? AND time < ?
+		GROUP BY day ORDER BY day`, t, blogID.Hex(), millis(from), millis(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []DailyViews
+	for rows.Next() {
+		var d DailyViews
+		if err := rows.Scan(&d.Day, &d.Total, &d.Unique); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+func (s *SQLStore) TopViews(ctx context.Context, from, to time.Time, limit int) ([]BlogViews, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, `SELECT blog_id, COUNT(*) AS total, COUNT(DISTINCT viewer) FROM views
+		WHERE tenant_id = ? AND time >= ? AND time < ?
+		GROUP BY blog_id ORDER BY total DESC, blog_id LIMIT ?`, t, millis(from), millis(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blogs []BlogViews
+	for rows.Next() {
+		var b BlogViews
+		var id string
+		if err := rows.Scan(&id, &b.Total, &b.Unique); err != nil {
+			return nil, err
+		}
+		if b.BlogID, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		blogs = append(blogs, b)
+	}
+	return blogs, rows.Err()
+}
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+// OutboxRetention is how long dispatched events are kept, like the TTL index of the Mongo backend.
+const OutboxRetention = 7 * 24 * time.Hour
+
+const eventColumns = `id, tenant_id, type, blog_id, payload, created_at, dispatched_at`
+
+func scanEvent(row scanner) (*model.OutboxEvent, error) {
+	var e model.OutboxEvent
+	var id string
+	var created int64
+	var dispatched sql.NullInt64
+	if err := row.Scan(&id, &e.TenantID, &e.Type, &e.BlogID, &e.Payload, &created, &dispatched); err != nil {
+		return nil, noRows(err)
+	}
+	var err error
+	if e.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	e.CreatedAt = fromMillis(created)
+	e.DispatchedAt = fromNullMillis(dispatched)
+	return &e, nil
+}
+
+func (s *SQLStore) AddEvent(ctx context.Context, e *model.OutboxEvent) error {
+	_, err := s.exec(ctx, `INSERT INTO outbox (`+eventColumns+`) VALUES (`+placeholders(7)+`)`,
+		e.ID.Hex(), e.TenantID, e.Type, e.BlogID, e.Payload, millis(e.CreatedAt), nullMillis(e.DispatchedAt))
+	return s.insertError(err)
+}
+
+func (s *SQLStore) PendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	rows, err := s.query(ctx, `SELECT `+eventColumns+` FROM outbox WHERE dispatched_at IS NULL ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.OutboxEvent
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLStore) GetEvent(ctx context.Context, id primitive.ObjectID) (*model.OutboxEvent, error) {
+	return scanEvent(s.queryRow(ctx, `SELECT `+eventColumns+` FROM outbox WHERE id = ?`, id.Hex()))
+}
+
+// DispatchEvent also deletes events dispatched longer than OutboxRetention ago.
+func (s *SQLStore) DispatchEvent(ctx context.Context, eventID primitive.ObjectID, deliveries []model.DeliveryItem) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return s.Tx(ctx, func(ctx context.Context) error {
+		for _, d := range deliveries {
+			_, err := s.exec(ctx, `INSERT INTO webhook_deliveries (`+deliveryColumns+`) VALUES (`+placeholders(12)+`) ON CONFLICT DO NOTHING`,
+				primitive.NewObjectID().Hex(), d.TenantID, d.EventID.Hex(), d.WebhookID.Hex(), d.EventType, d.BlogID, d.State,
+				d.Attempts, d.LastError, millis(d.NextAttemptAt), millis(d.CreatedAt), nullMillis(d.DeliveredAt))
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := s.exec(ctx, `UPDATE outbox SET dispatched_at = ? WHERE id = ?`, millis(now), eventID.Hex()); err != nil {
+			return err
+		}
+		_, err := s.exec(ctx, `DELETE FROM outbox WHERE dispatched_at < ?`, millis(now.Add(-OutboxRetention)))
+		return err
+	})
+}
+
+// ClaimDelivery moves the lease only when no other dispatcher claimed the
+// delivery since it was read, otherwise it tries the next one.
+func (s *SQLStore) ClaimDelivery(ctx context.Context, now, leaseUntil time.Time) (*model.DeliveryItem, error) {
+	for {
+		var id string
+		var next int64
+		err := s.queryRow(ctx, `SELECT id, next_attempt_at FROM webhook_deliveries WHERE state = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT 1`,
+			model.DeliveryPending, millis(now)).Scan(&id, &next)
+		if err != nil {
+			return nil, noRows(err)
+		}
+
+		res, err := s.exec(ctx, `UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id = ? AND state = ? AND next_attempt_at = ?`,
+			millis(leaseUntil), id, model.DeliveryPending, next)
+		if err != nil {
+			return nil, err
+		}
+		if err := mustAffect(res); err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return scanDelivery(s.queryRow(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id))
+	}
+}
+
+const webhookColumns = `id, tenant_id, url, events, description, secret, created_at`
+
+func scanWebhook(row scanner) (*model.WebhookItem, error) {
+	var w model.WebhookItem
+	var id, events string
+	var created int64
+	if err := row.Scan(&id, &w.TenantID, &w.URL, &events, &w.Description, &w.Secret, &created); err != nil {
+		return nil, noRows(err)
+	}
+	var err error
+	if w.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(events), &w.Events); err != nil {
+		return nil, err
+	}
+	w.CreatedAt = fromMillis(created)
+	return &w, nil
+}
+
+func (s *SQLStore) CreateWebhook(ctx context.Context, w *model.WebhookItem) error {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.exec(ctx, `INSERT INTO webhooks (`+webhookColumns+`) VALUES (`+placeholders(7)+`)`,
+		w.ID.Hex(), t, w.URL, string(events), w.Description, w.Secret, millis(w.CreatedAt))
+	return s.insertError(err)
+}
+
+func (s *SQLStore) GetWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error) {
+	return s.getWebhook(ctx, id, "")
+}
+
+func (s *SQLStore) getWebhook(ctx context.Context, id primitive.ObjectID, lock string) (*model.WebhookItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhook(s.queryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE tenant_id = ? AND id = ?`+lock, t, id.Hex()))
+}
+
+func (s *SQLStore) ListWebhooks(ctx context.Context) ([]model.WebhookItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE tenant_id = ? ORDER BY id`, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.WebhookItem
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *w)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) UpdateWebhook(ctx context.Context, id primitive.ObjectID, fn func(w *model.WebhookItem) error) (*model.WebhookItem, *model.WebhookItem, error) {
+	var before, after *model.WebhookItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getWebhook(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+
+		after = before.Clone()
+		if err := fn(after); err != nil {
+			return err
+		}
+		after.ID = id
+		after.TenantID = before.TenantID
+		events, err := json.Marshal(after.Events)
+		if err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `UPDATE webhooks SET url = ?, events = ?, description = ?, secret = ?, created_at = ? WHERE tenant_id = ? AND id = ?`,
+			after.URL, string(events), after.Description, after.Secret, millis(after.CreatedAt), after.TenantID, id.Hex())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+func (s *SQLStore) DeleteWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error) {
+	var before *model.WebhookItem
+	err := s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.getWebhook(ctx, id, s.dialect.forUpdate); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `DELETE FROM webhooks WHERE id = ?`, id.Hex())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+const deliveryColumns = `id, tenant_id, event_id, webhook_id, event_type, blog_id, state, attempts, last_error, next_attempt_at, created_at, delivered_at`
+
+func scanDelivery(row scanner) (*model.DeliveryItem, error) {
+	var d model.DeliveryItem
+	var id, eventID, webhookID string
+	var next, created int64
+	var delivered sql.NullInt64
+	err := row.Scan(&id, &d.TenantID, &eventID, &webhookID, &d.EventType, &d.BlogID, &d.State, &d.Attempts, &d.LastError, &next, &created, &delivered)
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	if d.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if d.EventID, err = primitive.ObjectIDFromHex(eventID); err != nil {
+		return nil, err
+	}
+	if d.WebhookID, err = primitive.ObjectIDFromHex(webhookID); err != nil {
+		return nil, err
+	}
+	d.NextAttemptAt = fromMillis(next)
+	d.CreatedAt = fromMillis(created)
+	d.DeliveredAt = fromNullMillis(delivered)
+	return &d, nil
+}
+
+func (s *SQLStore) ListDeliveries(ctx context.Context, q DeliveryQuery) ([]model.DeliveryItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where := []string{"tenant_id = ?"}
+	args := []interface{}{t}
+	if !q.WebhookID.IsZero() {
+		where = append(where, "webhook_id = ?")
+		args = append(args, q.WebhookID.Hex())
+	}
+	if q.State != "" {
+		where = append(where, "state = ?")
+		args = append(args, q.State)
+	}
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE ` + strings.Join(where, " AND ") + ` ORDER BY id DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.DeliveryItem
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *d)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) UpdateDelivery(ctx context.Context, id primitive.ObjectID, fn func(d *model.DeliveryItem) error) (*model.DeliveryItem, error) {
+	t, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var d *model.DeliveryItem
+	err = s.Tx(ctx, func(ctx context.Context) error {
+		var err error
+		d, err = scanDelivery(s.queryRow(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE tenant_id = ? AND id = ?`+s.dialect.forUpdate, t, id.Hex()))
+		if err != nil {
+			return err
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, `UPDATE webhook_deliveries SET state = ?, attempts = ?, last_error = ?, next_attempt_at = ?, delivered_at = ? WHERE id = ?`,
+			d.State, d.Attempts, d.LastError, millis(d.NextAttemptAt), nullMillis(d.DeliveredAt), id.Hex())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.ID = id
+	return d, nil
+}
@@ -0,0 +1,303 @@
+// Package storage defines what the blog server stores, independent of the
+// database. Methods of blogs, series, reading lists, webhooks and view
+// statistics are scoped to the tenant of the context, see package tenant, a
+// backend must never read or change the data of another tenant in them.
+// Methods used by admins and the webhook dispatcher work across tenants and
+// say so.
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/blog/blog_server/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrNotFound is returned when the requested item does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrExists is returned when an item with the same id exists already.
+	ErrExists = errors.New("already exists")
+	// ErrVersionConflict is returned by UpdateBlog when the blog has another version.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrNotSupported is returned by optional methods the backend does not have.
+	ErrNotSupported = errors.New("not supported by the storage backend")
+)
+
+// Store is implemented by every storage backend.
+type Store interface {
+	BlogStore
+	SeriesStore
+	TenantStore
+	AuditStore
+	OutboxStore
+	WebhookStore
+	ViewStore
+	ReadingListStore
+	StatsStore
+	MaintenanceStore
+
+	// Tx runs fn in a transaction, calls with the context passed to fn are
+	// part of it. fn may run more than once.
+	Tx(ctx context.Context, fn func(ctx context.Context) error) error
+	Close(ctx context.Context) error
+}
+
+// Snapshotter is implemented by backends that can copy their data while
+// serving requests.
+type Snapshotter interface {
+	// Snapshot writes a consistent copy of the data to w and returns its size.
+	Snapshot(ctx context.Context, w io.Writer) (int64, error)
+}
+
+type BlogStore interface {
+	// CreateBlog stores a blog with an id chosen by the caller.
+	CreateBlog(ctx context.Context, b *model.BlogItem) error
+	GetBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error)
+	// GetBlogs returns the existing blogs of ids in any order.
+	GetBlogs(ctx context.Context, ids []primitive.ObjectID) ([]model.BlogItem, error)
+	// UpdateBlog passes a copy of the blog to fn and stores it with the next
+	// version. A version of 0 updates any version, otherwise the blog must
+	// have it or ErrVersionConflict is returned. An error of fn is returned
+	// unchanged and nothing is stored.
+	UpdateBlog(ctx context.Context, id primitive.ObjectID, version int64, fn func(b *model.BlogItem) error) (before, after *model.BlogItem, err error)
+	// DeleteBlog deletes the blog and removes it from its series.
+	DeleteBlog(ctx context.Context, id primitive.ObjectID) (*model.BlogItem, error)
+	CountBlogs(ctx context.Context) (int64, error)
+	// ListBlogs calls fn for every blog matching q until fn returns an error.
+	// Only published blogs are listed unless q asks for a moderation state.
+	ListBlogs(ctx context.Context, q BlogQuery, fn func(b *model.BlogItem) error) error
+}
+
+// BlogQuery filters and orders listed blogs, zero fields match every
+// published blog.
+type BlogQuery struct {
+	AuthorID string
+	Tag      string
+	// Moderation lists the blogs in a moderation state of package model instead.
+	Moderation string
+	// Text matches blogs whose original title or content contains one of
+	// its words, ignoring case. Mongo and bolt match whole words, SQL also
+	// parts of words, and SQLite ignores the case of ASCII letters only.
+	// Text without words matches every blog.
+	Text string
+	// Newest lists the most recently created blogs first, by default the oldest come first.
+	Newest bool
+	// Limit of 0 lists every matching blog.
+	Limit int
+	// BatchSize is how many blogs a backend may read from the database at once.
+	BatchSize int
+}
+
+// textWords splits the text filter of a query into distinct lower case words.
+func textWords(text string) []string {
+	var words []string
+	seen := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), notWordRune) {
+		if !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+type SeriesStore interface {
+	CreateSeries(ctx context.Context, s *model.SeriesItem) error
+	GetSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error)
+	// SeriesOfBlog returns ErrNotFound when the blog belongs to no series.
+	SeriesOfBlog(ctx context.Context, blogID primitive.ObjectID) (*model.SeriesItem, error)
+	// UpdateSeries works like UpdateBlog, series have no versions.
+	UpdateSeries(ctx context.Context, id primitive.ObjectID, fn func(s *model.SeriesItem) error) (before, after *model.SeriesItem, err error)
+	DeleteSeries(ctx context.Context, id primitive.ObjectID) (*model.SeriesItem, error)
+}
+
+type ReadingListStore interface {
+	CreateReadingList(ctx context.Context, l *model.ReadingListItem) error
+	GetReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error)
+	// ReadingListsOf returns the lists of a reader, oldest first.
+	ReadingListsOf(ctx context.Context, readerID string) ([]model.ReadingListItem, error)
+	// UpdateReadingList works like UpdateSeries.
+	UpdateReadingList(ctx context.Context, id primitive.ObjectID, fn func(l *model.ReadingListItem) error) (before, after *model.ReadingListItem, err error)
+	DeleteReadingList(ctx context.Context, id primitive.ObjectID) (*model.ReadingListItem, error)
+}
+
+// StatsStore works across tenants.
+type StatsStore interface {
+	// BlogStats aggregates the published blogs matching q.
+	BlogStats(ctx context.Context, q StatsQuery) (*BlogStats, error)
+}
+
+// StatsQuery selects the blogs of BlogStats, zero fields match the published
+// blogs of every tenant.
+type StatsQuery struct {
+	TenantID string
+	// From and To limit the creation time to [From, To).
+	From, To time.Time
+}
+
+// StatsRows is the most authors and tags BlogStats returns.
+const StatsRows = 1000
+
+type BlogStats struct {
+	Posts int64
+	// ContentLength is the length of all contents in characters.
+	ContentLength int64
+	// Authors and Tags come with the most posts first.
+	Authors []AuthorPosts
+	Tags    []TagPosts
+	// Months come in order, months without posts are left out.
+	Months []MonthPosts
+}
+
+type AuthorPosts struct {
+	TenantID string
+	AuthorID string
+	Posts    int64
+}
+
+type TagPosts struct {
+	Tag   string `bson:"_id"`
+	Posts int64  `bson:"posts"`
+}
+
+type MonthPosts struct {
+	// Month is a UTC month like 2006-01.
+	Month string `bson:"_id"`
+	Posts int64  `bson:"posts"`
+}
+
+// MaintenanceStore works across tenants.
+type MaintenanceStore interface {
+	// Orphans calls fn for every orphan until fn returns an error.
+	Orphans(ctx context.Context, fn func(o *Orphan) error) error
+	// Sizes reports how much the data of the server takes.
+	Sizes(ctx context.Context) (*Sizes, error)
+}
+
+// Kinds of orphans.
+const (
+	// OrphanTenant is a blog, series, reading list or webhook of a deleted
+	// tenant, left behind by a DeleteTenant that failed halfway.
+	OrphanTenant = "tenant"
+	// OrphanSeriesBlog is an entry of a series naming a deleted blog.
+	OrphanSeriesBlog = "series_blog"
+)
+
+// Orphan is an item referring to something that does not exist.
+type Orphan struct {
+	Kind string
+	// Collection of the item, one of blogs, series, reading_lists and webhooks.
+	Collection string
+	TenantID   string
+	ID         string
+	// MissingID is the id of the deleted tenant or blog.
+	MissingID string
+}
+
+type Sizes struct {
+	// TotalBytes the database takes on disk.
+	TotalBytes  int64
+	Collections []CollectionSize
+}
+
+type CollectionSize struct {
+	// Name of the collection, table or bucket.
+	Name  string
+	Items int64
+	// Bytes taken including indexes, 0 when the backend cannot tell, like SQLite.
+	Bytes int64
+}
+
+// TenantStore works across tenants.
+type TenantStore interface {
+	// CreateTenant returns ErrExists when the id is taken.
+	CreateTenant(ctx context.Context, t *model.TenantItem) error
+	GetTenant(ctx context.Context, id string) (*model.TenantItem, error)
+	ListTenants(ctx context.Context) ([]model.TenantItem, error)
+	UpdateTenant(ctx context.Context, id string, fn func(t *model.TenantItem) error) (before, after *model.TenantItem, err error)
+	// LockTenant returns the tenant, other transactions locking or changing it
+	// wait or fail until the Tx of ctx ends. It must be called in a Tx.
+	LockTenant(ctx context.Context, id string) (*model.TenantItem, error)
+	// DeleteTenant deletes the tenant and all of its data except the audit log.
+	DeleteTenant(ctx context.Context, id string) (*model.TenantItem, error)
+}
+
+// AuditStore works across tenants, records are never changed or deleted.
+type AuditStore interface {
+	AppendAudit(ctx context.Context, rec *model.AuditRecord) error
+	// ListAudit calls fn for every matching record, oldest first.
+	ListAudit(ctx context.Context, f AuditFilter, fn func(rec *model.AuditRecord) error) error
+}
+
+// AuditFilter selects audit records, zero fields match every record.
+type AuditFilter struct {
+	Principal string
+	TenantID  string
+	BlogID    string
+	From, To  time.Time
+}
+
+type OutboxStore interface {
+	// AddEvent stores an event, it must be called in the Tx of the change the
+	// event describes.
+	AddEvent(ctx context.Context, e *model.OutboxEvent) error
+	// PendingEvents returns up to limit undispatched events of all tenants, oldest first.
+	PendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	// GetEvent returns an event of any tenant.
+	GetEvent(ctx context.Context, id primitive.ObjectID) (*model.OutboxEvent, error)
+	// DispatchEvent stores the deliveries of an event that do not exist yet
+	// and marks the event dispatched, so it can be repeated safely.
+	DispatchEvent(ctx context.Context, eventID primitive.ObjectID, deliveries []model.DeliveryItem) error
+	// ClaimDelivery moves NextAttemptAt of the most overdue pending delivery
+	// of any tenant to leaseUntil and returns it, or ErrNotFound when none is due.
+	ClaimDelivery(ctx context.Context, now, leaseUntil time.Time) (*model.DeliveryItem, error)
+}
+
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, w *model.WebhookItem) error
+	GetWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error)
+	ListWebhooks(ctx context.Context) ([]model.WebhookItem, error)
+	UpdateWebhook(ctx context.Context, id primitive.ObjectID, fn func(w *model.WebhookItem) error) (before, after *model.WebhookItem, err error)
+	// DeleteWebhook keeps the deliveries of the webhook.
+	DeleteWebhook(ctx context.Context, id primitive.ObjectID) (*model.WebhookItem, error)
+	// ListDeliveries returns the newest matching deliveries first.
+	ListDeliveries(ctx context.Context, q DeliveryQuery) ([]model.DeliveryItem, error)
+	UpdateDelivery(ctx context.Context, id primitive.ObjectID, fn func(d *model.DeliveryItem) error) (*model.DeliveryItem, error)
+}
+
+// DeliveryQuery filters deliveries, zero fields match every delivery.
+type DeliveryQuery struct {
+	WebhookID primitive.ObjectID
+	State     string
+	Limit     int
+}
+
+type ViewStore interface {
+	// InsertViews stores events of any tenant, events with a stored ID are skipped.
+	InsertViews(ctx context.Context, events []model.ViewEvent) error
+	// DailyViews returns total and unique views of a blog per UTC day in [from, to).
+	DailyViews(ctx context.Context, blogID primitive.ObjectID, from, to time.Time) ([]DailyViews, error)
+	// TopViews returns the most viewed blogs in [from, to).
+	TopViews(ctx context.Context, from, to time.Time, limit int) ([]BlogViews, error)
+}
+
+type DailyViews struct {
+	Day    string `bson:"_id"`
+	Total  int64  `bson:"total"`
+	Unique int64  `bson:"unique"`
+}
+
+type BlogViews struct {
+	BlogID primitive.ObjectID `bson:"_id"`
+	Total  int64              `bson:"total"`
+	Unique int64              `bson:"unique"`
+}
@@ -0,0 +1,216 @@
+// Package tenant scopes blog data to the tenant of the authenticated
+// principal. Every document carries a tenant_id field, Collection adds it to
+// every filter and inserted document, so a handler cannot read or change the
+// data of another tenant.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"github.com/dbielecki97/grpc-go-course/interceptor/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Field is the name of the tenant field of scoped documents.
+const Field = "tenant_id"
+
+// Default owns the data stored before tenants were introduced.
+const Default = "default"
+
+// ErrMissing is returned by scoped operations of requests without a tenant.
+var ErrMissing = errors.New("request has no tenant")
+
+// FromContext returns the tenant of the authenticated principal.
+func FromContext(ctx context.Context) (string, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Tenant == "" {
+		return "", ErrMissing
+	}
+	return p.Tenant, nil
+}
+
+// NewContext returns ctx acting as the tenant, for work outside of a request
+// such as serving feeds or delivering webhooks.
+func NewContext(ctx context.Context, id string) context.Context {
+	return auth.NewContext(ctx, &auth.Principal{Name: id, Tenant: id})
+}
+
+// Collection is a collection whose operations are limited to the tenant of
+// the context. It exposes only scoped operations on purpose.
+type Collection struct {
+	collection *mongo.Collection
+}
+
+func NewCollection(c *mongo.Collection) *Collection {
+	return &Collection{collection: c}
+}
+
+// Unscoped returns the underlying collection for operations across tenants,
+// such as deleting a tenant. Request handlers must not use it.
+func (c *Collection) Unscoped() *mongo.Collection {
+	return c.collection
+}
+
+// Filter returns filter limited to the tenant of ctx.
+func Filter(ctx context.Context, filter interface{}) (bson.M, error) {
+	t, err := FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		return bson.M{Field: t}, nil
+	}
+	return bson.M{"$and": bson.A{bson.M{Field: t}, filter}}, nil
+}
+
+func (c *Collection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.Find(ctx, f, opts...)
+}
+
+// SingleResult is a mongo.SingleResult that can also carry the error of a request without a tenant.
+type SingleResult struct {
+	res *mongo.SingleResult
+	err error
+}
+
+func (r *SingleResult) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.res.Err()
+}
+
+func (r *SingleResult) Decode(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.res.Decode(v)
+}
+
+func (c *Collection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *SingleResult {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return &SingleResult{err: err}
+	}
+	return &SingleResult{res: c.collection.FindOne(ctx, f, opts...)}
+}
+
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *SingleResult {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return &SingleResult{err: err}
+	}
+	return &SingleResult{res: c.collection.FindOneAndUpdate(ctx, f, update, opts...)}
+}
+
+func (c *Collection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *SingleResult {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return &SingleResult{err: err}
+	}
+	return &SingleResult{res: c.collection.FindOneAndDelete(ctx, f, opts...)}
+}
+
+func (c *Collection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return c.collection.CountDocuments(ctx, f, opts...)
+}
+
+func (c *Collection) UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.UpdateOne(ctx, f, update, opts...)
+}
+
+func (c *Collection) UpdateMany(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.UpdateMany(ctx, f, update, opts...)
+}
+
+func (c *Collection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.DeleteOne(ctx, f, opts...)
+}
+
+func (c *Collection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.DeleteMany(ctx, f, opts...)
+}
+
+// InsertOne stores document with the tenant of ctx, overwriting any tenant it had.
+func (c *Collection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	scoped, err := scope(ctx, document)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.InsertOne(ctx, scoped, opts...)
+}
+
+// ReplaceOne replaces a document of the tenant of ctx, keeping its tenant.
+func (c *Collection) ReplaceOne(ctx context.Context, filter, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	f, err := Filter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	scoped, err := scope(ctx, replacement)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.ReplaceOne(ctx, f, scoped, opts...)
+}
+
+// scope returns document with its tenant set to the tenant of ctx.
+func scope(ctx context.Context, document interface{}) (bson.D, error) {
+	t, err := FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	scoped := make(bson.D, 0, len(doc)+1)
+	for _, e := range doc {
+		if e.Key != Field {
+			scoped = append(scoped, e)
+		}
+	}
+	return append(scoped, bson.E{Key: Field, Value: t}), nil
+}
+
+// Aggregate prepends a $match on the tenant of ctx to pipeline.
+func (c *Collection) Aggregate(ctx context.Context, pipeline mongo.Pipeline, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	t, err := FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := append(mongo.Pipeline{{{Key: "$match", Value: bson.M{Field: t}}}}, pipeline...)
+	return c.collection.Aggregate(ctx, scoped, opts...)
+}
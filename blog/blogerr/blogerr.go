@@ -0,0 +1,128 @@
+// Package blogerr builds and reads the google.rpc error details of the blog
+// service. Every error carries an ErrorInfo with a stable reason, invalid
+// requests a BadRequest with field violations, missing resources a
+// ResourceInfo, failed preconditions a PreconditionFailure and exceeded
+// quotas a QuotaFailure.
+package blogerr
+
+import (
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"strings"
+)
+
+// Domain of the ErrorInfo of all blog service errors.
+const Domain = "blog.grpc-go-course"
+
+// Reasons are stable, clients may switch on them.
+const (
+	ReasonInvalidArgument     = "INVALID_ARGUMENT"
+	ReasonInvalidID           = "INVALID_ID"
+	ReasonMissingField        = "MISSING_FIELD"
+	ReasonBlogNotFound        = "BLOG_NOT_FOUND"
+	ReasonSeriesNotFound      = "SERIES_NOT_FOUND"
+	ReasonTranslationNotFound = "TRANSLATION_NOT_FOUND"
+	ReasonVersionConflict     = "VERSION_CONFLICT"
+	ReasonAlreadyInSeries     = "BLOG_ALREADY_IN_SERIES"
+	ReasonSeriesMismatch      = "SERIES_BLOGS_MISMATCH"
+	ReasonOriginalLanguage    = "ORIGINAL_LANGUAGE"
+	ReasonInternal            = "INTERNAL"
+	ReasonDatabase            = "DATABASE_ERROR"
+	ReasonCanceled            = "CANCELED"
+	ReasonDeadlineExceeded    = "DEADLINE_EXCEEDED"
+	ReasonQuotaExceeded       = "QUOTA_EXCEEDED"
+	ReasonNoTenant            = "NO_TENANT"
+	ReasonTenantNotFound      = "TENANT_NOT_FOUND"
+	ReasonTenantExists        = "TENANT_ALREADY_EXISTS"
+	ReasonWebhookNotFound     = "WEBHOOK_NOT_FOUND"
+	ReasonDeliveryNotFound    = "DELIVERY_NOT_FOUND"
+	ReasonSnapshotUnsupported = "SNAPSHOT_UNSUPPORTED"
+	ReasonModerationState     = "MODERATION_STATE"
+	ReasonNoReader            = "NO_READER"
+	ReasonReadingListNotFound = "READING_LIST_NOT_FOUND"
+	ReasonAlreadyInList       = "BLOG_ALREADY_IN_READING_LIST"
+	ReasonReadingListMismatch = "READING_LIST_BLOGS_MISMATCH"
+	ReasonTenantForbidden     = "TENANT_FORBIDDEN"
+	ReasonReadOnly            = "READ_ONLY"
+)
+
+// Resource types used in ResourceInfo and PreconditionFailure.
+const (
+	ResourceBlog        = "blog.Blog"
+	ResourceSeries      = "blog.Series"
+	ResourceTranslation = "blog.Translation"
+	ResourceTenant      = "blog.Tenant"
+	ResourceWebhook     = "blog.Webhook"
+	ResourceDelivery    = "blog.Delivery"
+	ResourceReadingList = "blog.ReadingList"
+)
+
+// New returns a status error with an ErrorInfo of reason followed by details.
+func New(code codes.Code, reason, msg string, details ...protoiface.MessageV1) error {
+	st := status.New(code, msg)
+
+	all := []protoiface.MessageV1{&errdetails.ErrorInfo{Reason: reason, Domain: Domain}}
+	all = append(all, details...)
+
+	withDetails, err := st.WithDetails(all...)
+	if err != nil {
+		// details are an extra, the error itself is still meaningful
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// Violation describes why the field at the given path, e.g. blog.title or blog_ids[2], is invalid.
+func Violation(field, format string, args ...interface{}) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)}
+}
+
+// BadRequest returns an InvalidArgument error listing the violations.
+func BadRequest(reason string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	msgs := make([]string, 0, len(violations))
+	for _, v := range violations {
+		msgs = append(msgs, v.GetField()+": "+v.GetDescription())
+	}
+	return New(codes.InvalidArgument, reason, strings.Join(msgs, "; "), &errdetails.BadRequest{FieldViolations: violations})
+}
+
+func InvalidArgument(field, format string, args ...interface{}) error {
+	return BadRequest(ReasonInvalidArgument, Violation(field, format, args...))
+}
+
+func InvalidID(field, value string) error {
+	return BadRequest(ReasonInvalidID, Violation(field, "%q is not a valid id", value))
+}
+
+func MissingField(field string) error {
+	return BadRequest(ReasonMissingField, Violation(field, "is required"))
+}
+
+// NotFound returns a NotFound error with the ResourceInfo of the missing resource.
+func NotFound(reason, resourceType, name, description string) error {
+	return New(codes.NotFound, reason, description, &errdetails.ResourceInfo{
+		ResourceType: resourceType,
+		ResourceName: name,
+		Description:  description,
+	})
+}
+
+// PreconditionViolation describes a failed precondition of the subject, e.g. a blog id.
+func PreconditionViolation(typ, subject, description string) *errdetails.PreconditionFailure_Violation {
+	return &errdetails.PreconditionFailure_Violation{Type: typ, Subject: subject, Description: description}
+}
+
+// FailedPrecondition returns a FailedPrecondition error listing the violations.
+func FailedPrecondition(reason, msg string, violations ...*errdetails.PreconditionFailure_Violation) error {
+	return New(codes.FailedPrecondition, reason, msg, &errdetails.PreconditionFailure{Violations: violations})
+}
+
+// QuotaExceeded returns a ResourceExhausted error with a QuotaFailure of subject, e.g. a tenant id.
+func QuotaExceeded(subject, description string) error {
+	return New(codes.ResourceExhausted, ReasonQuotaExceeded, description, &errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{Subject: subject, Description: description}},
+	})
+}
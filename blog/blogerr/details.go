@@ -0,0 +1,95 @@
+package blogerr
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// Info returns the ErrorInfo of err, nil when err has none.
+func Info(err error) *errdetails.ErrorInfo {
+	for _, d := range details(err) {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+// Reason returns the reason of err, empty when err is not a blog service error.
+func Reason(err error) string {
+	info := Info(err)
+	if info == nil || info.GetDomain() != Domain {
+		return ""
+	}
+	return info.GetReason()
+}
+
+// FieldViolations returns the field violations of an InvalidArgument error.
+func FieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var res []*errdetails.BadRequest_FieldViolation
+	for _, d := range details(err) {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			res = append(res, br.GetFieldViolations()...)
+		}
+	}
+	return res
+}
+
+// FieldErrors maps field paths to descriptions, e.g. for showing errors next
+// to form fields. Descriptions of repeated violations of a field are joined.
+func FieldErrors(err error) map[string]string {
+	violations := FieldViolations(err)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(violations))
+	for _, v := range violations {
+		if prev, ok := res[v.GetField()]; ok {
+			res[v.GetField()] = prev + "; " + v.GetDescription()
+			continue
+		}
+		res[v.GetField()] = v.GetDescription()
+	}
+	return res
+}
+
+// Resource returns the ResourceInfo of a NotFound error.
+func Resource(err error) *errdetails.ResourceInfo {
+	for _, d := range details(err) {
+		if info, ok := d.(*errdetails.ResourceInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+// PreconditionViolations returns the violations of a FailedPrecondition error.
+func PreconditionViolations(err error) []*errdetails.PreconditionFailure_Violation {
+	var res []*errdetails.PreconditionFailure_Violation
+	for _, d := range details(err) {
+		if pf, ok := d.(*errdetails.PreconditionFailure); ok {
+			res = append(res, pf.GetViolations()...)
+		}
+	}
+	return res
+}
+
+// QuotaViolations returns the violations of a ResourceExhausted error.
+func QuotaViolations(err error) []*errdetails.QuotaFailure_Violation {
+	var res []*errdetails.QuotaFailure_Violation
+	for _, d := range details(err) {
+		if qf, ok := d.(*errdetails.QuotaFailure); ok {
+			res = append(res, qf.GetViolations()...)
+		}
+	}
+	return res
+}
+
+func details(err error) []interface{} {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	return st.Details()
+}
@@ -0,0 +1,1242 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0-devel
+// 	protoc        v3.17.1
+// source: blog/proto/blog_stats.proto
+
+package proto
+
+import (
+	context "context"
+	_ "github.com/dbielecki97/grpc-go-course/validate"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// StatsFilter selects the blogs, from and to are rounded to whole UTC days.
+type StatsFilter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// only admins may ask for another tenant than their own
+	TenantId string `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// creation time of the blogs, inclusive
+	From *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	// exclusive
+	To *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *StatsFilter) Reset() {
+	*x = StatsFilter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatsFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsFilter) ProtoMessage() {}
+
+func (x *StatsFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsFilter.ProtoReflect.Descriptor instead.
+func (*StatsFilter) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StatsFilter) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *StatsFilter) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *StatsFilter) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type AuthorPosts struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TenantId string `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	AuthorId string `protobuf:"bytes,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Posts    int64  `protobuf:"varint,3,opt,name=posts,proto3" json:"posts,omitempty"`
+}
+
+func (x *AuthorPosts) Reset() {
+	*x = AuthorPosts{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuthorPosts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorPosts) ProtoMessage() {}
+
+func (x *AuthorPosts) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorPosts.ProtoReflect.Descriptor instead.
+func (*AuthorPosts) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AuthorPosts) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *AuthorPosts) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *AuthorPosts) GetPosts() int64 {
+	if x != nil {
+		return x.Posts
+	}
+	return 0
+}
+
+type PostsPerAuthorRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *StatsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// defaults to 20, at most 1000
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *PostsPerAuthorRequest) Reset() {
+	*x = PostsPerAuthorRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PostsPerAuthorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostsPerAuthorRequest) ProtoMessage() {}
+
+func (x *PostsPerAuthorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostsPerAuthorRequest.ProtoReflect.Descriptor instead.
+func (*PostsPerAuthorRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PostsPerAuthorRequest) GetFilter() *StatsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *PostsPerAuthorRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type PostsPerAuthorResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// most posts first
+	Authors     []*AuthorPosts         `protobuf:"bytes,1,rep,name=authors,proto3" json:"authors,omitempty"`
+	ComputeTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=compute_time,json=computeTime,proto3" json:"compute_time,omitempty"`
+}
+
+func (x *PostsPerAuthorResponse) Reset() {
+	*x = PostsPerAuthorResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PostsPerAuthorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostsPerAuthorResponse) ProtoMessage() {}
+
+func (x *PostsPerAuthorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostsPerAuthorResponse.ProtoReflect.Descriptor instead.
+func (*PostsPerAuthorResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PostsPerAuthorResponse) GetAuthors() []*AuthorPosts {
+	if x != nil {
+		return x.Authors
+	}
+	return nil
+}
+
+func (x *PostsPerAuthorResponse) GetComputeTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ComputeTime
+	}
+	return nil
+}
+
+type MonthPosts struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// UTC month like 2006-01
+	Month string `protobuf:"bytes,1,opt,name=month,proto3" json:"month,omitempty"`
+	Posts int64  `protobuf:"varint,2,opt,name=posts,proto3" json:"posts,omitempty"`
+}
+
+func (x *MonthPosts) Reset() {
+	*x = MonthPosts{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MonthPosts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonthPosts) ProtoMessage() {}
+
+func (x *MonthPosts) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonthPosts.ProtoReflect.Descriptor instead.
+func (*MonthPosts) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MonthPosts) GetMonth() string {
+	if x != nil {
+		return x.Month
+	}
+	return ""
+}
+
+func (x *MonthPosts) GetPosts() int64 {
+	if x != nil {
+		return x.Posts
+	}
+	return 0
+}
+
+type PostsPerMonthRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *StatsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+}
+
+func (x *PostsPerMonthRequest) Reset() {
+	*x = PostsPerMonthRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PostsPerMonthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostsPerMonthRequest) ProtoMessage() {}
+
+func (x *PostsPerMonthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostsPerMonthRequest.ProtoReflect.Descriptor instead.
+func (*PostsPerMonthRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PostsPerMonthRequest) GetFilter() *StatsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type PostsPerMonthResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// oldest first, months without posts are left out
+	Months      []*MonthPosts          `protobuf:"bytes,1,rep,name=months,proto3" json:"months,omitempty"`
+	ComputeTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=compute_time,json=computeTime,proto3" json:"compute_time,omitempty"`
+}
+
+func (x *PostsPerMonthResponse) Reset() {
+	*x = PostsPerMonthResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PostsPerMonthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostsPerMonthResponse) ProtoMessage() {}
+
+func (x *PostsPerMonthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostsPerMonthResponse.ProtoReflect.Descriptor instead.
+func (*PostsPerMonthResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PostsPerMonthResponse) GetMonths() []*MonthPosts {
+	if x != nil {
+		return x.Months
+	}
+	return nil
+}
+
+func (x *PostsPerMonthResponse) GetComputeTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ComputeTime
+	}
+	return nil
+}
+
+type ContentLengthRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *StatsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+}
+
+func (x *ContentLengthRequest) Reset() {
+	*x = ContentLengthRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContentLengthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContentLengthRequest) ProtoMessage() {}
+
+func (x *ContentLengthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContentLengthRequest.ProtoReflect.Descriptor instead.
+func (*ContentLengthRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ContentLengthRequest) GetFilter() *StatsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ContentLengthResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Posts int64 `protobuf:"varint,1,opt,name=posts,proto3" json:"posts,omitempty"`
+	// in characters
+	TotalLength   int64                  `protobuf:"varint,2,opt,name=total_length,json=totalLength,proto3" json:"total_length,omitempty"`
+	AverageLength float64                `protobuf:"fixed64,3,opt,name=average_length,json=averageLength,proto3" json:"average_length,omitempty"`
+	ComputeTime   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=compute_time,json=computeTime,proto3" json:"compute_time,omitempty"`
+}
+
+func (x *ContentLengthResponse) Reset() {
+	*x = ContentLengthResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContentLengthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContentLengthResponse) ProtoMessage() {}
+
+func (x *ContentLengthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContentLengthResponse.ProtoReflect.Descriptor instead.
+func (*ContentLengthResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ContentLengthResponse) GetPosts() int64 {
+	if x != nil {
+		return x.Posts
+	}
+	return 0
+}
+
+func (x *ContentLengthResponse) GetTotalLength() int64 {
+	if x != nil {
+		return x.TotalLength
+	}
+	return 0
+}
+
+func (x *ContentLengthResponse) GetAverageLength() float64 {
+	if x != nil {
+		return x.AverageLength
+	}
+	return 0
+}
+
+func (x *ContentLengthResponse) GetComputeTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ComputeTime
+	}
+	return nil
+}
+
+type TagPosts struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tag   string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Posts int64  `protobuf:"varint,2,opt,name=posts,proto3" json:"posts,omitempty"`
+}
+
+func (x *TagPosts) Reset() {
+	*x = TagPosts{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TagPosts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagPosts) ProtoMessage() {}
+
+func (x *TagPosts) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagPosts.ProtoReflect.Descriptor instead.
+func (*TagPosts) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TagPosts) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TagPosts) GetPosts() int64 {
+	if x != nil {
+		return x.Posts
+	}
+	return 0
+}
+
+type TopTagsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filter *StatsFilter `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// defaults to 20, at most 1000
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *TopTagsRequest) Reset() {
+	*x = TopTagsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopTagsRequest) ProtoMessage() {}
+
+func (x *TopTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopTagsRequest.ProtoReflect.Descriptor instead.
+func (*TopTagsRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TopTagsRequest) GetFilter() *StatsFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *TopTagsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type TopTagsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// most posts first
+	Tags        []*TagPosts            `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	ComputeTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=compute_time,json=computeTime,proto3" json:"compute_time,omitempty"`
+}
+
+func (x *TopTagsResponse) Reset() {
+	*x = TopTagsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_blog_stats_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopTagsResponse) ProtoMessage() {}
+
+func (x *TopTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_blog_stats_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopTagsResponse.ProtoReflect.Descriptor instead.
+func (*TopTagsResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_blog_stats_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TopTagsResponse) GetTags() []*TagPosts {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *TopTagsResponse) GetComputeTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ComputeTime
+	}
+	return nil
+}
+
+var File_blog_proto_blog_stats_proto protoreflect.FileDescriptor
+
+var file_blog_proto_blog_stats_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x62, 0x6c, 0x6f, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x62, 0x6c, 0x6f,
+	0x67, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x62,
+	0x6c, 0x6f, 0x67, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2f, 0x76,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x8f, 0x01,
+	0x0a, 0x0b, 0x53, 0x74, 0x61, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x24, 0x0a,
+	0x09, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x42, 0x07, 0xca, 0xf3, 0x18, 0x03, 0x18, 0x80, 0x01, 0x52, 0x08, 0x74, 0x65, 0x6e, 0x61, 0x6e,
+	0x74, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x66,
+	0x72, 0x6f, 0x6d, 0x12, 0x2a, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x02, 0x74, 0x6f, 0x22,
+	0x5d, 0x0a, 0x0b, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x1b,
+	0x0a, 0x09, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x61,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x73, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x70, 0x6f, 0x73, 0x74, 0x73, 0x22, 0x72,
+	0x0a, 0x15, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74,
+	0x65, 0x72, 0x12, 0x2e, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x42, 0x18, 0xca, 0xf3, 0x18, 0x14, 0x2a, 0x12, 0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x11, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x8f, 0x40, 0x52, 0x05, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x22, 0x84, 0x01, 0x0a, 0x16, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x41,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a,
+	0x07, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x50, 0x6f, 0x73, 0x74,
+	0x73, 0x52, 0x07, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x73, 0x12, 0x3d, 0x0a, 0x0c, 0x63, 0x6f,
+	0x6d, 0x70, 0x75, 0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x63, 0x6f,
+	0x6d, 0x70, 0x75, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x38, 0x0a, 0x0a, 0x4d, 0x6f, 0x6e,
+	0x74, 0x68, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x6e, 0x74, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x12, 0x14, 0x0a,
+	0x05, 0x70, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x70, 0x6f,
+	0x73, 0x74, 0x73, 0x22, 0x41, 0x0a, 0x14, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x4d,
+	0x6f, 0x6e, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x06, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c,
+	0x6f, 0x67, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x80, 0x01, 0x0a, 0x15, 0x50, 0x6f, 0x73, 0x74, 0x73,
+	0x50, 0x65, 0x72, 0x4d, 0x6f, 0x6e, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x28, 0x0a, 0x06, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x10, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x4d, 0x6f, 0x6e, 0x74, 0x68, 0x50, 0x6f, 0x73,
+	0x74, 0x73, 0x52, 0x06, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x73, 0x12, 0x3d, 0x0a, 0x0c, 0x63, 0x6f,
+	0x6d, 0x70, 0x75, 0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x63, 0x6f,
+	0x6d, 0x70, 0x75, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x41, 0x0a, 0x14, 0x43, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x29, 0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x46, 0x69,
+	0x6c, 0x74, 0x65, 0x72, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0xb6, 0x01, 0x0a,
+	0x15, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x73, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x70, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12,
+	0x25, 0x0a, 0x0e, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74,
+	0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65,
+	0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x3d, 0x0a, 0x0c, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74,
+	0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74,
+	0x65, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x32, 0x0a, 0x08, 0x54, 0x61, 0x67, 0x50, 0x6f, 0x73, 0x74,
+	0x73, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x74, 0x61, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x73, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x70, 0x6f, 0x73, 0x74, 0x73, 0x22, 0x6b, 0x0a, 0x0e, 0x54, 0x6f, 0x70,
+	0x54, 0x61, 0x67, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x06, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c,
+	0x6f, 0x67, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x2e, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x42, 0x18, 0xca, 0xf3, 0x18, 0x14, 0x2a, 0x12, 0x09, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x11, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x8f, 0x40, 0x52,
+	0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x74, 0x0a, 0x0f, 0x54, 0x6f, 0x70, 0x54, 0x61, 0x67,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x04, 0x74, 0x61, 0x67,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x54,
+	0x61, 0x67, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x3d, 0x0a,
+	0x0c, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x32, 0xb3, 0x02, 0x0a,
+	0x10, 0x42, 0x6c, 0x6f, 0x67, 0x53, 0x74, 0x61, 0x74, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x4d, 0x0a, 0x0e, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x12, 0x1b, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x73,
+	0x50, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1c, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72,
+	0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x12, 0x4a, 0x0a, 0x0d, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x4d, 0x6f, 0x6e, 0x74,
+	0x68, 0x12, 0x1a, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65,
+	0x72, 0x4d, 0x6f, 0x6e, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e,
+	0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x4d, 0x6f, 0x6e,
+	0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x0d,
+	0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x1a, 0x2e,
+	0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67,
+	0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x62, 0x6c, 0x6f, 0x67,
+	0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x07, 0x54, 0x6f, 0x70, 0x54,
+	0x61, 0x67, 0x73, 0x12, 0x14, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x54, 0x6f, 0x70, 0x54, 0x61,
+	0x67, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x62, 0x6c, 0x6f, 0x67,
+	0x2e, 0x54, 0x6f, 0x70, 0x54, 0x61, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x42, 0x0d, 0x5a, 0x0b, 0x2f, 0x62, 0x6c, 0x6f, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_blog_proto_blog_stats_proto_rawDescOnce sync.Once
+	file_blog_proto_blog_stats_proto_rawDescData = file_blog_proto_blog_stats_proto_rawDesc
+)
+
+func file_blog_proto_blog_stats_proto_rawDescGZIP() []byte {
+	file_blog_proto_blog_stats_proto_rawDescOnce.Do(func() {
+		file_blog_proto_blog_stats_proto_rawDescData = protoimpl.X.CompressGZIP(file_blog_proto_blog_stats_proto_rawDescData)
+	})
+	return file_blog_proto_blog_stats_proto_rawDescData
+}
+
+var file_blog_proto_blog_stats_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_blog_proto_blog_stats_proto_goTypes = []interface{}{
+	(*StatsFilter)(nil),            // 0: blog.StatsFilter
+	(*AuthorPosts)(nil),            // 1: blog.AuthorPosts
+	(*PostsPerAuthorRequest)(nil),  // 2: blog.PostsPerAuthorRequest
+	(*PostsPerAuthorResponse)(nil), // 3: blog.PostsPerAuthorResponse
+	(*MonthPosts)(nil),             // 4: blog.MonthPosts
+	(*PostsPerMonthRequest)(nil),   // 5: blog.PostsPerMonthRequest
+	(*PostsPerMonthResponse)(nil),  // 6: blog.PostsPerMonthResponse
+	(*ContentLengthRequest)(nil),   // 7: blog.ContentLengthRequest
+	(*ContentLengthResponse)(nil),  // 8: blog.ContentLengthResponse
+	(*TagPosts)(nil),               // 9: blog.TagPosts
+	(*TopTagsRequest)(nil),         // 10: blog.TopTagsRequest
+	(*TopTagsResponse)(nil),        // 11: blog.TopTagsResponse
+	(*timestamppb.Timestamp)(nil),  // 12: google.protobuf.Timestamp
+}
+var file_blog_proto_blog_stats_proto_depIdxs = []int32{
+	12, // 0: blog.StatsFilter.from:type_name -> google.protobuf.Timestamp
+	12, // 1: blog.StatsFilter.to:type_name -> google.protobuf.Timestamp
+	0,  // 2: blog.PostsPerAuthorRequest.filter:type_name -> blog.StatsFilter
+	1,  // 3: blog.PostsPerAuthorResponse.authors:type_name -> blog.AuthorPosts
+	12, // 4: blog.PostsPerAuthorResponse.compute_time:type_name -> google.protobuf.Timestamp
+	0,  // 5: blog.PostsPerMonthRequest.filter:type_name -> blog.StatsFilter
+	4,  // 6: blog.PostsPerMonthResponse.months:type_name -> blog.MonthPosts
+	12, // 7: blog.PostsPerMonthResponse.compute_time:type_name -> google.protobuf.Timestamp
+	0,  // 8: blog.ContentLengthRequest.filter:type_name -> blog.StatsFilter
+	12, // 9: blog.ContentLengthResponse.compute_time:type_name -> google.protobuf.Timestamp
+	0,  // 10: blog.TopTagsRequest.filter:type_name -> blog.StatsFilter
+	9,  // 11: blog.TopTagsResponse.tags:type_name -> blog.TagPosts
+	12, // 12: blog.TopTagsResponse.compute_time:type_name -> google.protobuf.Timestamp
+	2,  // 13: blog.BlogStatsService.PostsPerAuthor:input_type -> blog.PostsPerAuthorRequest
+	5,  // 14: blog.BlogStatsService.PostsPerMonth:input_type -> blog.PostsPerMonthRequest
+	7,  // 15: blog.BlogStatsService.ContentLength:input_type -> blog.ContentLengthRequest
+	10, // 16: blog.BlogStatsService.TopTags:input_type -> blog.TopTagsRequest
+	3,  // 17: blog.BlogStatsService.PostsPerAuthor:output_type -> blog.PostsPerAuthorResponse
+	6,  // 18: blog.BlogStatsService.PostsPerMonth:output_type -> blog.PostsPerMonthResponse
+	8,  // 19: blog.BlogStatsService.ContentLength:output_type -> blog.ContentLengthResponse
+	11, // 20: blog.BlogStatsService.TopTags:output_type -> blog.TopTagsResponse
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_blog_proto_blog_stats_proto_init() }
+func file_blog_proto_blog_stats_proto_init() {
+	if File_blog_proto_blog_stats_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_blog_proto_blog_stats_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatsFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuthorPosts); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PostsPerAuthorRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PostsPerAuthorResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MonthPosts); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PostsPerMonthRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PostsPerMonthResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContentLengthRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContentLengthResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TagPosts); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopTagsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_blog_stats_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopTagsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_blog_proto_blog_stats_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_blog_proto_blog_stats_proto_goTypes,
+		DependencyIndexes: file_blog_proto_blog_stats_proto_depIdxs,
+		MessageInfos:      file_blog_proto_blog_stats_proto_msgTypes,
+	}.Build()
+	File_blog_proto_blog_stats_proto = out.File
+	file_blog_proto_blog_stats_proto_rawDesc = nil
+	file_blog_proto_blog_stats_proto_goTypes = nil
+	file_blog_proto_blog_stats_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// BlogStatsServiceClient is the client API for BlogStatsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BlogStatsServiceClient interface {
+	PostsPerAuthor(ctx context.Context, in *PostsPerAuthorRequest, opts ...grpc.CallOption) (*PostsPerAuthorResponse, error)
+	PostsPerMonth(ctx context.Context, in *PostsPerMonthRequest, opts ...grpc.CallOption) (*PostsPerMonthResponse, error)
+	ContentLength(ctx context.Context, in *ContentLengthRequest, opts ...grpc.CallOption) (*ContentLengthResponse, error)
+	TopTags(ctx context.Context, in *TopTagsRequest, opts ...grpc.CallOption) (*TopTagsResponse, error)
+}
+
+type blogStatsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBlogStatsServiceClient(cc grpc.ClientConnInterface) BlogStatsServiceClient {
+	return &blogStatsServiceClient{cc}
+}
+
+func (c *blogStatsServiceClient) PostsPerAuthor(ctx context.Context, in *PostsPerAuthorRequest, opts ...grpc.CallOption) (*PostsPerAuthorResponse, error) {
+	out := new(PostsPerAuthorResponse)
+	err := c.cc.Invoke(ctx, "/blog.BlogStatsService/PostsPerAuthor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blogStatsServiceClient) PostsPerMonth(ctx context.Context, in *PostsPerMonthRequest, opts ...grpc.CallOption) (*PostsPerMonthResponse, error) {
+	out := new(PostsPerMonthResponse)
+	err := c.cc.Invoke(ctx, "/blog.BlogStatsService/PostsPerMonth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blogStatsServiceClient) ContentLength(ctx context.Context, in *ContentLengthRequest, opts ...grpc.CallOption) (*ContentLengthResponse, error) {
+	out := new(ContentLengthResponse)
+	err := c.cc.Invoke(ctx, "/blog.BlogStatsService/ContentLength", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blogStatsServiceClient) TopTags(ctx context.Context, in *TopTagsRequest, opts ...grpc.CallOption) (*TopTagsResponse, error) {
+	out := new(TopTagsResponse)
+	err := c.cc.Invoke(ctx, "/blog.BlogStatsService/TopTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BlogStatsServiceServer is the server API for BlogStatsService service.
+type BlogStatsServiceServer interface {
+	PostsPerAuthor(context.Context, *PostsPerAuthorRequest) (*PostsPerAuthorResponse, error)
+	PostsPerMonth(context.Context, *PostsPerMonthRequest) (*PostsPerMonthResponse, error)
+	ContentLength(context.Context, *ContentLengthRequest) (*ContentLengthResponse, error)
+	TopTags(context.Context, *TopTagsRequest) (*TopTagsResponse, error)
+}
+
+// UnimplementedBlogStatsServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedBlogStatsServiceServer struct {
+}
+
+func (*UnimplementedBlogStatsServiceServer) PostsPerAuthor(context.Context, *PostsPerAuthorRequest) (*PostsPerAuthorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PostsPerAuthor not implemented")
+}
+func (*UnimplementedBlogStatsServiceServer) PostsPerMonth(context.Context, *PostsPerMonthRequest) (*PostsPerMonthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PostsPerMonth not implemented")
+}
+func (*UnimplementedBlogStatsServiceServer) ContentLength(context.Context, *ContentLengthRequest) (*ContentLengthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ContentLength not implemented")
+}
+func (*UnimplementedBlogStatsServiceServer) TopTags(context.Context, *TopTagsRequest) (*TopTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TopTags not implemented")
+}
+
+func RegisterBlogStatsServiceServer(s *grpc.Server, srv BlogStatsServiceServer) {
+	s.RegisterService(&_BlogStatsService_serviceDesc, srv)
+}
+
+func _BlogStatsService_PostsPerAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostsPerAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlogStatsServiceServer).PostsPerAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.BlogStatsService/PostsPerAuthor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlogStatsServiceServer).PostsPerAuthor(ctx, req.(*PostsPerAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlogStatsService_PostsPerMonth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostsPerMonthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlogStatsServiceServer).PostsPerMonth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.BlogStatsService/PostsPerMonth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlogStatsServiceServer).PostsPerMonth(ctx, req.(*PostsPerMonthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlogStatsService_ContentLength_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContentLengthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlogStatsServiceServer).ContentLength(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.BlogStatsService/ContentLength",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlogStatsServiceServer).ContentLength(ctx, req.(*ContentLengthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlogStatsService_TopTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlogStatsServiceServer).TopTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.BlogStatsService/TopTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlogStatsServiceServer).TopTags(ctx, req.(*TopTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _BlogStatsService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "blog.BlogStatsService",
+	HandlerType: (*BlogStatsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PostsPerAuthor",
+			Handler:    _BlogStatsService_PostsPerAuthor_Handler,
+		},
+		{
+			MethodName: "PostsPerMonth",
+			Handler:    _BlogStatsService_PostsPerMonth_Handler,
+		},
+		{
+			MethodName: "ContentLength",
+			Handler:    _BlogStatsService_ContentLength_Handler,
+		},
+		{
+			MethodName: "TopTags",
+			Handler:    _BlogStatsService_TopTags_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "blog/proto/blog_stats.proto",
+}
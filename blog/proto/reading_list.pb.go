@@ -0,0 +1,2426 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0-devel
+// 	protoc        v3.17.1
+// source: blog/proto/reading_list.proto
+
+package proto
+
+import (
+	context "context"
+	_ "github.com/dbielecki97/grpc-go-course/validate"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ReadingListEntry_State int32
+
+const (
+	ReadingListEntry_STATE_UNSPECIFIED ReadingListEntry_State = 0
+	// blog is set
+	ReadingListEntry_AVAILABLE ReadingListEntry_State = 1
+	// the blog is held by moderation
+	ReadingListEntry_UNAVAILABLE ReadingListEntry_State = 2
+	// the blog was deleted, only the saved title is left
+	ReadingListEntry_DELETED ReadingListEntry_State = 3
+)
+
+// Enum value maps for ReadingListEntry_State.
+var (
+	ReadingListEntry_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "AVAILABLE",
+		2: "UNAVAILABLE",
+		3: "DELETED",
+	}
+	ReadingListEntry_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"AVAILABLE":         1,
+		"UNAVAILABLE":       2,
+		"DELETED":           3,
+	}
+)
+
+func (x ReadingListEntry_State) Enum() *ReadingListEntry_State {
+	p := new(ReadingListEntry_State)
+	*p = x
+	return p
+}
+
+func (x ReadingListEntry_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReadingListEntry_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_blog_proto_reading_list_proto_enumTypes[0].Descriptor()
+}
+
+func (ReadingListEntry_State) Type() protoreflect.EnumType {
+	return &file_blog_proto_reading_list_proto_enumTypes[0]
+}
+
+func (x ReadingListEntry_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReadingListEntry_State.Descriptor instead.
+func (ReadingListEntry_State) EnumDescriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{1, 0}
+}
+
+type ReadingList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// output only
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// output only, the saved blogs in order
+	BlogIds []string `protobuf:"bytes,3,rep,name=blog_ids,json=blogIds,proto3" json:"blog_ids,omitempty"`
+	// output only
+	Shared bool `protobuf:"varint,4,opt,name=shared,proto3" json:"shared,omitempty"`
+	// output only
+	CreateTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	// output only
+	UpdateTime *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+}
+
+func (x *ReadingList) Reset() {
+	*x = ReadingList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadingList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadingList) ProtoMessage() {}
+
+func (x *ReadingList) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadingList.ProtoReflect.Descriptor instead.
+func (*ReadingList) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ReadingList) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReadingList) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReadingList) GetBlogIds() []string {
+	if x != nil {
+		return x.BlogIds
+	}
+	return nil
+}
+
+func (x *ReadingList) GetShared() bool {
+	if x != nil {
+		return x.Shared
+	}
+	return false
+}
+
+func (x *ReadingList) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *ReadingList) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+type ReadingListEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BlogId string                 `protobuf:"bytes,1,opt,name=blog_id,json=blogId,proto3" json:"blog_id,omitempty"`
+	State  ReadingListEntry_State `protobuf:"varint,2,opt,name=state,proto3,enum=blog.ReadingListEntry_State" json:"state,omitempty"`
+	// title of the blog when it was saved
+	Title string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	// the current blog without its content
+	Blog    *Blog                  `protobuf:"bytes,4,opt,name=blog,proto3" json:"blog,omitempty"`
+	AddTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=add_time,json=addTime,proto3" json:"add_time,omitempty"`
+}
+
+func (x *ReadingListEntry) Reset() {
+	*x = ReadingListEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadingListEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadingListEntry) ProtoMessage() {}
+
+func (x *ReadingListEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadingListEntry.ProtoReflect.Descriptor instead.
+func (*ReadingListEntry) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReadingListEntry) GetBlogId() string {
+	if x != nil {
+		return x.BlogId
+	}
+	return ""
+}
+
+func (x *ReadingListEntry) GetState() ReadingListEntry_State {
+	if x != nil {
+		return x.State
+	}
+	return ReadingListEntry_STATE_UNSPECIFIED
+}
+
+func (x *ReadingListEntry) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ReadingListEntry) GetBlog() *Blog {
+	if x != nil {
+		return x.Blog
+	}
+	return nil
+}
+
+func (x *ReadingListEntry) GetAddTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AddTime
+	}
+	return nil
+}
+
+type CreateReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *CreateReadingListRequest) Reset() {
+	*x = CreateReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateReadingListRequest) ProtoMessage() {}
+
+func (x *CreateReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateReadingListRequest.ProtoReflect.Descriptor instead.
+func (*CreateReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateReadingListRequest) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type CreateReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *CreateReadingListResponse) Reset() {
+	*x = CreateReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateReadingListResponse) ProtoMessage() {}
+
+func (x *CreateReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateReadingListResponse.ProtoReflect.Descriptor instead.
+func (*CreateReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type ListReadingListsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListReadingListsRequest) Reset() {
+	*x = ListReadingListsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListReadingListsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReadingListsRequest) ProtoMessage() {}
+
+func (x *ListReadingListsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReadingListsRequest.ProtoReflect.Descriptor instead.
+func (*ListReadingListsRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{4}
+}
+
+type ListReadingListsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Lists []*ReadingList `protobuf:"bytes,1,rep,name=lists,proto3" json:"lists,omitempty"`
+}
+
+func (x *ListReadingListsResponse) Reset() {
+	*x = ListReadingListsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListReadingListsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReadingListsResponse) ProtoMessage() {}
+
+func (x *ListReadingListsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReadingListsResponse.ProtoReflect.Descriptor instead.
+func (*ListReadingListsResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListReadingListsResponse) GetLists() []*ReadingList {
+	if x != nil {
+		return x.Lists
+	}
+	return nil
+}
+
+type RenameReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	Name   string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *RenameReadingListRequest) Reset() {
+	*x = RenameReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenameReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameReadingListRequest) ProtoMessage() {}
+
+func (x *RenameReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameReadingListRequest.ProtoReflect.Descriptor instead.
+func (*RenameReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RenameReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *RenameReadingListRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type RenameReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *RenameReadingListResponse) Reset() {
+	*x = RenameReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenameReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameReadingListResponse) ProtoMessage() {}
+
+func (x *RenameReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameReadingListResponse.ProtoReflect.Descriptor instead.
+func (*RenameReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RenameReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type DeleteReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+}
+
+func (x *DeleteReadingListRequest) Reset() {
+	*x = DeleteReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReadingListRequest) ProtoMessage() {}
+
+func (x *DeleteReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReadingListRequest.ProtoReflect.Descriptor instead.
+func (*DeleteReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+type DeleteReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+}
+
+func (x *DeleteReadingListResponse) Reset() {
+	*x = DeleteReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReadingListResponse) ProtoMessage() {}
+
+func (x *DeleteReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReadingListResponse.ProtoReflect.Descriptor instead.
+func (*DeleteReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteReadingListResponse) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+type AddToReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	BlogId string `protobuf:"bytes,2,opt,name=blog_id,json=blogId,proto3" json:"blog_id,omitempty"`
+	// 1-based, 0 or a position past the end appends the blog
+	Position int32 `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+}
+
+func (x *AddToReadingListRequest) Reset() {
+	*x = AddToReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddToReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddToReadingListRequest) ProtoMessage() {}
+
+func (x *AddToReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddToReadingListRequest.ProtoReflect.Descriptor instead.
+func (*AddToReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AddToReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *AddToReadingListRequest) GetBlogId() string {
+	if x != nil {
+		return x.BlogId
+	}
+	return ""
+}
+
+func (x *AddToReadingListRequest) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+type AddToReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *AddToReadingListResponse) Reset() {
+	*x = AddToReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddToReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddToReadingListResponse) ProtoMessage() {}
+
+func (x *AddToReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddToReadingListResponse.ProtoReflect.Descriptor instead.
+func (*AddToReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AddToReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type RemoveFromReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	BlogId string `protobuf:"bytes,2,opt,name=blog_id,json=blogId,proto3" json:"blog_id,omitempty"`
+}
+
+func (x *RemoveFromReadingListRequest) Reset() {
+	*x = RemoveFromReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoveFromReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveFromReadingListRequest) ProtoMessage() {}
+
+func (x *RemoveFromReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveFromReadingListRequest.ProtoReflect.Descriptor instead.
+func (*RemoveFromReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RemoveFromReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *RemoveFromReadingListRequest) GetBlogId() string {
+	if x != nil {
+		return x.BlogId
+	}
+	return ""
+}
+
+type RemoveFromReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *RemoveFromReadingListResponse) Reset() {
+	*x = RemoveFromReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoveFromReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveFromReadingListResponse) ProtoMessage() {}
+
+func (x *RemoveFromReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveFromReadingListResponse.ProtoReflect.Descriptor instead.
+func (*RemoveFromReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RemoveFromReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type ReorderReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	// every blog of the list in the new order
+	BlogIds []string `protobuf:"bytes,2,rep,name=blog_ids,json=blogIds,proto3" json:"blog_ids,omitempty"`
+}
+
+func (x *ReorderReadingListRequest) Reset() {
+	*x = ReorderReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReorderReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReorderReadingListRequest) ProtoMessage() {}
+
+func (x *ReorderReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReorderReadingListRequest.ProtoReflect.Descriptor instead.
+func (*ReorderReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ReorderReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *ReorderReadingListRequest) GetBlogIds() []string {
+	if x != nil {
+		return x.BlogIds
+	}
+	return nil
+}
+
+type ReorderReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *ReorderReadingListResponse) Reset() {
+	*x = ReorderReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReorderReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReorderReadingListResponse) ProtoMessage() {}
+
+func (x *ReorderReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReorderReadingListResponse.ProtoReflect.Descriptor instead.
+func (*ReorderReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ReorderReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type StreamReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+	// preferred languages of the blogs, most preferred first
+	Languages []string `protobuf:"bytes,2,rep,name=languages,proto3" json:"languages,omitempty"`
+}
+
+func (x *StreamReadingListRequest) Reset() {
+	*x = StreamReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamReadingListRequest) ProtoMessage() {}
+
+func (x *StreamReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamReadingListRequest.ProtoReflect.Descriptor instead.
+func (*StreamReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+func (x *StreamReadingListRequest) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+type StreamReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// set in the first response only
+	List    *ReadingList        `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+	Entries []*ReadingListEntry `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *StreamReadingListResponse) Reset() {
+	*x = StreamReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamReadingListResponse) ProtoMessage() {}
+
+func (x *StreamReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamReadingListResponse.ProtoReflect.Descriptor instead.
+func (*StreamReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *StreamReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+func (x *StreamReadingListResponse) GetEntries() []*ReadingListEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ShareReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+}
+
+func (x *ShareReadingListRequest) Reset() {
+	*x = ShareReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShareReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareReadingListRequest) ProtoMessage() {}
+
+func (x *ShareReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareReadingListRequest.ProtoReflect.Descriptor instead.
+func (*ShareReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ShareReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+type ShareReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+	// returned only once, only its hash is stored
+	ShareToken string `protobuf:"bytes,2,opt,name=share_token,json=shareToken,proto3" json:"share_token,omitempty"`
+}
+
+func (x *ShareReadingListResponse) Reset() {
+	*x = ShareReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShareReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareReadingListResponse) ProtoMessage() {}
+
+func (x *ShareReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareReadingListResponse.ProtoReflect.Descriptor instead.
+func (*ShareReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ShareReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+func (x *ShareReadingListResponse) GetShareToken() string {
+	if x != nil {
+		return x.ShareToken
+	}
+	return ""
+}
+
+type UnshareReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ListId string `protobuf:"bytes,1,opt,name=list_id,json=listId,proto3" json:"list_id,omitempty"`
+}
+
+func (x *UnshareReadingListRequest) Reset() {
+	*x = UnshareReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnshareReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnshareReadingListRequest) ProtoMessage() {}
+
+func (x *UnshareReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnshareReadingListRequest.ProtoReflect.Descriptor instead.
+func (*UnshareReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UnshareReadingListRequest) GetListId() string {
+	if x != nil {
+		return x.ListId
+	}
+	return ""
+}
+
+type UnshareReadingListResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	List *ReadingList `protobuf:"bytes,1,opt,name=list,proto3" json:"list,omitempty"`
+}
+
+func (x *UnshareReadingListResponse) Reset() {
+	*x = UnshareReadingListResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnshareReadingListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnshareReadingListResponse) ProtoMessage() {}
+
+func (x *UnshareReadingListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnshareReadingListResponse.ProtoReflect.Descriptor instead.
+func (*UnshareReadingListResponse) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UnshareReadingListResponse) GetList() *ReadingList {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type StreamSharedReadingListRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShareToken string   `protobuf:"bytes,1,opt,name=share_token,json=shareToken,proto3" json:"share_token,omitempty"`
+	Languages  []string `protobuf:"bytes,2,rep,name=languages,proto3" json:"languages,omitempty"`
+}
+
+func (x *StreamSharedReadingListRequest) Reset() {
+	*x = StreamSharedReadingListRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_blog_proto_reading_list_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamSharedReadingListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSharedReadingListRequest) ProtoMessage() {}
+
+func (x *StreamSharedReadingListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blog_proto_reading_list_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSharedReadingListRequest.ProtoReflect.Descriptor instead.
+func (*StreamSharedReadingListRequest) Descriptor() ([]byte, []int) {
+	return file_blog_proto_reading_list_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StreamSharedReadingListRequest) GetShareToken() string {
+	if x != nil {
+		return x.ShareToken
+	}
+	return ""
+}
+
+func (x *StreamSharedReadingListRequest) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+var File_blog_proto_reading_list_proto protoreflect.FileDescriptor
+
+var file_blog_proto_reading_list_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x62, 0x6c, 0x6f, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x72, 0x65, 0x61,
+	0x64, 0x69, 0x6e, 0x67, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x04, 0x62, 0x6c, 0x6f, 0x67, 0x1a, 0x15, 0x62, 0x6c, 0x6f, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2f, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x76,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe9, 0x01, 0x0a, 0x0b, 0x52, 0x65, 0x61, 0x64, 0x69,
+	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x42, 0x09, 0xca, 0xf3, 0x18, 0x05, 0x08, 0x01, 0x18, 0xc8, 0x01, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x6c, 0x6f, 0x67, 0x5f, 0x69, 0x64,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x62, 0x6c, 0x6f, 0x67, 0x49, 0x64, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x06, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x12, 0x3b, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3b, 0x0a, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x54, 0x69,
+	0x6d, 0x65, 0x22, 0x99, 0x02, 0x0a, 0x10, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x17, 0x0a, 0x07, 0x62, 0x6c, 0x6f, 0x67, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x67, 0x49, 0x64,
+	0x12, 0x32, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x1c, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x1e, 0x0a, 0x04, 0x62, 0x6c,
+	0x6f, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e,
+	0x42, 0x6c, 0x6f, 0x67, 0x52, 0x04, 0x62, 0x6c, 0x6f, 0x67, 0x12, 0x35, 0x0a, 0x08, 0x61, 0x64,
+	0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x61, 0x64, 0x64, 0x54, 0x69, 0x6d,
+	0x65, 0x22, 0x4b, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x15, 0x0a, 0x11, 0x53, 0x54,
+	0x41, 0x54, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x0d, 0x0a, 0x09, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01,
+	0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10,
+	0x02, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x03, 0x22, 0x49,
+	0x0a, 0x18, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c,
+	0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x04, 0x6c, 0x69,
+	0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e,
+	0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x06, 0xca, 0xf3, 0x18,
+	0x02, 0x08, 0x01, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x42, 0x0a, 0x19, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64,
+	0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x19, 0x0a,
+	0x17, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x43, 0x0a, 0x18, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x05, 0x6c, 0x69, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x69,
+	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x05, 0x6c, 0x69, 0x73, 0x74, 0x73, 0x22, 0x5a, 0x0a,
+	0x18, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x6c, 0x69, 0x73,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca, 0xf3, 0x18, 0x02,
+	0x08, 0x01, 0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x09, 0xca, 0xf3, 0x18, 0x05, 0x08, 0x01,
+	0x18, 0xc8, 0x01, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x42, 0x0a, 0x19, 0x52, 0x65, 0x6e,
+	0x61, 0x6d, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64,
+	0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x3b, 0x0a,
+	0x18, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x6c, 0x69, 0x73,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca, 0xf3, 0x18, 0x02,
+	0x08, 0x01, 0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x22, 0x34, 0x0a, 0x19, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x6c, 0x69, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64,
+	0x22, 0x88, 0x01, 0x0a, 0x17, 0x41, 0x64, 0x64, 0x54, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07,
+	0x6c, 0x69, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca,
+	0xf3, 0x18, 0x02, 0x08, 0x01, 0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1f, 0x0a,
+	0x07, 0x62, 0x6c, 0x6f, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06,
+	0xca, 0xf3, 0x18, 0x02, 0x08, 0x01, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x67, 0x49, 0x64, 0x12, 0x2b,
+	0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x42, 0x0f, 0xca, 0xf3, 0x18, 0x0b, 0x2a, 0x09, 0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x41, 0x0a, 0x18, 0x41,
+	0x64, 0x64, 0x54, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61,
+	0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x60,
+	0x0a, 0x1c, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52, 0x65, 0x61, 0x64,
+	0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f,
+	0x0a, 0x07, 0x6c, 0x69, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42,
+	0x06, 0xca, 0xf3, 0x18, 0x02, 0x08, 0x01, 0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x12,
+	0x1f, 0x0a, 0x07, 0x62, 0x6c, 0x6f, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x42, 0x06, 0xca, 0xf3, 0x18, 0x02, 0x08, 0x01, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x67, 0x49, 0x64,
+	0x22, 0x46, 0x0a, 0x1d, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x60, 0x0a, 0x19, 0x52, 0x65, 0x6f, 0x72,
+	0x64, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x6c, 0x69, 0x73, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca, 0xf3, 0x18, 0x02, 0x08, 0x01, 0x52, 0x06,
+	0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x12, 0x22, 0x0a, 0x08, 0x62, 0x6c, 0x6f, 0x67, 0x5f, 0x69,
+	0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x42, 0x07, 0xca, 0xf3, 0x18, 0x03, 0x38, 0xe8,
+	0x07, 0x52, 0x07, 0x62, 0x6c, 0x6f, 0x67, 0x49, 0x64, 0x73, 0x22, 0x43, 0x0a, 0x1a, 0x52, 0x65,
+	0x6f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22,
+	0x63, 0x0a, 0x18, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67,
+	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x6c,
+	0x69, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca, 0xf3,
+	0x18, 0x02, 0x08, 0x01, 0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x09,
+	0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x42,
+	0x08, 0xca, 0xf3, 0x18, 0x04, 0x18, 0x23, 0x38, 0x0a, 0x52, 0x09, 0x6c, 0x61, 0x6e, 0x67, 0x75,
+	0x61, 0x67, 0x65, 0x73, 0x22, 0x74, 0x0a, 0x19, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72,
+	0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x62, 0x6c, 0x6f, 0x67,
+	0x2e, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x3a, 0x0a, 0x17, 0x53, 0x68,
+	0x61, 0x72, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x6c, 0x69, 0x73, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca, 0xf3, 0x18, 0x02, 0x08, 0x01, 0x52, 0x06,
+	0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x22, 0x62, 0x0a, 0x18, 0x53, 0x68, 0x61, 0x72, 0x65, 0x52,
+	0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c,
+	0x69, 0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x68, 0x61,
+	0x72, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x73, 0x68, 0x61, 0x72, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x3c, 0x0a, 0x19, 0x55, 0x6e,
+	0x73, 0x68, 0x61, 0x72, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x07, 0x6c, 0x69, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x06, 0xca, 0xf3, 0x18, 0x02, 0x08, 0x01,
+	0x52, 0x06, 0x6c, 0x69, 0x73, 0x74, 0x49, 0x64, 0x22, 0x43, 0x0a, 0x1a, 0x55, 0x6e, 0x73, 0x68,
+	0x61, 0x72, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x61, 0x64,
+	0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x74, 0x0a,
+	0x1e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x68, 0x61, 0x72, 0x65, 0x64, 0x52, 0x65, 0x61,
+	0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x2a, 0x0a, 0x0b, 0x73, 0x68, 0x61, 0x72, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x42, 0x09, 0xca, 0xf3, 0x18, 0x05, 0x08, 0x01, 0x18, 0xf4, 0x03, 0x52,
+	0x0a, 0x73, 0x68, 0x61, 0x72, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x26, 0x0a, 0x09, 0x6c,
+	0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x42, 0x08,
+	0xca, 0xf3, 0x18, 0x04, 0x18, 0x23, 0x38, 0x0a, 0x52, 0x09, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61,
+	0x67, 0x65, 0x73, 0x32, 0xf5, 0x07, 0x0a, 0x12, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c,
+	0x69, 0x73, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x56, 0x0a, 0x11, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12,
+	0x1e, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x61,
+	0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1f, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x65, 0x61,
+	0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x53, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x73, 0x12, 0x1d, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x56, 0x0a, 0x11, 0x52, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1e, 0x2e, 0x62,
+	0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x62,
+	0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x56, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67,
+	0x4c, 0x69, 0x73, 0x74, 0x12, 0x1e, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x10, 0x41, 0x64, 0x64, 0x54, 0x6f,
+	0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1d, 0x2e, 0x62, 0x6c,
+	0x6f, 0x67, 0x2e, 0x41, 0x64, 0x64, 0x54, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c,
+	0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x62, 0x6c, 0x6f,
+	0x67, 0x2e, 0x41, 0x64, 0x64, 0x54, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x62, 0x0a, 0x15,
+	0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x22, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65, 0x6d,
+	0x6f, 0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69,
+	0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x62, 0x6c, 0x6f, 0x67,
+	0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x69,
+	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x12, 0x59, 0x0a, 0x12, 0x52, 0x65, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x69,
+	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1f, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52, 0x65,
+	0x6f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x52,
+	0x65, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73,
+	0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x58, 0x0a, 0x11, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x1e, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1f, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x53, 0x0a, 0x10, 0x53, 0x68, 0x61, 0x72, 0x65, 0x52, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x1d, 0x2e, 0x62, 0x6c, 0x6f, 0x67,
+	0x2e, 0x53, 0x68, 0x61, 0x72, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e,
+	0x53, 0x68, 0x61, 0x72, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x59, 0x0a, 0x12, 0x55, 0x6e,
+	0x73, 0x68, 0x61, 0x72, 0x65, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x1f, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x55, 0x6e, 0x73, 0x68, 0x61, 0x72, 0x65, 0x52,
+	0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x20, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x55, 0x6e, 0x73, 0x68, 0x61, 0x72, 0x65,
+	0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x17, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53,
+	0x68, 0x61, 0x72, 0x65, 0x64, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x24, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x68,
+	0x61, 0x72, 0x65, 0x64, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x62, 0x6c, 0x6f, 0x67, 0x2e, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0d, 0x5a, 0x0b, 0x2f,
+	0x62, 0x6c, 0x6f, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_blog_proto_reading_list_proto_rawDescOnce sync.Once
+	file_blog_proto_reading_list_proto_rawDescData = file_blog_proto_reading_list_proto_rawDesc
+)
+
+func file_blog_proto_reading_list_proto_rawDescGZIP() []byte {
+	file_blog_proto_reading_list_proto_rawDescOnce.Do(func() {
+		file_blog_proto_reading_list_proto_rawDescData = protoimpl.X.CompressGZIP(file_blog_proto_reading_list_proto_rawDescData)
+	})
+	return file_blog_proto_reading_list_proto_rawDescData
+}
+
+var file_blog_proto_reading_list_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_blog_proto_reading_list_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_blog_proto_reading_list_proto_goTypes = []interface{}{
+	(ReadingListEntry_State)(0),            // 0: blog.ReadingListEntry.State
+	(*ReadingList)(nil),                    // 1: blog.ReadingList
+	(*ReadingListEntry)(nil),               // 2: blog.ReadingListEntry
+	(*CreateReadingListRequest)(nil),       // 3: blog.CreateReadingListRequest
+	(*CreateReadingListResponse)(nil),      // 4: blog.CreateReadingListResponse
+	(*ListReadingListsRequest)(nil),        // 5: blog.ListReadingListsRequest
+	(*ListReadingListsResponse)(nil),       // 6: blog.ListReadingListsResponse
+	(*RenameReadingListRequest)(nil),       // 7: blog.RenameReadingListRequest
+	(*RenameReadingListResponse)(nil),      // 8: blog.RenameReadingListResponse
+	(*DeleteReadingListRequest)(nil),       // 9: blog.DeleteReadingListRequest
+	(*DeleteReadingListResponse)(nil),      // 10: blog.DeleteReadingListResponse
+	(*AddToReadingListRequest)(nil),        // 11: blog.AddToReadingListRequest
+	(*AddToReadingListResponse)(nil),       // 12: blog.AddToReadingListResponse
+	(*RemoveFromReadingListRequest)(nil),   // 13: blog.RemoveFromReadingListRequest
+	(*RemoveFromReadingListResponse)(nil),  // 14: blog.RemoveFromReadingListResponse
+	(*ReorderReadingListRequest)(nil),      // 15: blog.ReorderReadingListRequest
+	(*ReorderReadingListResponse)(nil),     // 16: blog.ReorderReadingListResponse
+	(*StreamReadingListRequest)(nil),       // 17: blog.StreamReadingListRequest
+	(*StreamReadingListResponse)(nil),      // 18: blog.StreamReadingListResponse
+	(*ShareReadingListRequest)(nil),        // 19: blog.ShareReadingListRequest
+	(*ShareReadingListResponse)(nil),       // 20: blog.ShareReadingListResponse
+	(*UnshareReadingListRequest)(nil),      // 21: blog.UnshareReadingListRequest
+	(*UnshareReadingListResponse)(nil),     // 22: blog.UnshareReadingListResponse
+	(*StreamSharedReadingListRequest)(nil), // 23: blog.StreamSharedReadingListRequest
+	(*timestamppb.Timestamp)(nil),          // 24: google.protobuf.Timestamp
+	(*Blog)(nil),                           // 25: blog.Blog
+}
+var file_blog_proto_reading_list_proto_depIdxs = []int32{
+	24, // 0: blog.ReadingList.create_time:type_name -> google.protobuf.Timestamp
+	24, // 1: blog.ReadingList.update_time:type_name -> google.protobuf.Timestamp
+	0,  // 2: blog.ReadingListEntry.state:type_name -> blog.ReadingListEntry.State
+	25, // 3: blog.ReadingListEntry.blog:type_name -> blog.Blog
+	24, // 4: blog.ReadingListEntry.add_time:type_name -> google.protobuf.Timestamp
+	1,  // 5: blog.CreateReadingListRequest.list:type_name -> blog.ReadingList
+	1,  // 6: blog.CreateReadingListResponse.list:type_name -> blog.ReadingList
+	1,  // 7: blog.ListReadingListsResponse.lists:type_name -> blog.ReadingList
+	1,  // 8: blog.RenameReadingListResponse.list:type_name -> blog.ReadingList
+	1,  // 9: blog.AddToReadingListResponse.list:type_name -> blog.ReadingList
+	1,  // 10: blog.RemoveFromReadingListResponse.list:type_name -> blog.ReadingList
+	1,  // 11: blog.ReorderReadingListResponse.list:type_name -> blog.ReadingList
+	1,  // 12: blog.StreamReadingListResponse.list:type_name -> blog.ReadingList
+	2,  // 13: blog.StreamReadingListResponse.entries:type_name -> blog.ReadingListEntry
+	1,  // 14: blog.ShareReadingListResponse.list:type_name -> blog.ReadingList
+	1,  // 15: blog.UnshareReadingListResponse.list:type_name -> blog.ReadingList
+	3,  // 16: blog.ReadingListService.CreateReadingList:input_type -> blog.CreateReadingListRequest
+	5,  // 17: blog.ReadingListService.ListReadingLists:input_type -> blog.ListReadingListsRequest
+	7,  // 18: blog.ReadingListService.RenameReadingList:input_type -> blog.RenameReadingListRequest
+	9,  // 19: blog.ReadingListService.DeleteReadingList:input_type -> blog.DeleteReadingListRequest
+	11, // 20: blog.ReadingListService.AddToReadingList:input_type -> blog.AddToReadingListRequest
+	13, // 21: blog.ReadingListService.RemoveFromReadingList:input_type -> blog.RemoveFromReadingListRequest
+	15, // 22: blog.ReadingListService.ReorderReadingList:input_type -> blog.ReorderReadingListRequest
+	17, // 23: blog.ReadingListService.StreamReadingList:input_type -> blog.StreamReadingListRequest
+	19, // 24: blog.ReadingListService.ShareReadingList:input_type -> blog.ShareReadingListRequest
+	21, // 25: blog.ReadingListService.UnshareReadingList:input_type -> blog.UnshareReadingListRequest
+	23, // 26: blog.ReadingListService.StreamSharedReadingList:input_type -> blog.StreamSharedReadingListRequest
+	4,  // 27: blog.ReadingListService.CreateReadingList:output_type -> blog.CreateReadingListResponse
+	6,  // 28: blog.ReadingListService.ListReadingLists:output_type -> blog.ListReadingListsResponse
+	8,  // 29: blog.ReadingListService.RenameReadingList:output_type -> blog.RenameReadingListResponse
+	10, // 30: blog.ReadingListService.DeleteReadingList:output_type -> blog.DeleteReadingListResponse
+	12, // 31: blog.ReadingListService.AddToReadingList:output_type -> blog.AddToReadingListResponse
+	14, // 32: blog.ReadingListService.RemoveFromReadingList:output_type -> blog.RemoveFromReadingListResponse
+	16, // 33: blog.ReadingListService.ReorderReadingList:output_type -> blog.ReorderReadingListResponse
+	18, // 34: blog.ReadingListService.StreamReadingList:output_type -> blog.StreamReadingListResponse
+	20, // 35: blog.ReadingListService.ShareReadingList:output_type -> blog.ShareReadingListResponse
+	22, // 36: blog.ReadingListService.UnshareReadingList:output_type -> blog.UnshareReadingListResponse
+	18, // 37: blog.ReadingListService.StreamSharedReadingList:output_type -> blog.StreamReadingListResponse
+	27, // [27:38] is the sub-list for method output_type
+	16, // [16:27] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_blog_proto_reading_list_proto_init() }
+func file_blog_proto_reading_list_proto_init() {
+	if File_blog_proto_reading_list_proto != nil {
+		return
+	}
+	file_blog_proto_blog_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_blog_proto_reading_list_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadingList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadingListEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CreateReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListReadingListsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListReadingListsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenameReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenameReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddToReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddToReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemoveFromReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemoveFromReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReorderReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReorderReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShareReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShareReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnshareReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnshareReadingListResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_blog_proto_reading_list_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamSharedReadingListRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_blog_proto_reading_list_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_blog_proto_reading_list_proto_goTypes,
+		DependencyIndexes: file_blog_proto_reading_list_proto_depIdxs,
+		EnumInfos:         file_blog_proto_reading_list_proto_enumTypes,
+		MessageInfos:      file_blog_proto_reading_list_proto_msgTypes,
+	}.Build()
+	File_blog_proto_reading_list_proto = out.File
+	file_blog_proto_reading_list_proto_rawDesc = nil
+	file_blog_proto_reading_list_proto_goTypes = nil
+	file_blog_proto_reading_list_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ReadingListServiceClient is the client API for ReadingListService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ReadingListServiceClient interface {
+	CreateReadingList(ctx context.Context, in *CreateReadingListRequest, opts ...grpc.CallOption) (*CreateReadingListResponse, error)
+	// ListReadingLists lists the lists of the reader oldest first, StreamReadingList sends their entries
+	ListReadingLists(ctx context.Context, in *ListReadingListsRequest, opts ...grpc.CallOption) (*ListReadingListsResponse, error)
+	RenameReadingList(ctx context.Context, in *RenameReadingListRequest, opts ...grpc.CallOption) (*RenameReadingListResponse, error)
+	DeleteReadingList(ctx context.Context, in *DeleteReadingListRequest, opts ...grpc.CallOption) (*DeleteReadingListResponse, error)
+	// AddToReadingList saves a published blog
+	AddToReadingList(ctx context.Context, in *AddToReadingListRequest, opts ...grpc.CallOption) (*AddToReadingListResponse, error)
+	RemoveFromReadingList(ctx context.Context, in *RemoveFromReadingListRequest, opts ...grpc.CallOption) (*RemoveFromReadingListResponse, error)
+	ReorderReadingList(ctx context.Context, in *ReorderReadingListRequest, opts ...grpc.CallOption) (*ReorderReadingListResponse, error)
+	// StreamReadingList sends the entries of a list in order, joined with the current blogs
+	StreamReadingList(ctx context.Context, in *StreamReadingListRequest, opts ...grpc.CallOption) (ReadingListService_StreamReadingListClient, error)
+	// ShareReadingList returns a new share token of the list, tokens issued before stop working
+	ShareReadingList(ctx context.Context, in *ShareReadingListRequest, opts ...grpc.CallOption) (*ShareReadingListResponse, error)
+	// UnshareReadingList makes the list private again
+	UnshareReadingList(ctx context.Context, in *UnshareReadingListRequest, opts ...grpc.CallOption) (*UnshareReadingListResponse, error)
+	// StreamSharedReadingList works like StreamReadingList for anyone with the share token, no api key is needed
+	StreamSharedReadingList(ctx context.Context, in *StreamSharedReadingListRequest, opts ...grpc.CallOption) (ReadingListService_StreamSharedReadingListClient, error)
+}
+
+type readingListServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReadingListServiceClient(cc grpc.ClientConnInterface) ReadingListServiceClient {
+	return &readingListServiceClient{cc}
+}
+
+func (c *readingListServiceClient) CreateReadingList(ctx context.Context, in *CreateReadingListRequest, opts ...grpc.CallOption) (*CreateReadingListResponse, error) {
+	out := new(CreateReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/CreateReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) ListReadingLists(ctx context.Context, in *ListReadingListsRequest, opts ...grpc.CallOption) (*ListReadingListsResponse, error) {
+	out := new(ListReadingListsResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/ListReadingLists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) RenameReadingList(ctx context.Context, in *RenameReadingListRequest, opts ...grpc.CallOption) (*RenameReadingListResponse, error) {
+	out := new(RenameReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/RenameReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) DeleteReadingList(ctx context.Context, in *DeleteReadingListRequest, opts ...grpc.CallOption) (*DeleteReadingListResponse, error) {
+	out := new(DeleteReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/DeleteReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) AddToReadingList(ctx context.Context, in *AddToReadingListRequest, opts ...grpc.CallOption) (*AddToReadingListResponse, error) {
+	out := new(AddToReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/AddToReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) RemoveFromReadingList(ctx context.Context, in *RemoveFromReadingListRequest, opts ...grpc.CallOption) (*RemoveFromReadingListResponse, error) {
+	out := new(RemoveFromReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/RemoveFromReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) ReorderReadingList(ctx context.Context, in *ReorderReadingListRequest, opts ...grpc.CallOption) (*ReorderReadingListResponse, error) {
+	out := new(ReorderReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/ReorderReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) StreamReadingList(ctx context.Context, in *StreamReadingListRequest, opts ...grpc.CallOption) (ReadingListService_StreamReadingListClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ReadingListService_serviceDesc.Streams[0], "/blog.ReadingListService/StreamReadingList", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &readingListServiceStreamReadingListClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ReadingListService_StreamReadingListClient interface {
+	Recv() (*StreamReadingListResponse, error)
+	grpc.ClientStream
+}
+
+type readingListServiceStreamReadingListClient struct {
+	grpc.ClientStream
+}
+
+func (x *readingListServiceStreamReadingListClient) Recv() (*StreamReadingListResponse, error) {
+	m := new(StreamReadingListResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *readingListServiceClient) ShareReadingList(ctx context.Context, in *ShareReadingListRequest, opts ...grpc.CallOption) (*ShareReadingListResponse, error) {
+	out := new(ShareReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/ShareReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) UnshareReadingList(ctx context.Context, in *UnshareReadingListRequest, opts ...grpc.CallOption) (*UnshareReadingListResponse, error) {
+	out := new(UnshareReadingListResponse)
+	err := c.cc.Invoke(ctx, "/blog.ReadingListService/UnshareReadingList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readingListServiceClient) StreamSharedReadingList(ctx context.Context, in *StreamSharedReadingListRequest, opts ...grpc.CallOption) (ReadingListService_StreamSharedReadingListClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ReadingListService_serviceDesc.Streams[1], "/blog.ReadingListService/StreamSharedReadingList", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &readingListServiceStreamSharedReadingListClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ReadingListService_StreamSharedReadingListClient interface {
+	Recv() (*StreamReadingListResponse, error)
+	grpc.ClientStream
+}
+
+type readingListServiceStreamSharedReadingListClient struct {
+	grpc.ClientStream
+}
+
+func (x *readingListServiceStreamSharedReadingListClient) Recv() (*StreamReadingListResponse, error) {
+	m := new(StreamReadingListResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReadingListServiceServer is the server API for ReadingListService service.
+type ReadingListServiceServer interface {
+	CreateReadingList(context.Context, *CreateReadingListRequest) (*CreateReadingListResponse, error)
+	// ListReadingLists lists the lists of the reader oldest first, StreamReadingList sends their entries
+	ListReadingLists(context.Context, *ListReadingListsRequest) (*ListReadingListsResponse, error)
+	RenameReadingList(context.Context, *RenameReadingListRequest) (*RenameReadingListResponse, error)
+	DeleteReadingList(context.Context, *DeleteReadingListRequest) (*DeleteReadingListResponse, error)
+	// AddToReadingList saves a published blog
+	AddToReadingList(context.Context, *AddToReadingListRequest) (*AddToReadingListResponse, error)
+	RemoveFromReadingList(context.Context, *RemoveFromReadingListRequest) (*RemoveFromReadingListResponse, error)
+	ReorderReadingList(context.Context, *ReorderReadingListRequest) (*ReorderReadingListResponse, error)
+	// StreamReadingList sends the entries of a list in order, joined with the current blogs
+	StreamReadingList(*StreamReadingListRequest, ReadingListService_StreamReadingListServer) error
+	// ShareReadingList returns a new share token of the list, tokens issued before stop working
+	ShareReadingList(context.Context, *ShareReadingListRequest) (*ShareReadingListResponse, error)
+	// UnshareReadingList makes the list private again
+	UnshareReadingList(context.Context, *UnshareReadingListRequest) (*UnshareReadingListResponse, error)
+	// StreamSharedReadingList works like StreamReadingList for anyone with the share token, no api key is needed
+	StreamSharedReadingList(*StreamSharedReadingListRequest, ReadingListService_StreamSharedReadingListServer) error
+}
+
+// UnimplementedReadingListServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedReadingListServiceServer struct {
+}
+
+func (*UnimplementedReadingListServiceServer) CreateReadingList(context.Context, *CreateReadingListRequest) (*CreateReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) ListReadingLists(context.Context, *ListReadingListsRequest) (*ListReadingListsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReadingLists not implemented")
+}
+func (*UnimplementedReadingListServiceServer) RenameReadingList(context.Context, *RenameReadingListRequest) (*RenameReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) DeleteReadingList(context.Context, *DeleteReadingListRequest) (*DeleteReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) AddToReadingList(context.Context, *AddToReadingListRequest) (*AddToReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddToReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) RemoveFromReadingList(context.Context, *RemoveFromReadingListRequest) (*RemoveFromReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFromReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) ReorderReadingList(context.Context, *ReorderReadingListRequest) (*ReorderReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReorderReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) StreamReadingList(*StreamReadingListRequest, ReadingListService_StreamReadingListServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) ShareReadingList(context.Context, *ShareReadingListRequest) (*ShareReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) UnshareReadingList(context.Context, *UnshareReadingListRequest) (*UnshareReadingListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnshareReadingList not implemented")
+}
+func (*UnimplementedReadingListServiceServer) StreamSharedReadingList(*StreamSharedReadingListRequest, ReadingListService_StreamSharedReadingListServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSharedReadingList not implemented")
+}
+
+func RegisterReadingListServiceServer(s *grpc.Server, srv ReadingListServiceServer) {
+	s.RegisterService(&_ReadingListService_serviceDesc, srv)
+}
+
+func _ReadingListService_CreateReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).CreateReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/CreateReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).CreateReadingList(ctx, req.(*CreateReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_ListReadingLists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReadingListsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).ListReadingLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/ListReadingLists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).ListReadingLists(ctx, req.(*ListReadingListsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_RenameReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).RenameReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/RenameReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).RenameReadingList(ctx, req.(*RenameReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_DeleteReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).DeleteReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/DeleteReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).DeleteReadingList(ctx, req.(*DeleteReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_AddToReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddToReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).AddToReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/AddToReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).AddToReadingList(ctx, req.(*AddToReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_RemoveFromReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveFromReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).RemoveFromReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/RemoveFromReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).RemoveFromReadingList(ctx, req.(*RemoveFromReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_ReorderReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReorderReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).ReorderReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/ReorderReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).ReorderReadingList(ctx, req.(*ReorderReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_StreamReadingList_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamReadingListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReadingListServiceServer).StreamReadingList(m, &readingListServiceStreamReadingListServer{stream})
+}
+
+type ReadingListService_StreamReadingListServer interface {
+	Send(*StreamReadingListResponse) error
+	grpc.ServerStream
+}
+
+type readingListServiceStreamReadingListServer struct {
+	grpc.ServerStream
+}
+
+func (x *readingListServiceStreamReadingListServer) Send(m *StreamReadingListResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ReadingListService_ShareReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).ShareReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/ShareReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).ShareReadingList(ctx, req.(*ShareReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_UnshareReadingList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnshareReadingListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReadingListServiceServer).UnshareReadingList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blog.ReadingListService/UnshareReadingList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReadingListServiceServer).UnshareReadingList(ctx, req.(*UnshareReadingListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReadingListService_StreamSharedReadingList_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSharedReadingListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReadingListServiceServer).StreamSharedReadingList(m, &readingListServiceStreamSharedReadingListServer{stream})
+}
+
+type ReadingListService_StreamSharedReadingListServer interface {
+	Send(*StreamReadingListResponse) error
+	grpc.ServerStream
+}
+
+type readingListServiceStreamSharedReadingListServer struct {
+	grpc.ServerStream
+}
+
+func (x *readingListServiceStreamSharedReadingListServer) Send(m *StreamReadingListResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _ReadingListService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "blog.ReadingListService",
+	HandlerType: (*ReadingListServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateReadingList",
+			Handler:    _ReadingListService_CreateReadingList_Handler,
+		},
+		{
+			MethodName: "ListReadingLists",
+			Handler:    _ReadingListService_ListReadingLists_Handler,
+		},
+		{
+			MethodName: "RenameReadingList",
+			Handler:    _ReadingListService_RenameReadingList_Handler,
+		},
+		{
+			MethodName: "DeleteReadingList",
+			Handler:    _ReadingListService_DeleteReadingList_Handler,
+		},
+		{
+			MethodName: "AddToReadingList",
+			Handler:    _ReadingListService_AddToReadingList_Handler,
+		},
+		{
+			MethodName: "RemoveFromReadingList",
+			Handler:    _ReadingListService_RemoveFromReadingList_Handler,
+		},
+		{
+			MethodName: "ReorderReadingList",
+			Handler:    _ReadingListService_ReorderReadingList_Handler,
+		},
+		{
+			MethodName: "ShareReadingList",
+			Handler:    _ReadingListService_ShareReadingList_Handler,
+		},
+		{
+			MethodName: "UnshareReadingList",
+			Handler:    _ReadingListService_UnshareReadingList_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamReadingList",
+			Handler:       _ReadingListService_StreamReadingList_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamSharedReadingList",
+			Handler:       _ReadingListService_StreamSharedReadingList_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "blog/proto/reading_list.proto",
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	pb "github.com/dbielecki97/grpc-go-course/calculator/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -13,7 +14,10 @@ import (
 )
 
 func main() {
-	cc, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
+	cc, err := grpc.Dial("localhost:50051", grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(validation.UnaryClient()),
+		grpc.WithStreamInterceptor(validation.StreamClient()),
+	)
 	if err != nil {
 		log.Fatalf("Could not dial: %v", err)
 	}
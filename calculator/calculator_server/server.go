@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	pb "github.com/dbielecki97/grpc-go-course/calculator/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/validation"
+	"github.com/dbielecki97/grpc-go-course/validate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
@@ -19,7 +22,13 @@ type server struct {
 
 func (s server) Sum(ctx context.Context, r *pb.SumRequest) (*pb.SumResponse, error) {
 	fmt.Printf("Greet request was invoked with: %v\n", r)
-	return &pb.SumResponse{Sum: r.A + r.B}, nil
+	sum := int64(r.A) + int64(r.B)
+	if sum > math.MaxInt32 || sum < math.MinInt32 {
+		return nil, &validate.Error{Violations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "b", Description: fmt.Sprintf("sum of a and b does not fit in int32, %d", sum)},
+		}}
+	}
+	return &pb.SumResponse{Sum: int32(sum)}, nil
 }
 
 func (s server) Decompose(r *pb.DecomposeRequest, stream pb.Calculator_DecomposeServer) error {
@@ -97,7 +106,10 @@ func main() {
 		log.Fatalf("Could not lister: %v", err)
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(validation.UnaryServer()),
+		grpc.StreamInterceptor(validation.StreamServer()),
+	)
 	reflection.Register(s)
 	pb.RegisterCalculatorServer(s, &server{})
 
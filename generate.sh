@@ -1,5 +1,11 @@
 #!/bin/bash
+protoc --go_out=paths=source_relative:. validate/validate.proto
 protoc --go_out=plugins=grpc:. greet/proto/greet.proto
 protoc --go_out=plugins=grpc:. calculator/proto/calculator.proto
 protoc --go_out=plugins=grpc:. blog/proto/blog.proto
+protoc --go_out=plugins=grpc:. blog/proto/admin.proto
 
+protoc --go_out=plugins=grpc:. blog/proto/webhook.proto
+protoc --go_out=plugins=grpc:. blog/proto/moderation.proto
+protoc --go_out=plugins=grpc:. blog/proto/reading_list.proto
+protoc --go_out=plugins=grpc:. blog/proto/blog_stats.proto
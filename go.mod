@@ -3,7 +3,14 @@ module github.com/dbielecki97/grpc-go-course
 go 1.16
 
 require (
+	github.com/lib/pq v1.10.2
+	github.com/mattn/go-sqlite3 v1.14.7
+	go.etcd.io/bbolt v1.3.5
 	go.mongodb.org/mongo-driver v1.5.3
+	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+	golang.org/x/text v0.3.5
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.38.0
 	google.golang.org/protobuf v1.26.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	pb "github.com/dbielecki97/grpc-go-course/greet/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -27,7 +28,10 @@ func main() {
 		opts = grpc.WithTransportCredentials(creds)
 	}
 
-	cc, err := grpc.Dial("localhost:50051", opts)
+	cc, err := grpc.Dial("localhost:50051", opts,
+		grpc.WithUnaryInterceptor(validation.UnaryClient()),
+		grpc.WithStreamInterceptor(validation.StreamClient()),
+	)
 	if err != nil {
 		log.Fatalf("Could not connect: %v", err)
 	}
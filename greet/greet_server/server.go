@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	pb "github.com/dbielecki97/grpc-go-course/greet/proto"
+	"github.com/dbielecki97/grpc-go-course/interceptor/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -44,12 +45,11 @@ func (s server) LongGreet(stream pb.GreetService_LongGreetServer) error {
 			if err == io.EOF {
 				return stream.SendAndClose(&pb.LongGreetResponse{Result: result})
 			}
-			log.Fatalf("error while reading from client: %v", err)
+			log.Printf("error while reading from client: %v", err)
+			return err
 		}
 		result += "Hello " + req.Greeting.FirstName + "\n"
 	}
-
-	return nil
 }
 
 func (s server) GreetEveryone(stream pb.GreetService_GreetEveryoneServer) error {
@@ -60,14 +60,14 @@ func (s server) GreetEveryone(stream pb.GreetService_GreetEveryoneServer) error
 			if err == io.EOF {
 				return nil
 			}
-			log.Fatalf("error while reading client stream: %v", err)
+			log.Printf("error while reading client stream: %v", err)
 			return err
 		}
 
 		result := "Hello " + req.Greeting.FirstName + "! \n"
 		err = stream.Send(&pb.GreetEveryoneResponse{Result: result})
 		if err != nil {
-			log.Fatalf("error while sending data to client: %v", err)
+			log.Printf("error while sending data to client: %v", err)
 			return err
 		}
 	}
@@ -89,7 +89,10 @@ func (s server) GreetWithDeadline(ctx context.Context, r *pb.GreetWithDeadlineRe
 func main() {
 	fmt.Println("Hello world!")
 
-	var opts []grpc.ServerOption
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(validation.UnaryServer()),
+		grpc.StreamInterceptor(validation.StreamServer()),
+	}
 	tls := false
 	if tls {
 		certFile := "ssl/server.crt"
@@ -0,0 +1,145 @@
+// Package auth authenticates requests by the bearer token in the
+// authorization metadata and puts the resulting Principal into the context.
+package auth
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// Header is the metadata key carrying "Bearer <token>".
+const Header = "authorization"
+
+// Principal is who sent a request.
+type Principal struct {
+	Name string
+	// Tenant scopes the data the principal can reach, empty for admins.
+	Tenant string
+	Admin  bool
+}
+
+// Authenticator resolves a token to a principal. It returns a status error,
+// usually Unauthenticated or PermissionDenied, when the token is not accepted.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// Interceptor rejects requests without a valid token, except for Public methods.
+// Methods starting with one of AdminPrefixes are only allowed for admins.
+type Interceptor struct {
+	Authenticator Authenticator
+	// Public are full method names that need no token, e.g. server reflection.
+	Public map[string]bool
+	// AdminPrefixes are method prefixes such as /blog.AdminService/.
+	AdminPrefixes []string
+}
+
+func New(authenticator Authenticator, adminPrefixes []string, public ...string) *Interceptor {
+	i := &Interceptor{Authenticator: authenticator, AdminPrefixes: adminPrefixes, Public: make(map[string]bool, len(public))}
+	for _, m := range public {
+		i.Public[m] = true
+	}
+	return i
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &stream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *Interceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if i.Public[method] {
+		return ctx, nil
+	}
+
+	token := tokenFromContext(ctx)
+	if token == "" {
+		return ctx, status.Errorf(codes.Unauthenticated, "missing bearer token in %s metadata", Header)
+	}
+
+	p, err := i.Authenticator.Authenticate(ctx, token)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return ctx, err
+		}
+		return ctx, status.Errorf(codes.Unauthenticated, "invalid token")
+	}
+
+	for _, prefix := range i.AdminPrefixes {
+		if strings.HasPrefix(method, prefix) && !p.Admin {
+			return ctx, status.Errorf(codes.PermissionDenied, "%s requires the admin role", method)
+		}
+	}
+
+	return NewContext(ctx, p), nil
+}
+
+func tokenFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get(Header) {
+		if len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+			return strings.TrimSpace(v[7:])
+		}
+	}
+	return ""
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of an authenticated request.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// Token sends a bearer token with every call of a client, as a
+// grpc.WithPerRPCCredentials dial option.
+type Token string
+
+func (t Token) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if t == "" {
+		return nil, nil
+	}
+	return map[string]string{Header: "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity allows tokens on plaintext connections, the servers of this repo use no TLS.
+func (t Token) RequireTransportSecurity() bool {
+	return false
+}
+
+// stream replaces the context of a server stream.
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
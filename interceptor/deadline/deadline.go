@@ -0,0 +1,62 @@
+// Package deadline gives RPCs a server-side deadline when the client did not
+// set one, so storage calls made with the request context always end.
+package deadline
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"time"
+)
+
+// Interceptor applies Timeouts per full method name and Default to the other
+// methods. A zero timeout leaves the method without a deadline.
+type Interceptor struct {
+	Default  time.Duration
+	Timeouts map[string]time.Duration
+}
+
+func New(def time.Duration, timeouts map[string]time.Duration) *Interceptor {
+	return &Interceptor{Default: def, Timeouts: timeouts}
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := i.withDeadline(ctx, info.FullMethod)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := i.withDeadline(ss.Context(), info.FullMethod)
+		defer cancel()
+		return handler(srv, &stream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *Interceptor) withDeadline(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	// the client's deadline wins, even when it is longer than ours
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	timeout, ok := i.Timeouts[method]
+	if !ok {
+		timeout = i.Default
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// stream replaces the context of a server stream.
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
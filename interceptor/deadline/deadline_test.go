@@ -0,0 +1,72 @@
+package deadline
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"testing"
+	"time"
+)
+
+// deadlineOf returns how long the handler of method has, or 0 without a deadline.
+func deadlineOf(t *testing.T, i *Interceptor, ctx context.Context, method string) time.Duration {
+	t.Helper()
+	var left time.Duration
+	_, err := i.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if d, ok := ctx.Deadline(); ok {
+			left = time.Until(d)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return left
+}
+
+func TestDeadlines(t *testing.T) {
+	i := New(time.Minute, map[string]time.Duration{"/long": time.Hour, "/unlimited": 0})
+
+	if d := deadlineOf(t, i, context.Background(), "/other"); d <= 0 || d > time.Minute {
+		t.Fatalf("default deadline is %v, want a minute", d)
+	}
+	if d := deadlineOf(t, i, context.Background(), "/long"); d <= time.Minute || d > time.Hour {
+		t.Fatalf("deadline of /long is %v, want an hour", d)
+	}
+	if d := deadlineOf(t, i, context.Background(), "/unlimited"); d != 0 {
+		t.Fatalf("deadline of /unlimited is %v, want none", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+	if d := deadlineOf(t, i, ctx, "/other"); d <= time.Hour {
+		t.Fatalf("deadline of the client was replaced by %v", d)
+	}
+}
+
+// fakeStream is a server stream with a context.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamIsCancelledOnReturn(t *testing.T) {
+	i := New(time.Minute, nil)
+	var ctx context.Context
+	err := i.Stream()(nil, &fakeStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/list"}, func(_ interface{}, ss grpc.ServerStream) error {
+		ctx = ss.Context()
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("stream has no deadline")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("context of a returned stream is %v, want cancelled", ctx.Err())
+	}
+}
@@ -0,0 +1,198 @@
+// Package idempotency replays the stored response of a unary RPC when a client
+// retries it with the same idempotency-key metadata.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"log"
+	"time"
+)
+
+const (
+	// Header is the metadata key carrying the idempotency key.
+	Header = "idempotency-key"
+	// ReplayedHeader is set on responses replayed from the store.
+	ReplayedHeader = "idempotent-replayed"
+	// MaxKeyLength rejects keys that are clearly not UUIDs or similar.
+	MaxKeyLength = 255
+	// PendingTimeout bounds how long a key stays locked by a request that never completed.
+	PendingTimeout = time.Minute
+)
+
+// Record is what the store keeps for a key.
+type Record struct {
+	// Token identifies the request that began the record.
+	Token       string
+	Fingerprint string
+	Done        bool
+	Response    *anypb.Any
+	ExpiresAt   time.Time
+}
+
+// Store keeps records of idempotency keys, it must be safe for concurrent use.
+type Store interface {
+	// Begin locks the key for a new request identified by token, when the
+	// key is already known its record is returned and nothing is changed.
+	Begin(ctx context.Context, key, token, fingerprint string, expiresAt time.Time) (*Record, error)
+	// Complete stores the response of the request that locked the key. It
+	// changes nothing when the key is no longer locked by token, e.g. after
+	// the lock expired and another request began the key.
+	Complete(ctx context.Context, key, token string, response *anypb.Any, expiresAt time.Time) error
+	// Release unlocks a key whose request failed, so it can be retried. Like
+	// Complete, it only releases the lock of token.
+	Release(ctx context.Context, key, token string) error
+}
+
+// Interceptor makes the configured unary methods idempotent. Requests without
+// the idempotency-key header are passed through unchanged.
+type Interceptor struct {
+	Store Store
+	// TTL is how long a response is replayed for.
+	TTL time.Duration
+	// Methods are the full method names, e.g. /blog.BlogService/CreateBlog.
+	Methods map[string]bool
+	// Scope, when set, separates the keys of different callers, e.g. tenants.
+	Scope func(ctx context.Context) string
+}
+
+func New(store Store, ttl time.Duration, methods ...string) *Interceptor {
+	i := &Interceptor{Store: store, TTL: ttl, Methods: make(map[string]bool, len(methods))}
+	for _, m := range methods {
+		i.Methods[m] = true
+	}
+	return i
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !i.Methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		key := keyFromContext(ctx)
+		if key == "" {
+			return handler(ctx, req)
+		}
+		if len(key) > MaxKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "%s must be at most %d characters long", Header, MaxKeyLength)
+		}
+
+		fingerprint, err := fingerprint(info.FullMethod, req)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "could not fingerprint request: %v", err)
+		}
+
+		// the same key may be used with different methods
+		key = info.FullMethod + ":" + key
+		if i.Scope != nil {
+			key = i.Scope(ctx) + ":" + key
+		}
+
+		token, err := newToken()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "could not create idempotency token: %v", err)
+		}
+		rec, err := i.Store.Begin(ctx, key, token, fingerprint, time.Now().Add(PendingTimeout))
+		if err != nil {
+			log.Printf("Could not begin idempotent request: %v", err)
+			return nil, status.Errorf(codes.Internal, "unexpected idempotency store error")
+		}
+
+		if rec != nil {
+			return replay(ctx, rec, fingerprint)
+		}
+
+		res, err := handler(ctx, req)
+		if err != nil {
+			// failed requests are not stored, a retry executes them again
+			if rErr := i.Store.Release(context.Background(), key, token); rErr != nil {
+				log.Printf("Could not release idempotency key: %v", rErr)
+			}
+			return res, err
+		}
+
+		msg, ok := res.(proto.Message)
+		if !ok {
+			return res, nil
+		}
+
+		stored, err := anypb.New(msg)
+		if err == nil {
+			err = i.Store.Complete(context.Background(), key, token, stored, time.Now().Add(i.TTL))
+		}
+		if err != nil {
+			// the request succeeded, so it is not failed because of the store
+			log.Printf("Could not store idempotent response: %v", err)
+		}
+
+		return res, nil
+	}
+}
+
+func replay(ctx context.Context, rec *Record, fingerprint string) (interface{}, error) {
+	if rec.Fingerprint != fingerprint {
+		return nil, status.Errorf(codes.InvalidArgument, "%s was already used with a different request", Header)
+	}
+
+	if !rec.Done {
+		return nil, status.Errorf(codes.Aborted, "a request with the same %s is in progress", Header)
+	}
+
+	res, err := anypb.UnmarshalNew(rec.Response, proto.UnmarshalOptions{})
+	if err != nil {
+		log.Printf("Could not decode stored response: %v", err)
+		return nil, status.Errorf(codes.Internal, "unexpected idempotency store error")
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ReplayedHeader, "true")); err != nil {
+		log.Printf("Could not set %s header: %v", ReplayedHeader, err)
+	}
+	return res, nil
+}
+
+func keyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(Header); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// newToken returns a random token of a request.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func fingerprint(method string, req interface{}) (string, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", errors.New("request is not a protobuf message")
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
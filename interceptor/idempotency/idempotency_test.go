@@ -0,0 +1,157 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"strings"
+	"testing"
+	"time"
+)
+
+const method = "/test.Service/Create"
+
+// headerStream records the header a handler sets.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// counter is a handler answering with the number of times it ran.
+type counter struct {
+	calls int
+	err   error
+}
+
+func (c *counter) handle(ctx context.Context, req interface{}) (interface{}, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return wrapperspb.Int64(int64(c.calls)), nil
+}
+
+// call runs the interceptor with the idempotency key and returns the header it set.
+func call(i *Interceptor, key string, req proto.Message, handler grpc.UnaryHandler) (interface{}, metadata.MD, error) {
+	stream := &headerStream{}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, key))
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+	res, err := i.Unary()(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	return res, stream.header, err
+}
+
+func TestReplaysCompletedResponse(t *testing.T) {
+	i := New(NewMemoryStore(), time.Hour, method)
+	c := &counter{}
+
+	first, header, err := call(i, "key", wrapperspb.String("blog"), c.handle)
+	if err != nil || len(header.Get(ReplayedHeader)) != 0 {
+		t.Fatalf("first request returned %v with header %v", err, header)
+	}
+	second, header, err := call(i, "key", wrapperspb.String("blog"), c.handle)
+	if err != nil {
+		t.Fatalf("retry returned %v", err)
+	}
+	if c.calls != 1 {
+		t.Fatalf("handler ran %d times, want once", c.calls)
+	}
+	if !proto.Equal(first.(proto.Message), second.(proto.Message)) {
+		t.Fatalf("retry returned %v, want the stored %v", second, first)
+	}
+	if v := header.Get(ReplayedHeader); len(v) != 1 || v[0] != "true" {
+		t.Fatalf("replayed response has header %v, want %s", header, ReplayedHeader)
+	}
+
+	// another method or no key are not replayed
+	if _, _, err := call(New(i.Store, time.Hour, "/other"), "key", wrapperspb.String("blog"), c.handle); err != nil || c.calls != 2 {
+		t.Fatalf("unlisted method returned %v after %d calls", err, c.calls)
+	}
+	if _, err := i.Unary()(context.Background(), wrapperspb.String("blog"), &grpc.UnaryServerInfo{FullMethod: method}, c.handle); err != nil || c.calls != 3 {
+		t.Fatalf("request without key returned %v after %d calls", err, c.calls)
+	}
+}
+
+func TestRejectsKeyReusedWithDifferentRequest(t *testing.T) {
+	i := New(NewMemoryStore(), time.Hour, method)
+	c := &counter{}
+	if _, _, err := call(i, "key", wrapperspb.String("blog"), c.handle); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := call(i, "key", wrapperspb.String("another blog"), c.handle)
+	if status.Code(err) != codes.InvalidArgument || c.calls != 1 {
+		t.Fatalf("reused key returned %v after %d calls, want InvalidArgument", err, c.calls)
+	}
+}
+
+func TestPendingKeyIsInProgress(t *testing.T) {
+	i := New(NewMemoryStore(), time.Hour, method)
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, _, err := call(i, "key", wrapperspb.String("blog"), func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return wrapperspb.Int64(1), nil
+		})
+		done <- err
+	}()
+	<-started
+
+	c := &counter{}
+	_, _, err := call(i, "key", wrapperspb.String("blog"), c.handle)
+	if status.Code(err) != codes.Aborted || c.calls != 0 {
+		t.Fatalf("request with a pending key returned %v after %d calls, want Aborted", err, c.calls)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first request returned %v", err)
+	}
+	if _, _, err := call(i, "key", wrapperspb.String("blog"), c.handle); err != nil || c.calls != 0 {
+		t.Fatalf("retry after completion returned %v after %d calls, want the stored response", err, c.calls)
+	}
+}
+
+func TestReleasesKeyAfterHandlerError(t *testing.T) {
+	i := New(NewMemoryStore(), time.Hour, method)
+	failure := status.Error(codes.Unavailable, "try again")
+	c := &counter{err: failure}
+	if _, _, err := call(i, "key", wrapperspb.String("blog"), c.handle); !errors.Is(err, failure) {
+		t.Fatalf("failed request returned %v, want the error of the handler", err)
+	}
+
+	c.err = nil
+	res, header, err := call(i, "key", wrapperspb.String("blog"), c.handle)
+	if err != nil || c.calls != 2 || len(header.Get(ReplayedHeader)) != 0 {
+		t.Fatalf("retry returned %v, %v after %d calls, want a new run", res, err, c.calls)
+	}
+}
+
+func TestScopeSeparatesKeys(t *testing.T) {
+	i := New(NewMemoryStore(), time.Hour, method)
+	scope := "t1"
+	i.Scope = func(context.Context) string { return scope }
+	c := &counter{}
+	call(i, "key", wrapperspb.String("blog"), c.handle)
+	scope = "t2"
+	if _, _, err := call(i, "key", wrapperspb.String("other"), c.handle); err != nil || c.calls != 2 {
+		t.Fatalf("key of another scope returned %v after %d calls, want a new run", err, c.calls)
+	}
+}
+
+func TestRejectsLongKey(t *testing.T) {
+	i := New(NewMemoryStore(), time.Hour, method)
+	if _, _, err := call(i, strings.Repeat("k", MaxKeyLength+1), wrapperspb.String("blog"), (&counter{}).handle); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("long key returned %v, want InvalidArgument", err)
+	}
+}
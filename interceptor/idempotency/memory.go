@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"container/heap"
+	"context"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records in process, it is enough for a single server instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	// expiries orders the keys by expiry, so sweeping only looks at expired
+	// ones. Entries outdated by Complete or Release are skipped.
+	expiries expiryHeap
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, token, fingerprint string, expiresAt time.Time) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if rec, ok := s.records[key]; ok {
+		copied := *rec
+		return &copied, nil
+	}
+
+	s.records[key] = &Record{Token: token, Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	heap.Push(&s.expiries, expiry{key: key, at: expiresAt})
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key, token string, response *anypb.Any, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok && rec.Token == token && !rec.Done {
+		rec.Done = true
+		rec.Response = response
+		rec.ExpiresAt = expiresAt
+		heap.Push(&s.expiries, expiry{key: key, at: expiresAt})
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok && rec.Token == token && !rec.Done {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for len(s.expiries) > 0 && !s.expiries[0].at.After(now) {
+		e := heap.Pop(&s.expiries).(expiry)
+		if rec, ok := s.records[e.key]; ok && !rec.ExpiresAt.After(now) {
+			delete(s.records, e.key)
+		}
+	}
+}
+
+type expiry struct {
+	key string
+	at  time.Time
+}
+
+// expiryHeap implements heap.Interface, the earliest expiry first.
+type expiryHeap []expiry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *expiryHeap) Push(x interface{}) {
+	*h = append(*h, x.(expiry))
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,100 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func mustBegin(t *testing.T, s *MemoryStore, key string, expiresAt time.Time, wantNew bool) {
+	t.Helper()
+	rec, err := s.Begin(context.Background(), key, "token", "fp", expiresAt)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if (rec == nil) != wantNew {
+		t.Fatalf("Begin of %s returned %+v, want a new record: %t", key, rec, wantNew)
+	}
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Now()
+
+	mustBegin(t, s, "expired", now.Add(-time.Second), true)
+	mustBegin(t, s, "expired", now.Add(time.Hour), true)
+
+	// Complete extends the expiry past the first one
+	mustBegin(t, s, "completed", now.Add(20*time.Millisecond), true)
+	if err := s.Complete(context.Background(), "completed", "token", nil, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	mustBegin(t, s, "completed", now.Add(time.Hour), false)
+
+	// a key released and begun again keeps its new expiry
+	mustBegin(t, s, "released", now.Add(20*time.Millisecond), true)
+	if err := s.Release(context.Background(), "released", "token"); err != nil {
+		t.Fatal(err)
+	}
+	mustBegin(t, s, "released", now.Add(time.Hour), true)
+	time.Sleep(30 * time.Millisecond)
+	mustBegin(t, s, "released", now.Add(time.Hour), false)
+}
+
+func TestMemoryStoreSweepsExpiredRecords(t *testing.T) {
+	s := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		mustBegin(t, s, fmt.Sprint(i), time.Now().Add(10*time.Millisecond), true)
+	}
+	time.Sleep(20 * time.Millisecond)
+	mustBegin(t, s, "new", time.Now().Add(time.Hour), true)
+	if len(s.records) != 1 || len(s.expiries) != 1 {
+		t.Fatalf("%d records and %d expiries are kept, want 1", len(s.records), len(s.expiries))
+	}
+}
+
+func TestMemoryStoreOnlyChangesLockOfToken(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	// the lock of the stale request expired and another request took the key
+	if _, err := s.Begin(ctx, "key", "stale", "fp", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Begin(ctx, "key", "owner", "fp", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Release(ctx, "key", "stale"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Complete(ctx, "key", "stale", nil, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := s.Begin(ctx, "key", "third", "fp", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec == nil || rec.Token != "owner" || rec.Done {
+		t.Fatalf("stale request changed the record of the owner to %+v", rec)
+	}
+
+	if err := s.Release(ctx, "key", "owner"); err != nil {
+		t.Fatal(err)
+	}
+	mustBegin(t, s, "key", time.Now().Add(time.Hour), true)
+}
+
+// BenchmarkBegin begins keys while many records are live.
+func BenchmarkBegin(b *testing.B) {
+	s := NewMemoryStore()
+	expiresAt := time.Now().Add(time.Hour)
+	for i := 0; i < 100000; i++ {
+		s.Begin(context.Background(), fmt.Sprint("live", i), "token", "fp", expiresAt)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Begin(context.Background(), fmt.Sprint(i), "token", "fp", expiresAt)
+	}
+}
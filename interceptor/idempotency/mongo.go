@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"time"
+)
+
+// MongoStore shares records between server instances. Expired records are
+// ignored and replaced, a TTL index on expires_at removes them eventually.
+type MongoStore struct {
+	Collection *mongo.Collection
+}
+
+type mongoRecord struct {
+	Key         string    `bson:"_id"`
+	Token       string    `bson:"token"`
+	Fingerprint string    `bson:"fingerprint"`
+	Done        bool      `bson:"done"`
+	Response    []byte    `bson:"response,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{Collection: collection}
+}
+
+func (s *MongoStore) Begin(ctx context.Context, key, token, fingerprint string, expiresAt time.Time) (*Record, error) {
+	// the second attempt runs after an expired record was removed
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := s.Collection.InsertOne(ctx, mongoRecord{Key: key, Token: token, Fingerprint: fingerprint, ExpiresAt: expiresAt})
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		var existing mongoRecord
+		err = s.Collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if existing.ExpiresAt.After(time.Now()) {
+			return existing.record()
+		}
+
+		_, err = s.Collection.DeleteOne(ctx, bson.M{"_id": key, "expires_at": existing.ExpiresAt})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("could not lock idempotency key")
+}
+
+func (s *MongoStore) Complete(ctx context.Context, key, token string, response *anypb.Any, expiresAt time.Time) error {
+	data, err := proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Collection.UpdateOne(ctx,
+		bson.M{"_id": key, "token": token, "done": false},
+		bson.M{"$set": bson.M{"done": true, "response": data, "expires_at": expiresAt}},
+	)
+	return err
+}
+
+func (s *MongoStore) Release(ctx context.Context, key, token string) error {
+	_, err := s.Collection.DeleteOne(ctx, bson.M{"_id": key, "token": token, "done": false})
+	return err
+}
+
+func (r *mongoRecord) record() (*Record, error) {
+	rec := &Record{Token: r.Token, Fingerprint: r.Fingerprint, Done: r.Done, ExpiresAt: r.ExpiresAt}
+	if r.Done {
+		rec.Response = &anypb.Any{}
+		if err := proto.Unmarshal(r.Response, rec.Response); err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
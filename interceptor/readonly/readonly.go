@@ -0,0 +1,71 @@
+// Package readonly rejects the methods that change data while the server is
+// switched to read-only mode, e.g. during maintenance of the database.
+package readonly
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync/atomic"
+)
+
+// Interceptor answers Methods with Unavailable while it is enabled, every
+// other method is served as usual.
+type Interceptor struct {
+	// Methods are full method names such as /blog.BlogService/CreateBlog.
+	Methods map[string]bool
+	// Err is returned instead of a plain Unavailable status when set.
+	Err error
+
+	enabled int32
+}
+
+func New(methods ...string) *Interceptor {
+	i := &Interceptor{Methods: make(map[string]bool, len(methods))}
+	for _, m := range methods {
+		i.Methods[m] = true
+	}
+	return i
+}
+
+// Set switches read-only mode on or off and reports whether it was on before.
+func (i *Interceptor) Set(enabled bool) bool {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	return atomic.SwapInt32(&i.enabled, v) == 1
+}
+
+func (i *Interceptor) Enabled() bool {
+	return atomic.LoadInt32(&i.enabled) == 1
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.check(info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.check(info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (i *Interceptor) check(method string) error {
+	if !i.Methods[method] || !i.Enabled() {
+		return nil
+	}
+	if i.Err != nil {
+		return i.Err
+	}
+	return status.Errorf(codes.Unavailable, "server is read-only, %s is not available until it is writable again", method)
+}
@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"context"
+	pb "github.com/dbielecki97/grpc-go-course/blog/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"testing"
+)
+
+// violations returns the fields of the BadRequest detail of err.
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("returned %v, want InvalidArgument", err)
+	}
+	var fields []string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	if len(fields) == 0 {
+		t.Fatalf("%v has no BadRequest detail", err)
+	}
+	return fields
+}
+
+func TestUnaryServer(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		req  interface{}
+		want string
+	}{
+		{"valid", &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Title: "title", Tags: []string{"go"}}}, ""},
+		{"missing message", &pb.CreateBlogRequest{}, "blog"},
+		{"nested fields", &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Tags: make([]string, 21)}}, "blog.title blog.tags"},
+		{"too long element", &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "author", Title: "title", Tags: []string{strings.Repeat("t", 51)}}}, "blog.tags[0]"},
+		{"range", &pb.ListBlogRequest{BatchSize: -1, MaxBytesPerSecond: -1}, "batch_size max_bytes_per_second"},
+		{"not a message", "request", ""},
+	} {
+		called := false
+		_, err := UnaryServer()(context.Background(), c.req, &grpc.UnaryServerInfo{FullMethod: "/test"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		})
+		if c.want == "" {
+			if err != nil || !called {
+				t.Errorf("%s: returned %v, handler called: %t", c.name, err, called)
+			}
+			continue
+		}
+		if called {
+			t.Errorf("%s: handler was called with an invalid request", c.name)
+		}
+		if got := strings.Join(violations(t, err), " "); got != c.want {
+			t.Errorf("%s: violated %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// recvStream receives a copy of req.
+type recvStream struct {
+	grpc.ServerStream
+	req proto.Message
+}
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.req)
+	return nil
+}
+
+func TestStreamServerValidatesReceivedMessages(t *testing.T) {
+	for _, c := range []struct {
+		req  proto.Message
+		want string
+	}{
+		{&pb.ListBlogRequest{BatchSize: 10}, ""},
+		{&pb.ListBlogRequest{Tag: strings.Repeat("t", 51)}, "tag"},
+	} {
+		err := StreamServer()(nil, &recvStream{req: c.req}, &grpc.StreamServerInfo{FullMethod: "/test"}, func(srv interface{}, ss grpc.ServerStream) error {
+			return ss.RecvMsg(&pb.ListBlogRequest{})
+		})
+		if c.want == "" {
+			if err != nil {
+				t.Errorf("valid message returned %v", err)
+			}
+			continue
+		}
+		if got := strings.Join(violations(t, err), " "); got != c.want {
+			t.Errorf("violated %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestUnaryClientFailsBeforeSending(t *testing.T) {
+	sent := false
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent = true
+		return nil
+	}
+	err := UnaryClient()(context.Background(), "/test", &pb.CreateBlogRequest{}, nil, nil, invoker)
+	if got := strings.Join(violations(t, err), " "); got != "blog" || sent {
+		t.Fatalf("violated %q and sent: %t, want blog without sending", got, sent)
+	}
+	if err := UnaryClient()(context.Background(), "/test", &pb.CreateBlogRequest{Blog: &pb.Blog{AuthorId: "a", Title: "t"}}, nil, nil, invoker); err != nil || !sent {
+		t.Fatalf("valid request returned %v, sent: %t", err, sent)
+	}
+}
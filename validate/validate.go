@@ -0,0 +1,214 @@
+// Package validate checks messages against the rules declared on their fields
+// with the (validate.rules) option. It is used by the validation interceptors
+// of the servers and can be called by clients before sending a request.
+package validate
+
+import (
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Error lists every violated rule of a message. It converts to an
+// InvalidArgument status with a BadRequest detail.
+type Error struct {
+	Violations []*errdetails.BadRequest_FieldViolation
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.GetField()+": "+v.GetDescription())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(codes.InvalidArgument, e.Error())
+	withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: e.Violations})
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// Validate returns an *Error when m violates any of its rules, nested messages
+// are validated too.
+func Validate(m proto.Message) error {
+	v := &validator{}
+	v.message("", m.ProtoReflect())
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &Error{Violations: v.violations}
+}
+
+type validator struct {
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.violations = append(v.violations, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) message(prefix string, m protoreflect.Message) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := string(fd.Name())
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		rules := rulesOf(fd)
+		set := m.Has(fd)
+
+		switch {
+		case fd.IsMap():
+			// maps carry no rules, their messages are still validated
+			if set && fd.MapValue().Kind() == protoreflect.MessageKind {
+				m.Get(fd).Map().Range(func(k protoreflect.MapKey, val protoreflect.Value) bool {
+					v.message(fmt.Sprintf("%s[%v]", path, k.Interface()), val.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			list := m.Get(fd).List()
+			v.list(path, fd, rules, list)
+		case !set && fd.HasPresence():
+			if rules != nil && rules.GetRequired() {
+				v.add(path, "is required")
+			}
+		default:
+			// scalars without presence are checked even when they are zero
+			v.value(path, fd, rules, m.Get(fd))
+		}
+	}
+}
+
+func (v *validator) list(path string, fd protoreflect.FieldDescriptor, rules *FieldRules, list protoreflect.List) {
+	n := uint32(list.Len())
+	if rules != nil {
+		if rules.GetRequired() && n == 0 {
+			v.add(path, "is required")
+			return
+		}
+		if rules.GetMinItems() > 0 && n < rules.GetMinItems() {
+			v.add(path, "must have at least %d elements", rules.GetMinItems())
+		}
+		if rules.GetMaxItems() > 0 && n > rules.GetMaxItems() {
+			v.add(path, "must have at most %d elements", rules.GetMaxItems())
+		}
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		v.value(fmt.Sprintf("%s[%d]", path, i), fd, rules, list.Get(i))
+	}
+}
+
+func (v *validator) value(path string, fd protoreflect.FieldDescriptor, rules *FieldRules, val protoreflect.Value) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		v.message(path, val.Message())
+	case protoreflect.StringKind:
+		v.string(path, rules, val.String())
+	case protoreflect.BytesKind:
+		if rules != nil && rules.GetRequired() && len(val.Bytes()) == 0 {
+			v.add(path, "is required")
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v.number(path, rules, float64(val.Int()), val.Int() == 0)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v.number(path, rules, float64(val.Uint()), val.Uint() == 0)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		v.number(path, rules, val.Float(), val.Float() == 0)
+	case protoreflect.EnumKind:
+		if rules != nil && rules.GetRequired() && val.Enum() == 0 {
+			v.add(path, "is required")
+		}
+	}
+}
+
+func (v *validator) string(path string, rules *FieldRules, s string) {
+	if rules == nil {
+		return
+	}
+	if s == "" {
+		// proto3 cannot tell an empty string from an unset one
+		if rules.GetRequired() {
+			v.add(path, "is required")
+		}
+		return
+	}
+
+	n := uint32(utf8.RuneCountInString(s))
+	if rules.GetMinLen() > 0 && n < rules.GetMinLen() {
+		v.add(path, "must be at least %d characters long", rules.GetMinLen())
+	}
+	if rules.GetMaxLen() > 0 && n > rules.GetMaxLen() {
+		v.add(path, "must be at most %d characters long", rules.GetMaxLen())
+	}
+	if p := rules.GetPattern(); p != "" {
+		re, err := compile(p)
+		if err != nil {
+			v.add(path, "has an invalid pattern rule: %v", err)
+		} else if !re.MatchString(s) {
+			v.add(path, "must match %s", p)
+		}
+	}
+}
+
+func (v *validator) number(path string, rules *FieldRules, n float64, zero bool) {
+	if rules == nil {
+		return
+	}
+	if zero && rules.GetRequired() {
+		v.add(path, "is required")
+		return
+	}
+
+	r := rules.GetRange()
+	if r == nil {
+		return
+	}
+	if r.Min != nil && n < r.GetMin() {
+		v.add(path, "must be at least %v", r.GetMin())
+	}
+	if r.Max != nil && n > r.GetMax() {
+		v.add(path, "must be at most %v", r.GetMax())
+	}
+}
+
+func rulesOf(fd protoreflect.FieldDescriptor) *FieldRules {
+	opts := fd.Options()
+	if opts == nil || !proto.HasExtension(opts, E_Rules) {
+		return nil
+	}
+	rules, _ := proto.GetExtension(opts, E_Rules).(*FieldRules)
+	return rules
+}
+
+var patterns sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
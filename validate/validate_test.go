@@ -0,0 +1,197 @@
+package validate
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"strings"
+	"testing"
+)
+
+// field declares a field of the test messages with its rules.
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string, rules *FieldRules) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Type:     typ.Enum(),
+		Label:    label.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	if rules != nil {
+		f.Options = &descriptorpb.FieldOptions{}
+		proto.SetExtension(f.Options, E_Rules, rules)
+	}
+	return f
+}
+
+func between(min, max float64) *Range {
+	return &Range{Min: &min, Max: &max}
+}
+
+// testItem describes a message with a field of every rule kind.
+func testItem(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		str      = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg      = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("validate_test.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"validate/validate.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("KIND_BLOG"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("Child"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, str, optional, "", &FieldRules{Required: true})},
+			},
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, optional, "", &FieldRules{Required: true, MinLen: 2, MaxLen: 5}),
+					field("slug", 2, str, optional, "", &FieldRules{Pattern: "^[a-z]+$"}),
+					field("tags", 3, str, repeated, "", &FieldRules{MinItems: 1, MaxItems: 2, MaxLen: 3}),
+					field("count", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, "", &FieldRules{Range: between(1, 10)}),
+					field("ratio", 5, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, optional, "", &FieldRules{Range: between(0, 1)}),
+					field("size", 6, descriptorpb.FieldDescriptorProto_TYPE_UINT64, optional, "", &FieldRules{Required: true}),
+					field("data", 7, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional, "", &FieldRules{Required: true}),
+					field("kind", 8, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional, ".test.Kind", &FieldRules{Required: true}),
+					field("child", 9, msg, optional, ".test.Child", &FieldRules{Required: true}),
+					field("children", 10, msg, repeated, ".test.Child", &FieldRules{Required: true}),
+					field("by_name", 11, msg, repeated, ".test.Item.ByNameEntry", nil),
+					field("note", 12, str, optional, "", nil),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("ByNameEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, str, optional, "", nil),
+						field("value", 2, msg, optional, ".test.Child", nil),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("could not build the test message: %v", err)
+	}
+	return fd.Messages().ByName("Item")
+}
+
+// newItem returns a valid message of desc.
+func newItem(desc protoreflect.MessageDescriptor) *dynamicpb.Message {
+	m := dynamicpb.NewMessage(desc)
+	fields := desc.Fields()
+	m.Set(fields.ByName("name"), protoreflect.ValueOfString("item"))
+	m.Set(fields.ByName("slug"), protoreflect.ValueOfString("item"))
+	m.Mutable(fields.ByName("tags")).List().Append(protoreflect.ValueOfString("go"))
+	m.Set(fields.ByName("count"), protoreflect.ValueOfInt32(5))
+	m.Set(fields.ByName("ratio"), protoreflect.ValueOfFloat64(0.5))
+	m.Set(fields.ByName("size"), protoreflect.ValueOfUint64(1))
+	m.Set(fields.ByName("data"), protoreflect.ValueOfBytes([]byte{1}))
+	m.Set(fields.ByName("kind"), protoreflect.ValueOfEnum(1))
+	m.Set(fields.ByName("child"), protoreflect.ValueOfMessage(newChild(desc, "child")))
+	m.Mutable(fields.ByName("children")).List().Append(protoreflect.ValueOfMessage(newChild(desc, "first")))
+	return m
+}
+
+func newChild(item protoreflect.MessageDescriptor, id string) protoreflect.Message {
+	c := dynamicpb.NewMessage(item.Fields().ByName("child").Message())
+	c.Set(c.Descriptor().Fields().ByName("id"), protoreflect.ValueOfString(id))
+	return c
+}
+
+func TestRules(t *testing.T) {
+	desc := testItem(t)
+	fields := desc.Fields()
+	str := func(name protoreflect.Name, s string) func(m *dynamicpb.Message) {
+		return func(m *dynamicpb.Message) { m.Set(fields.ByName(name), protoreflect.ValueOfString(s)) }
+	}
+	unset := func(name protoreflect.Name) func(m *dynamicpb.Message) {
+		return func(m *dynamicpb.Message) { m.Clear(fields.ByName(name)) }
+	}
+	tags := func(tags ...string) func(m *dynamicpb.Message) {
+		return func(m *dynamicpb.Message) {
+			m.Clear(fields.ByName("tags"))
+			list := m.Mutable(fields.ByName("tags")).List()
+			for _, tag := range tags {
+				list.Append(protoreflect.ValueOfString(tag))
+			}
+		}
+	}
+
+	for _, c := range []struct {
+		name   string
+		change func(m *dynamicpb.Message)
+		want   string
+	}{
+		{"valid", func(*dynamicpb.Message) {}, ""},
+		{"required string", unset("name"), "name: is required"},
+		{"min_len", str("name", "a"), "name: must be at least 2 characters long"},
+		{"max_len", str("name", "toolong"), "name: must be at most 5 characters long"},
+		{"max_len counts characters", str("name", "żółwi"), ""},
+		{"pattern", str("slug", "Item"), "slug: must match ^[a-z]+$"},
+		{"pattern of an empty string", unset("slug"), ""},
+		{"without rules", str("note", strings.Repeat("n", 1000)), ""},
+		{"min_items", tags(), "tags: must have at least 1 elements"},
+		{"max_items", tags("a", "b", "c"), "tags: must have at most 2 elements"},
+		{"rules of elements", tags("go", "grpc"), "tags[1]: must be at most 3 characters long"},
+		{"range min", func(m *dynamicpb.Message) { m.Set(fields.ByName("count"), protoreflect.ValueOfInt32(-1)) }, "count: must be at least 1"},
+		{"range of zero", unset("count"), "count: must be at least 1"},
+		{"range max", func(m *dynamicpb.Message) { m.Set(fields.ByName("count"), protoreflect.ValueOfInt32(11)) }, "count: must be at most 10"},
+		{"range of floats", func(m *dynamicpb.Message) { m.Set(fields.ByName("ratio"), protoreflect.ValueOfFloat64(1.5)) }, "ratio: must be at most 1"},
+		{"required number", unset("size"), "size: is required"},
+		{"required bytes", unset("data"), "data: is required"},
+		{"required enum", unset("kind"), "kind: is required"},
+		{"required message", unset("child"), "child: is required"},
+		{"nested message", func(m *dynamicpb.Message) {
+			m.Set(fields.ByName("child"), protoreflect.ValueOfMessage(newChild(desc, "")))
+		}, "child.id: is required"},
+		{"required list", unset("children"), "children: is required"},
+		{"message of a list", func(m *dynamicpb.Message) {
+			m.Mutable(fields.ByName("children")).List().Append(protoreflect.ValueOfMessage(newChild(desc, "")))
+		}, "children[1].id: is required"},
+		{"message of a map", func(m *dynamicpb.Message) {
+			m.Mutable(fields.ByName("by_name")).Map().Set(protoreflect.ValueOfString("k").MapKey(), protoreflect.ValueOfMessage(newChild(desc, "")))
+		}, "by_name[k].id: is required"},
+		{"every violation", func(m *dynamicpb.Message) {
+			str("name", "a")(m)
+			unset("data")(m)
+		}, "name: must be at least 2 characters long; data: is required"},
+	} {
+		m := newItem(desc)
+		c.change(m)
+		err := Validate(m)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != c.want {
+			t.Errorf("%s: Validate returned %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	v := &validator{}
+	v.string("field", &FieldRules{Pattern: "("}, "value")
+	if len(v.violations) != 1 || !strings.HasPrefix(v.violations[0].GetDescription(), "has an invalid pattern rule") {
+		t.Fatalf("invalid pattern added %v", v.violations)
+	}
+}